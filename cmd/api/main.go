@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"mf-analytics-service/internal/api"
+	"mf-analytics-service/internal/apikey"
 	"mf-analytics-service/internal/config"
+	"mf-analytics-service/internal/events"
 	"mf-analytics-service/internal/logging"
+	"mf-analytics-service/internal/ratelimiter"
 	"mf-analytics-service/internal/storage"
 )
 
@@ -28,6 +31,28 @@ func main() {
 		logger.Error("config validate", "error", err)
 		os.Exit(1)
 	}
+	windows, err := appCfg.AnalyticsWindows()
+	if err != nil {
+		logger.Error("analytics windows", "error", err)
+		os.Exit(1)
+	}
+	riskFreePct, err := appCfg.RiskFreeRatePct()
+	if err != nil {
+		logger.Error("risk-free rate", "error", err)
+		os.Exit(1)
+	}
+
+	cacheTTL, cacheEntries, err := appCfg.ResponseCache()
+	if err != nil {
+		logger.Error("response cache", "error", err)
+		os.Exit(1)
+	}
+
+	syncSchedule, err := appCfg.IncrementalSchedule()
+	if err != nil {
+		logger.Error("incremental schedule", "error", err)
+		os.Exit(1)
+	}
 
 	cfg := storage.Config{DatabaseURL: appCfg.DatabaseURL}
 
@@ -38,8 +63,30 @@ func main() {
 	}
 	defer pool.Close()
 
+	opts := []api.Option{
+		api.WithWindows(windows),
+		api.WithRiskFreeRate(riskFreePct),
+		api.WithResponseCache(cacheTTL, cacheEntries, events.SyncCompleted),
+		api.WithSyncSchedule(syncSchedule),
+	}
+	if appCfg.Auth.Disabled {
+		logger.Warn("api key authentication disabled")
+	} else {
+		keyCfg, err := appCfg.KeyRateLimiterConfig()
+		if err != nil {
+			logger.Error("key rate limiter config", "error", err)
+			os.Exit(1)
+		}
+		keyLimiter, err := ratelimiter.New(pool, keyCfg)
+		if err != nil {
+			logger.Error("key rate limiter", "error", err)
+			os.Exit(1)
+		}
+		opts = append(opts, api.WithAPIKeys(apikey.NewStore(pool), keyLimiter))
+	}
+
 	addr := appCfg.HTTPAddr
-	srv := api.NewServer(pool, logger)
+	srv := api.NewServer(pool, logger, opts...)
 
 	go func() {
 		logger.Info("api listening", "addr", addr)
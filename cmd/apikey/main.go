@@ -0,0 +1,129 @@
+// Command apikey creates, lists and revokes the API keys cmd/api requires. A new key is printed
+// once; only its hash is stored.
+//
+//	go run ./cmd/apikey create -name dashboard -scopes read
+//	go run ./cmd/apikey create -name ops -scopes read,sync:trigger
+//	go run ./cmd/apikey list
+//	go run ./cmd/apikey revoke -id 3f9c0a1b2c3d4e5f
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mf-analytics-service/internal/apikey"
+	"mf-analytics-service/internal/config"
+	"mf-analytics-service/internal/logging"
+	"mf-analytics-service/internal/storage"
+)
+
+const usage = "usage: apikey create -name NAME -scopes SCOPES | list | revoke -id ID"
+
+func main() {
+	ctx := context.Background()
+	logger := logging.New(logging.Options{Service: "apikey"})
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	name := fs.String("name", "", "who or what the key is for (create)")
+	scopes := fs.String("scopes", apikey.ScopeRead, "comma-separated scopes: "+strings.Join(apikey.Scopes, ", ")+" (create)")
+	id := fs.String("id", "", "key id to revoke (revoke)")
+	_ = fs.Parse(args)
+
+	switch cmd {
+	case "create":
+		if err := apikey.ValidateScopes(splitScopes(*scopes)); err != nil {
+			logger.Error("invalid -scopes", "error", err)
+			os.Exit(2)
+		}
+		if strings.TrimSpace(*name) == "" {
+			logger.Error("-name is required")
+			os.Exit(2)
+		}
+	case "revoke":
+		if *id == "" {
+			logger.Error("-id is required")
+			os.Exit(2)
+		}
+	case "list":
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	appCfg, err := config.Load()
+	if err != nil {
+		logger.Error("config load", "error", err)
+		os.Exit(1)
+	}
+	pool, err := storage.NewPool(ctx, storage.Config{DatabaseURL: appCfg.DatabaseURL})
+	if err != nil {
+		logger.Error("db pool", "error", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	store := apikey.NewStore(pool)
+
+	switch cmd {
+	case "create":
+		key, plaintext, err := store.Create(ctx, *name, splitScopes(*scopes))
+		if err != nil {
+			logger.Error("create key", "error", err)
+			pool.Close()
+			os.Exit(1)
+		}
+		logger.Info("key created", "key_id", key.ID, "name", key.Name, "scopes", strings.Join(key.Scopes, ","))
+		// The plaintext goes to stdout alone so it can be piped into a secret store.
+		fmt.Println(plaintext)
+	case "revoke":
+		err := store.Revoke(ctx, *id)
+		if errors.Is(err, apikey.ErrNotFound) {
+			logger.Error("no active key with this id", "key_id", *id)
+			pool.Close()
+			os.Exit(1)
+		}
+		if err != nil {
+			logger.Error("revoke key", "error", err)
+			pool.Close()
+			os.Exit(1)
+		}
+		logger.Info("key revoked", "key_id", *id)
+	case "list":
+		keys, err := store.List(ctx)
+		if err != nil {
+			logger.Error("list keys", "error", err)
+			pool.Close()
+			os.Exit(1)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tCREATED\tREVOKED")
+		for _, k := range keys {
+			revoked := "-"
+			if !k.RevokedAt.IsZero() {
+				revoked = k.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, strings.Join(k.Scopes, ","), k.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		_ = tw.Flush()
+	}
+}
+
+func splitScopes(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
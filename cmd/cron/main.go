@@ -14,6 +14,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/robfig/cron/v3"
 
+	"mf-analytics-service/internal/analytics"
 	"mf-analytics-service/internal/config"
 	"mf-analytics-service/internal/db"
 	"mf-analytics-service/internal/logging"
@@ -50,26 +51,37 @@ func main() {
 	}
 	defer pool.Close()
 
-	sched := os.Getenv("INCREMENTAL_CRON")
-	if sched == "" {
-		sched = "0 2 * * *" // daily 02:00
+	incremental, err := appCfg.IncrementalSchedule()
+	if err != nil {
+		logger.Error("cron schedule", "error", err)
+		os.Exit(1)
 	}
-
-	loc := time.UTC
-	if tz := os.Getenv("TZ"); tz != "" {
-		if l, err := time.LoadLocation(tz); err == nil {
-			loc = l
-		}
+	corrSched := os.Getenv("CORRELATION_CRON")
+	if corrSched == "" {
+		corrSched = "0 5 * * *" // daily 05:00, after the incremental sync has usually drained
+	}
+	corrCfg, err := appCfg.CorrelationConfig()
+	if err != nil {
+		logger.Error("correlation config", "error", err)
+		os.Exit(1)
 	}
 
-	c := cron.New(cron.WithLocation(loc))
-	_, err = c.AddFunc(sched, func() {
+	c := cron.New(cron.WithLocation(config.Location()))
+	c.Schedule(incremental, cron.FuncJob(func() {
 		if err := enqueueIncremental(ctx, pool, logger); err != nil {
 			logger.Warn("enqueue incremental", "error", err)
 		}
+	}))
+	_, err = c.AddFunc(corrSched, func() {
+		started := time.Now()
+		if err := analytics.RefreshCorrelations(ctx, pool, corrCfg); err != nil {
+			logger.Warn("refresh correlations", "error", err)
+			return
+		}
+		logger.Info("refreshed correlations", "duration_ms", time.Since(started).Milliseconds())
 	})
 	if err != nil {
-		logger.Error("cron schedule", "error", err)
+		logger.Error("cron schedule correlations", "error", err)
 		os.Exit(1)
 	}
 	c.Start()
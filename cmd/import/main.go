@@ -0,0 +1,72 @@
+// Command import loads an investor's transactions from a local CSV file or CAS text export into
+// `user_transactions`.
+//
+//	go run ./cmd/import -user alice -format cas -file statement.txt
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"mf-analytics-service/internal/config"
+	"mf-analytics-service/internal/investor"
+	"mf-analytics-service/internal/logging"
+	"mf-analytics-service/internal/storage"
+)
+
+func main() {
+	userID := flag.String("user", "", "user id the transactions belong to")
+	format := flag.String("format", investor.SourceCAS, "file format: cas|csv")
+	path := flag.String("file", "", "path of the statement to import")
+	flag.Parse()
+
+	ctx := context.Background()
+	logger := logging.New(logging.Options{Service: "import"})
+
+	if !investor.ValidUserID(*userID) {
+		logger.Error("invalid -user", "error", investor.ErrInvalidUserID)
+		os.Exit(2)
+	}
+	parse := investor.ParseCAS
+	switch *format {
+	case investor.SourceCAS:
+	case investor.SourceCSV:
+		parse = investor.ParseCSV
+	default:
+		logger.Error("-format must be cas|csv", "format", *format)
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		logger.Error("open statement", "error", err)
+		os.Exit(1)
+	}
+	txns, err := parse(f)
+	_ = f.Close()
+	if err != nil {
+		logger.Error("parse statement", "file", *path, "error", err)
+		os.Exit(1)
+	}
+
+	appCfg, err := config.Load()
+	if err != nil {
+		logger.Error("config load", "error", err)
+		os.Exit(1)
+	}
+	pool, err := storage.NewPool(ctx, storage.Config{DatabaseURL: appCfg.DatabaseURL})
+	if err != nil {
+		logger.Error("db pool", "error", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	n, err := investor.Import(ctx, pool, *userID, *format, txns)
+	if err != nil {
+		logger.Error("import", "error", err)
+		pool.Close()
+		os.Exit(1)
+	}
+	logger.Info("import complete", "user_id", *userID, "parsed", len(txns), "imported", n, "duplicates", int64(len(txns))-n)
+}
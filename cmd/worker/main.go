@@ -37,6 +37,16 @@ func main() {
 		logger.Error("config validate", "error", err)
 		os.Exit(1)
 	}
+	windows, err := appCfg.AnalyticsWindows()
+	if err != nil {
+		logger.Error("analytics windows", "error", err)
+		os.Exit(1)
+	}
+	riskFreePct, err := appCfg.RiskFreeRatePct()
+	if err != nil {
+		logger.Error("risk-free rate", "error", err)
+		os.Exit(1)
+	}
 
 	pool, err := storage.NewPool(ctx, storage.Config{DatabaseURL: appCfg.DatabaseURL})
 	if err != nil {
@@ -66,7 +76,13 @@ func main() {
 		}
 	}
 
-	runner := pipeline.NewBackfillRunner(pool, mf, staleAfter, logger)
+	runner := pipeline.NewBackfillRunner(
+		pool, mf, staleAfter, logger,
+		pipeline.WithWindows(windows),
+		pipeline.WithRiskFreeRate(riskFreePct),
+		pipeline.WithIncrementalAnalytics(appCfg.Analytics.Incremental),
+		pipeline.WithHistoryRetention(time.Duration(appCfg.Analytics.HistoryRetentionDays)*24*time.Hour),
+	)
 
 	pollEvery := 2 * time.Second
 	for {
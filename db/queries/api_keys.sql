@@ -0,0 +1,19 @@
+-- name: CreateAPIKey :exec
+INSERT INTO api_keys (key_id, name, secret_hash, scopes, created_at)
+VALUES ($1, $2, $3, $4, NOW());
+
+-- name: GetAPIKey :one
+SELECT *
+FROM api_keys
+WHERE key_id = $1;
+
+-- name: ListAPIKeys :many
+SELECT *
+FROM api_keys
+ORDER BY created_at ASC, key_id ASC;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE key_id = $1
+  AND revoked_at IS NULL;
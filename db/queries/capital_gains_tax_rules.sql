@@ -0,0 +1,6 @@
+-- name: ListCapitalGainsTaxRules :many
+SELECT version, asset_class, effective_from, ltcg_min_holding_months, stcg_rate, ltcg_rate,
+       ltcg_exemption, cess_rate, grandfather_date, description
+FROM capital_gains_tax_rules
+WHERE asset_class = $1
+ORDER BY effective_from ASC;
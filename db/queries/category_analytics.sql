@@ -0,0 +1,96 @@
+-- name: DeleteCategoryAnalytics :exec
+DELETE FROM category_analytics;
+
+-- name: InsertCategoryAnalytics :exec
+INSERT INTO category_analytics (category, "window", metric, fund_count, mean, p25, median, p75, computed_at)
+SELECT
+  f.category,
+  fa."window",
+  m.metric,
+  COUNT(*)::int,
+  AVG(m.value)::float8,
+  percentile_cont(0.25) WITHIN GROUP (ORDER BY m.value),
+  percentile_cont(0.50) WITHIN GROUP (ORDER BY m.value),
+  percentile_cont(0.75) WITHIN GROUP (ORDER BY m.value),
+  NOW()
+FROM fund_analytics fa
+JOIN funds f ON f.scheme_code = fa.scheme_code
+CROSS JOIN LATERAL (VALUES
+  ('rolling_median', fa.rolling_median::float8),
+  ('rolling_p25', fa.rolling_p25::float8),
+  ('rolling_p75', fa.rolling_p75::float8),
+  ('rolling_min', fa.rolling_min::float8),
+  ('rolling_max', fa.rolling_max::float8),
+  ('max_drawdown', fa.max_drawdown::float8),
+  ('cagr_median', fa.cagr_median::float8),
+  ('cagr_min', fa.cagr_min::float8),
+  ('cagr_max', fa.cagr_max::float8),
+  ('volatility', fa.volatility::float8),
+  ('sharpe', fa.sharpe::float8),
+  ('consistency', fa.consistency::float8)
+) AS m(metric, value)
+WHERE m.value IS NOT NULL
+GROUP BY f.category, fa."window", m.metric;
+
+-- name: DeleteFundCategoryRanks :exec
+DELETE FROM fund_category_ranks;
+
+-- name: InsertFundCategoryRanks :exec
+INSERT INTO fund_category_ranks (scheme_code, "window", metric, category, percentile, quartile, computed_at)
+SELECT
+  r.scheme_code,
+  r."window",
+  r.metric,
+  r.category,
+  r.percentile,
+  CASE
+    WHEN r.percentile >= 75 THEN 1
+    WHEN r.percentile >= 50 THEN 2
+    WHEN r.percentile >= 25 THEN 3
+    ELSE 4
+  END::smallint,
+  NOW()
+FROM (
+  SELECT
+    fa.scheme_code,
+    fa."window",
+    m.metric,
+    f.category,
+    CASE
+      WHEN COUNT(*) OVER w = 1 THEN 100.0
+      ELSE (percent_rank() OVER (w ORDER BY CASE WHEN m.higher_is_better THEN m.value ELSE -m.value END) * 100)::float8
+    END AS percentile
+  FROM fund_analytics fa
+  JOIN funds f ON f.scheme_code = fa.scheme_code
+  CROSS JOIN LATERAL (VALUES
+    ('rolling_median', fa.rolling_median::float8, true),
+    ('rolling_p25', fa.rolling_p25::float8, true),
+    ('rolling_p75', fa.rolling_p75::float8, true),
+    ('rolling_min', fa.rolling_min::float8, true),
+    ('rolling_max', fa.rolling_max::float8, true),
+    ('max_drawdown', fa.max_drawdown::float8, true),
+    ('cagr_median', fa.cagr_median::float8, true),
+    ('cagr_min', fa.cagr_min::float8, true),
+    ('cagr_max', fa.cagr_max::float8, true),
+    ('volatility', fa.volatility::float8, false),
+    ('sharpe', fa.sharpe::float8, true),
+    ('consistency', fa.consistency::float8, true)
+  ) AS m(metric, value, higher_is_better)
+  WHERE m.value IS NOT NULL
+  WINDOW w AS (PARTITION BY f.category, fa."window", m.metric)
+) r;
+
+-- name: ListCategoryAnalytics :many
+SELECT *
+FROM category_analytics
+WHERE category = $1
+  AND "window" = $2
+ORDER BY metric ASC;
+
+-- name: ListFundCategoryRanks :many
+SELECT *
+FROM fund_category_ranks
+WHERE scheme_code = $1
+  AND "window" = $2
+ORDER BY metric ASC;
+
@@ -5,6 +5,9 @@ INSERT INTO fund_analytics (
   max_drawdown,
   cagr_min, cagr_max, cagr_median,
   data_start_date, data_end_date, nav_points, rolling_periods,
+  distribution,
+  volatility, sharpe, consistency,
+  risk_free_rate,
   computed_at
 )
 VALUES (
@@ -13,6 +16,9 @@ VALUES (
   $8,
   $9, $10, $11,
   $12, $13, $14, $15,
+  $16,
+  $17, $18, $19,
+  $20,
   NOW()
 )
 ON CONFLICT (scheme_code, "window") DO UPDATE SET
@@ -29,6 +35,11 @@ ON CONFLICT (scheme_code, "window") DO UPDATE SET
   data_end_date = EXCLUDED.data_end_date,
   nav_points = EXCLUDED.nav_points,
   rolling_periods = EXCLUDED.rolling_periods,
+  distribution = EXCLUDED.distribution,
+  volatility = EXCLUDED.volatility,
+  sharpe = EXCLUDED.sharpe,
+  consistency = EXCLUDED.consistency,
+  risk_free_rate = EXCLUDED.risk_free_rate,
   computed_at = NOW();
 
 -- name: GetFundAnalytics :one
@@ -37,53 +48,125 @@ FROM fund_analytics
 WHERE scheme_code = $1
   AND "window" = $2;
 
--- name: RankFundsByMedianReturn :many
+-- name: GetFundAnalyticsVersion :one
+-- The newest computation and NAV date over the windows; with the row count they change whenever
+-- any fund's analytics in those windows do.
+SELECT
+  MAX(computed_at)::timestamp AS computed_at,
+  MAX(data_end_date)::date AS data_end_date,
+  COUNT(*) AS analytics_rows
+FROM fund_analytics
+WHERE "window" = ANY(@windows::text[]);
+
+-- name: ListFundAnalyticsForSchemes :many
+SELECT *
+FROM fund_analytics
+WHERE scheme_code = ANY(@scheme_codes::text[])
+  AND "window" = ANY(@windows::text[])
+ORDER BY scheme_code ASC, "window" ASC;
+
+-- name: ListRankCandidates :many
 SELECT
   fa.scheme_code,
   f.scheme_name,
   f.amc,
   f.category,
   fa."window",
+  fa.rolling_min,
+  fa.rolling_max,
   fa.rolling_median,
+  fa.rolling_p25,
+  fa.rolling_p75,
   fa.max_drawdown,
+  fa.cagr_min,
+  fa.cagr_max,
+  fa.cagr_median,
+  fa.volatility,
+  fa.sharpe,
+  fa.consistency,
   nav.nav_value AS current_nav,
   nav.nav_date AS last_updated
 FROM fund_analytics fa
 JOIN funds f ON f.scheme_code = fa.scheme_code
-LEFT JOIN LATERAL (
-  SELECT nh.nav_value, nh.nav_date
-  FROM nav_history nh
-  WHERE nh.scheme_code = fa.scheme_code
-  ORDER BY nh.nav_date DESC
-  LIMIT 1
-) nav ON true
-WHERE f.category = $1
-  AND fa."window" = $2
-ORDER BY fa.rolling_median DESC NULLS LAST
-LIMIT $3;
+LEFT JOIN fund_latest_nav nav ON nav.scheme_code = fa.scheme_code
+WHERE fa."window" = ANY(@windows::text[])
+  AND (sqlc.narg('categories')::text[] IS NULL OR f.category = ANY(sqlc.narg('categories')::text[]))
+  AND (sqlc.narg('amcs')::text[] IS NULL OR f.amc = ANY(sqlc.narg('amcs')::text[]))
+ORDER BY fa.scheme_code ASC, fa."window" ASC;
 
--- name: RankFundsByMaxDrawdown :many
+-- name: ListPreviousRankCandidates :many
 SELECT
-  fa.scheme_code,
-  f.scheme_name,
-  f.amc,
+  fp.scheme_code,
   f.category,
-  fa."window",
-  fa.rolling_median,
-  fa.max_drawdown,
-  nav.nav_value AS current_nav,
-  nav.nav_date AS last_updated
-FROM fund_analytics fa
-JOIN funds f ON f.scheme_code = fa.scheme_code
-LEFT JOIN LATERAL (
-  SELECT nh.nav_value, nh.nav_date
-  FROM nav_history nh
-  WHERE nh.scheme_code = fa.scheme_code
-  ORDER BY nh.nav_date DESC
-  LIMIT 1
-) nav ON true
-WHERE f.category = $1
-  AND fa."window" = $2
-ORDER BY fa.max_drawdown ASC NULLS LAST
-LIMIT $3;
+  fp."window",
+  fp.rolling_min,
+  fp.rolling_max,
+  fp.rolling_median,
+  fp.rolling_p25,
+  fp.rolling_p75,
+  fp.max_drawdown,
+  fp.cagr_min,
+  fp.cagr_max,
+  fp.cagr_median,
+  fp.volatility,
+  fp.sharpe,
+  fp.consistency
+FROM fund_analytics_previous fp
+JOIN funds f ON f.scheme_code = fp.scheme_code
+WHERE fp."window" = ANY(@windows::text[])
+  AND (sqlc.narg('categories')::text[] IS NULL OR f.category = ANY(sqlc.narg('categories')::text[]))
+  AND (sqlc.narg('amcs')::text[] IS NULL OR f.amc = ANY(sqlc.narg('amcs')::text[]))
+ORDER BY fp.scheme_code ASC, fp."window" ASC;
+
+-- name: SnapshotPreviousFundAnalytics :exec
+-- Keeps the current row as the previous computation, but only when the new data end date moves
+-- past it, so recomputing on unchanged data doesn't erase the comparison point.
+INSERT INTO fund_analytics_previous (
+  scheme_code, "window",
+  rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75,
+  max_drawdown,
+  cagr_min, cagr_max, cagr_median,
+  volatility, sharpe, consistency,
+  data_end_date, computed_at
+)
+SELECT
+  scheme_code, "window",
+  rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75,
+  max_drawdown,
+  cagr_min, cagr_max, cagr_median,
+  volatility, sharpe, consistency,
+  data_end_date, computed_at
+FROM fund_analytics
+WHERE scheme_code = @scheme_code
+  AND "window" = @window
+  AND data_end_date < @new_data_end_date::date
+ON CONFLICT (scheme_code, "window") DO UPDATE SET
+  rolling_min = EXCLUDED.rolling_min,
+  rolling_max = EXCLUDED.rolling_max,
+  rolling_median = EXCLUDED.rolling_median,
+  rolling_p25 = EXCLUDED.rolling_p25,
+  rolling_p75 = EXCLUDED.rolling_p75,
+  max_drawdown = EXCLUDED.max_drawdown,
+  cagr_min = EXCLUDED.cagr_min,
+  cagr_max = EXCLUDED.cagr_max,
+  cagr_median = EXCLUDED.cagr_median,
+  volatility = EXCLUDED.volatility,
+  sharpe = EXCLUDED.sharpe,
+  consistency = EXCLUDED.consistency,
+  data_end_date = EXCLUDED.data_end_date,
+  computed_at = EXCLUDED.computed_at;
+
+-- name: UpsertFundAnalyticsState :exec
+INSERT INTO fund_analytics_state (scheme_code, "window", last_end_date, worst_drawdown, updated_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (scheme_code, "window") DO UPDATE SET
+  last_end_date = EXCLUDED.last_end_date,
+  worst_drawdown = EXCLUDED.worst_drawdown,
+  updated_at = NOW();
+
+-- name: GetFundAnalyticsState :one
+SELECT *
+FROM fund_analytics_state
+WHERE scheme_code = $1
+  AND "window" = $2;
 
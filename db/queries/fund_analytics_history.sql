@@ -0,0 +1,123 @@
+-- name: SnapshotFundAnalyticsHistory :exec
+-- Copies the current fund_analytics row into the history, one snapshot per data end date.
+INSERT INTO fund_analytics_history (
+  scheme_code, "window", as_of,
+  rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75,
+  max_drawdown,
+  cagr_min, cagr_max, cagr_median,
+  volatility, sharpe, consistency,
+  computed_at
+)
+SELECT
+  scheme_code, "window", data_end_date,
+  rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75,
+  max_drawdown,
+  cagr_min, cagr_max, cagr_median,
+  volatility, sharpe, consistency,
+  computed_at
+FROM fund_analytics
+WHERE scheme_code = $1
+  AND "window" = $2
+  AND data_end_date IS NOT NULL
+ON CONFLICT (scheme_code, "window", as_of) DO UPDATE SET
+  rolling_min = EXCLUDED.rolling_min,
+  rolling_max = EXCLUDED.rolling_max,
+  rolling_median = EXCLUDED.rolling_median,
+  rolling_p25 = EXCLUDED.rolling_p25,
+  rolling_p75 = EXCLUDED.rolling_p75,
+  max_drawdown = EXCLUDED.max_drawdown,
+  cagr_min = EXCLUDED.cagr_min,
+  cagr_max = EXCLUDED.cagr_max,
+  cagr_median = EXCLUDED.cagr_median,
+  volatility = EXCLUDED.volatility,
+  sharpe = EXCLUDED.sharpe,
+  consistency = EXCLUDED.consistency,
+  computed_at = EXCLUDED.computed_at;
+
+-- name: DeleteFundAnalyticsHistoryBefore :execrows
+DELETE FROM fund_analytics_history
+WHERE as_of < $1;
+
+-- name: ListFundAnalyticsHistoryDates :many
+SELECT as_of
+FROM fund_analytics_history
+WHERE scheme_code = $1
+  AND "window" = $2
+  AND (sqlc.narg('from_date')::date IS NULL OR as_of >= sqlc.narg('from_date')::date)
+  AND (sqlc.narg('to_date')::date IS NULL OR as_of <= sqlc.narg('to_date')::date)
+ORDER BY as_of ASC;
+
+-- name: ListCategoryHistorySnapshots :many
+-- For each date, the latest snapshot on or before it of every fund in the given fund's category.
+SELECT
+  d.as_of::date AS snapshot_date,
+  h.scheme_code,
+  f.category,
+  h."window",
+  h.rolling_min,
+  h.rolling_max,
+  h.rolling_median,
+  h.rolling_p25,
+  h.rolling_p75,
+  h.max_drawdown,
+  h.cagr_min,
+  h.cagr_max,
+  h.cagr_median,
+  h.volatility,
+  h.sharpe,
+  h.consistency
+FROM unnest(@as_of_dates::date[]) AS d(as_of)
+CROSS JOIN LATERAL (
+  SELECT DISTINCT ON (fh.scheme_code) fh.*
+  FROM fund_analytics_history fh
+  JOIN funds pf ON pf.scheme_code = fh.scheme_code
+  WHERE pf.category = (SELECT category FROM funds WHERE funds.scheme_code = @scheme_code)
+    AND fh."window" = @window
+    AND fh.as_of <= d.as_of
+  ORDER BY fh.scheme_code, fh.as_of DESC
+) h
+JOIN funds f ON f.scheme_code = h.scheme_code
+ORDER BY d.as_of ASC, h.scheme_code ASC;
+
+-- name: ListRankCandidatesAsOf :many
+-- Same shape as ListRankCandidates, from each fund's latest snapshot on or before as_of.
+SELECT
+  h.scheme_code,
+  f.scheme_name,
+  f.amc,
+  f.category,
+  h."window",
+  h.rolling_min,
+  h.rolling_max,
+  h.rolling_median,
+  h.rolling_p25,
+  h.rolling_p75,
+  h.max_drawdown,
+  h.cagr_min,
+  h.cagr_max,
+  h.cagr_median,
+  h.volatility,
+  h.sharpe,
+  h.consistency,
+  nav.nav_value AS current_nav,
+  nav.nav_date AS last_updated
+FROM (
+  SELECT DISTINCT ON (fh.scheme_code, fh."window") fh.*
+  FROM fund_analytics_history fh
+  WHERE fh."window" = ANY(@windows::text[])
+    AND fh.as_of <= @as_of::date
+  ORDER BY fh.scheme_code, fh."window", fh.as_of DESC
+) h
+JOIN funds f ON f.scheme_code = h.scheme_code
+LEFT JOIN LATERAL (
+  SELECT nh.nav_value, nh.nav_date
+  FROM nav_history nh
+  WHERE nh.scheme_code = h.scheme_code
+    AND nh.nav_date <= @as_of::date
+  ORDER BY nh.nav_date DESC
+  LIMIT 1
+) nav ON true
+WHERE (sqlc.narg('categories')::text[] IS NULL OR f.category = ANY(sqlc.narg('categories')::text[]))
+  AND (sqlc.narg('amcs')::text[] IS NULL OR f.amc = ANY(sqlc.narg('amcs')::text[]))
+ORDER BY h.scheme_code ASC, h."window" ASC;
+
@@ -0,0 +1,47 @@
+-- name: DeleteFundCorrelations :exec
+DELETE FROM fund_correlations;
+
+-- name: InsertFundCorrelations :exec
+INSERT INTO fund_correlations (
+  "window", frequency, scheme_code_a, scheme_code_b,
+  correlation, observations, sufficient, period_start, period_end, computed_at
+)
+SELECT
+  @window::text,
+  @frequency::text,
+  t.scheme_code_a,
+  t.scheme_code_b,
+  NULLIF(t.correlation, 'NaN'::float8),
+  t.observations,
+  t.sufficient,
+  t.period_start,
+  t.period_end,
+  NOW()
+FROM unnest(
+  @scheme_codes_a::text[],
+  @scheme_codes_b::text[],
+  @correlations::float8[],
+  @observations::int[],
+  @sufficient::boolean[],
+  @period_starts::date[],
+  @period_ends::date[]
+) AS t(scheme_code_a, scheme_code_b, correlation, observations, sufficient, period_start, period_end);
+
+-- name: ListFundCorrelations :many
+-- Pairs within one window and frequency; with a category, only pairs where both funds are in it.
+SELECT
+  fc.scheme_code_a,
+  fc.scheme_code_b,
+  fc.correlation,
+  fc.observations,
+  fc.sufficient,
+  fc.period_start,
+  fc.period_end,
+  fc.computed_at
+FROM fund_correlations fc
+JOIN funds fa ON fa.scheme_code = fc.scheme_code_a
+JOIN funds fb ON fb.scheme_code = fc.scheme_code_b
+WHERE fc."window" = @window
+  AND fc.frequency = @frequency
+  AND (sqlc.narg('category')::text IS NULL OR (fa.category = sqlc.narg('category')::text AND fb.category = sqlc.narg('category')::text))
+ORDER BY fc.scheme_code_a ASC, fc.scheme_code_b ASC;
@@ -24,3 +24,52 @@ ON CONFLICT (scheme_code) DO UPDATE SET
 SELECT COUNT(*)::bigint
 FROM funds
 WHERE category = $1;
+
+-- name: CountFundsFiltered :one
+SELECT COUNT(*)::bigint
+FROM funds
+WHERE (sqlc.narg('categories')::text[] IS NULL OR category = ANY(sqlc.narg('categories')::text[]))
+  AND (sqlc.narg('amcs')::text[] IS NULL OR amc = ANY(sqlc.narg('amcs')::text[]));
+
+-- name: SearchFunds :many
+-- One keyset page of funds. sort is name|amc|nav|inception; each row carries its (sort_text,
+-- sort_num) key so the last row of a page is the cursor for the next. Patterns are ILIKE patterns
+-- with wildcards already escaped; q is the raw search text, matched by trigram word similarity
+-- besides its q_pattern.
+WITH keyed AS (
+  SELECT f.scheme_code, f.scheme_name, f.amc, f.category, f.inception_date,
+         n.nav_date AS latest_nav_date, n.nav_value AS latest_nav,
+         (CASE sqlc.arg('sort')::text
+            WHEN 'name' THEN lower(f.scheme_name)
+            WHEN 'amc' THEN lower(f.amc)
+            ELSE ''
+          END)::text AS sort_text,
+         (CASE sqlc.arg('sort')::text
+            WHEN 'nav' THEN COALESCE(n.nav_value::float8, -1)
+            WHEN 'inception' THEN COALESCE((f.inception_date - DATE '1900-01-01')::float8, -1)
+            ELSE 0
+          END)::float8 AS sort_num
+  FROM funds f
+  LEFT JOIN fund_latest_nav n ON n.scheme_code = f.scheme_code
+  WHERE (sqlc.narg('category')::text IS NULL OR f.category ILIKE '%' || sqlc.narg('category')::text || '%')
+    AND (sqlc.narg('amc')::text IS NULL OR f.amc ILIKE '%' || sqlc.narg('amc')::text || '%')
+    AND (sqlc.narg('q_pattern')::text IS NULL
+         OR f.scheme_name ILIKE '%' || sqlc.narg('q_pattern')::text || '%'
+         OR sqlc.narg('q')::text <% f.scheme_name)
+)
+SELECT scheme_code, scheme_name, amc, category, inception_date, latest_nav_date, latest_nav,
+       sort_text, sort_num
+FROM keyed
+WHERE sqlc.narg('after_code')::text IS NULL
+   OR (NOT sqlc.arg('descending')::bool
+       AND (sort_text, sort_num, scheme_code) > (sqlc.arg('after_text')::text, sqlc.arg('after_num')::float8, sqlc.narg('after_code')::text))
+   OR (sqlc.arg('descending')::bool
+       AND (sort_text, sort_num, scheme_code) < (sqlc.arg('after_text')::text, sqlc.arg('after_num')::float8, sqlc.narg('after_code')::text))
+ORDER BY
+  CASE WHEN NOT sqlc.arg('descending')::bool THEN sort_text END ASC,
+  CASE WHEN NOT sqlc.arg('descending')::bool THEN sort_num END ASC,
+  CASE WHEN NOT sqlc.arg('descending')::bool THEN scheme_code END ASC,
+  CASE WHEN sqlc.arg('descending')::bool THEN sort_text END DESC,
+  CASE WHEN sqlc.arg('descending')::bool THEN sort_num END DESC,
+  CASE WHEN sqlc.arg('descending')::bool THEN scheme_code END DESC
+LIMIT sqlc.arg('page_limit')::int;
@@ -1,15 +1,26 @@
 -- name: UpsertNavHistory :exec
-INSERT INTO nav_history (scheme_code, nav_date, nav_value, created_at)
-VALUES ($1, $2, $3, NOW())
-ON CONFLICT (scheme_code, nav_date) DO UPDATE SET
-  nav_value = EXCLUDED.nav_value;
+-- Also advances fund_latest_nav in the same statement, so the two can't disagree. A correction
+-- of the latest NAV updates it too; older dates leave it alone.
+WITH nav AS (
+  INSERT INTO nav_history (scheme_code, nav_date, nav_value, created_at)
+  VALUES ($1, $2, $3, NOW())
+  ON CONFLICT (scheme_code, nav_date) DO UPDATE SET
+    nav_value = EXCLUDED.nav_value
+  RETURNING scheme_code, nav_date, nav_value
+)
+INSERT INTO fund_latest_nav (scheme_code, nav_date, nav_value, updated_at)
+SELECT scheme_code, nav_date, nav_value, NOW()
+FROM nav
+ON CONFLICT (scheme_code) DO UPDATE SET
+  nav_date = EXCLUDED.nav_date,
+  nav_value = EXCLUDED.nav_value,
+  updated_at = NOW()
+WHERE EXCLUDED.nav_date >= fund_latest_nav.nav_date;
 
 -- name: GetLatestNav :one
-SELECT scheme_code, nav_date, nav_value, created_at
-FROM nav_history
-WHERE scheme_code = $1
-ORDER BY nav_date DESC
-LIMIT 1;
+SELECT scheme_code, nav_date, nav_value, updated_at
+FROM fund_latest_nav
+WHERE scheme_code = $1;
 
 -- name: ListNavHistoryForScheme :many
 SELECT scheme_code, nav_date, nav_value, created_at
@@ -25,3 +36,21 @@ WHERE scheme_code = $1
   AND nav_date <= $3
 ORDER BY nav_date ASC;
 
+
+-- name: GetNavHistoryBounds :one
+-- Only positive NAVs count, the same rows the analytics compute uses.
+SELECT
+  MIN(nav_date)::date AS start_date,
+  MAX(nav_date)::date AS end_date,
+  COUNT(*)::int AS nav_points
+FROM nav_history
+WHERE scheme_code = $1
+  AND nav_value > 0;
+
+-- name: GetNavOnOrBefore :one
+SELECT scheme_code, nav_date, nav_value, created_at
+FROM nav_history
+WHERE scheme_code = $1
+  AND nav_date <= $2
+ORDER BY nav_date DESC
+LIMIT 1;
@@ -0,0 +1,34 @@
+-- name: UpsertFundTrailingReturn :exec
+INSERT INTO fund_trailing_returns (
+  scheme_code, period, start_date, end_date, absolute_return, annualized_return, computed_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
+ON CONFLICT (scheme_code, period) DO UPDATE SET
+  start_date = EXCLUDED.start_date,
+  end_date = EXCLUDED.end_date,
+  absolute_return = EXCLUDED.absolute_return,
+  annualized_return = EXCLUDED.annualized_return,
+  computed_at = NOW();
+
+-- name: ListFundTrailingReturns :many
+SELECT *
+FROM fund_trailing_returns
+WHERE scheme_code = $1;
+
+-- name: UpsertFundCalendarReturn :exec
+INSERT INTO fund_calendar_returns (
+  scheme_code, year, start_date, end_date, return_pct, complete, computed_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
+ON CONFLICT (scheme_code, year) DO UPDATE SET
+  start_date = EXCLUDED.start_date,
+  end_date = EXCLUDED.end_date,
+  return_pct = EXCLUDED.return_pct,
+  complete = EXCLUDED.complete,
+  computed_at = NOW();
+
+-- name: ListFundCalendarReturns :many
+SELECT *
+FROM fund_calendar_returns
+WHERE scheme_code = $1
+ORDER BY year DESC;
@@ -0,0 +1,44 @@
+-- name: CreatePortfolio :one
+INSERT INTO portfolios (name, rebalance)
+VALUES ($1, $2)
+RETURNING id, name, rebalance, created_at, updated_at;
+
+-- name: GetPortfolio :one
+SELECT id, name, rebalance, created_at, updated_at
+FROM portfolios
+WHERE id = $1;
+
+-- name: ListPortfolios :many
+SELECT id, name, rebalance, created_at, updated_at
+FROM portfolios
+ORDER BY id ASC;
+
+-- name: UpdatePortfolio :one
+UPDATE portfolios
+SET name = $2,
+  rebalance = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, rebalance, created_at, updated_at;
+
+-- name: DeletePortfolio :execrows
+DELETE FROM portfolios
+WHERE id = $1;
+
+-- name: ListPortfolioHoldings :many
+SELECT portfolio_id, scheme_code, weight
+FROM portfolio_holdings
+WHERE portfolio_id = ANY(@portfolio_ids::bigint[])
+ORDER BY portfolio_id ASC, weight DESC, scheme_code ASC;
+
+-- name: DeletePortfolioHoldings :exec
+DELETE FROM portfolio_holdings
+WHERE portfolio_id = $1;
+
+-- name: InsertPortfolioHoldings :exec
+INSERT INTO portfolio_holdings (portfolio_id, scheme_code, weight)
+SELECT @portfolio_id::bigint, t.scheme_code, t.weight
+FROM unnest(
+  @scheme_codes::text[],
+  @weights::float8[]
+) AS t(scheme_code, weight);
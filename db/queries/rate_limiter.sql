@@ -12,3 +12,18 @@ ON CONFLICT (window_type) DO UPDATE SET
   request_count = EXCLUDED.request_count,
   updated_at = NOW();
 
+
+-- name: GetAPIKeyRateLimitStateForUpdate :one
+SELECT key_id, window_type, window_start, request_count, updated_at
+FROM api_key_rate_limit_state
+WHERE key_id = $1
+  AND window_type = $2
+FOR UPDATE;
+
+-- name: UpsertAPIKeyRateLimitState :exec
+INSERT INTO api_key_rate_limit_state (key_id, window_type, window_start, request_count, updated_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (key_id, window_type) DO UPDATE SET
+  window_start = EXCLUDED.window_start,
+  request_count = EXCLUDED.request_count,
+  updated_at = NOW();
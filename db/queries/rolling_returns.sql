@@ -0,0 +1,29 @@
+-- name: DeleteFundRollingReturns :exec
+DELETE FROM fund_rolling_returns
+WHERE scheme_code = $1
+  AND "window" = $2;
+
+-- name: InsertFundRollingReturns :exec
+INSERT INTO fund_rolling_returns (scheme_code, "window", end_date, start_date, return_pct, cagr_pct)
+SELECT
+  sqlc.arg('scheme_code')::text,
+  sqlc.arg('window')::text,
+  t.end_date,
+  t.start_date,
+  t.return_pct,
+  NULLIF(t.cagr_pct, 'NaN'::float8)
+FROM unnest(
+  sqlc.arg('end_dates')::date[],
+  sqlc.arg('start_dates')::date[],
+  sqlc.arg('return_pcts')::float8[],
+  sqlc.arg('cagr_pcts')::float8[]
+) AS t(end_date, start_date, return_pct, cagr_pct);
+
+-- name: ListFundRollingReturns :many
+SELECT end_date, start_date, return_pct, cagr_pct
+FROM fund_rolling_returns
+WHERE scheme_code = sqlc.arg('scheme_code')
+  AND "window" = sqlc.arg('window')
+  AND (sqlc.narg('from_date')::date IS NULL OR end_date >= sqlc.narg('from_date')::date)
+  AND (sqlc.narg('to_date')::date IS NULL OR end_date <= sqlc.narg('to_date')::date)
+ORDER BY end_date ASC;
@@ -96,6 +96,10 @@ SET
 WHERE scheme_code IN (SELECT scheme_code FROM candidate)
 RETURNING scheme_code, last_synced_date, status, retry_count, last_error, last_attempt_at, updated_at;
 
+-- name: NotifySyncCompleted :exec
+-- Tells API replicas (LISTEN sync_completed) that synced data changed.
+SELECT pg_notify('sync_completed', @run_id::text);
+
 -- name: RequeueStaleInProgressSyncState :exec
 UPDATE sync_state
 SET
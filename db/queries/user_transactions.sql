@@ -0,0 +1,18 @@
+-- name: InsertUserTransactions :execrows
+-- Skips transactions already stored for the user, so re-imports only add new rows.
+INSERT INTO user_transactions (user_id, scheme_code, txn_type, txn_date, amount, units, source)
+SELECT @user_id::text, t.scheme_code, t.txn_type, t.txn_date, t.amount, t.units, @source::text
+FROM unnest(
+  @scheme_codes::text[],
+  @txn_types::text[],
+  @txn_dates::date[],
+  @amounts::float8[],
+  @units::float8[]
+) AS t(scheme_code, txn_type, txn_date, amount, units)
+ON CONFLICT (user_id, scheme_code, txn_type, txn_date, amount, units) DO NOTHING;
+
+-- name: ListUserTransactions :many
+SELECT id, user_id, scheme_code, txn_type, txn_date, amount, units, source, created_at
+FROM user_transactions
+WHERE user_id = $1
+ORDER BY txn_date ASC, id ASC;
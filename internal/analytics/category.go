@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// RefreshCategoryAnalytics rebuilds `category_analytics` (per category/window/metric mean and
+// quartile boundaries) and `fund_category_ranks` (each fund's percentile and quartile within its
+// category) from the current `fund_analytics`. Metrics are named after the fund_analytics columns.
+// Both tables are swapped in one transaction, so readers never see a half-built peer group.
+func RefreshCategoryAnalytics(ctx context.Context, pool *pgxpool.Pool) error {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := db.New(tx)
+	if err := q.DeleteCategoryAnalytics(ctx); err != nil {
+		return err
+	}
+	if err := q.InsertCategoryAnalytics(ctx); err != nil {
+		return err
+	}
+	if err := q.DeleteFundCategoryRanks(ctx); err != nil {
+		return err
+	}
+	if err := q.InsertFundCategoryRanks(ctx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
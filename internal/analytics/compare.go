@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// CommonPeriodFund is one fund's performance over the period every compared fund has NAVs for.
+type CommonPeriodFund struct {
+	SchemeCode  string
+	Return      float64 // absolute %, first to last common date
+	CAGR        float64 // NaN when the period is shorter than a year
+	Volatility  float64 // annualised % of the aligned log returns
+	MaxDrawdown float64 // % over the aligned NAVs
+	Relative    float64 // % growth relative to the first fund over the same dates
+}
+
+// CommonPeriodResult compares funds on the dates they all have a NAV for, so no fund is credited
+// with a period the others didn't trade through.
+type CommonPeriodResult struct {
+	Start        time.Time
+	End          time.Time
+	Observations int                // common NAV dates
+	Funds        []CommonPeriodFund // in the order requested
+
+	// Correlation of the funds' daily log returns, aligned with Funds. Entries are NaN when a
+	// fund's NAV didn't move over the period.
+	Correlation [][]float64
+}
+
+// CompareCommonPeriod loads each scheme's NAV history and compares them over their common dates.
+// It returns ErrInsufficientHistory when they share fewer than two.
+func CompareCommonPeriod(ctx context.Context, pool *pgxpool.Pool, schemeCodes []string) (CommonPeriodResult, error) {
+	q := db.New(pool)
+	series := make([][]point, len(schemeCodes))
+	for i, code := range schemeCodes {
+		pts, err := loadPoints(ctx, q, code, time.Time{})
+		if err != nil {
+			return CommonPeriodResult{}, err
+		}
+		series[i] = pts
+	}
+	return commonPeriod(schemeCodes, series)
+}
+
+func commonPeriod(schemeCodes []string, series [][]point) (CommonPeriodResult, error) {
+	navs := alignDates(series)
+	if len(navs) == 0 || len(navs[0]) < 2 {
+		return CommonPeriodResult{}, fmt.Errorf("%w: fewer than 2 common nav dates", ErrInsufficientHistory)
+	}
+	aligned := navs[0]
+	first, last := aligned[0], aligned[len(aligned)-1]
+	years := last.date.Sub(first.date).Hours() / 24 / 365.25
+
+	out := CommonPeriodResult{
+		Start:        first.date,
+		End:          last.date,
+		Observations: len(aligned),
+		Funds:        make([]CommonPeriodFund, len(series)),
+	}
+
+	logReturns := make([][]float64, len(series))
+	for f, pts := range navs {
+		n := len(pts)
+		lr := make([]float64, n-1)
+		for k := 1; k < n; k++ {
+			lr[k-1] = math.Log(pts[k].nav / pts[k-1].nav)
+		}
+		logReturns[f] = lr
+
+		growth := pts[n-1].nav / pts[0].nav
+		res := CommonPeriodFund{
+			SchemeCode:  schemeCodes[f],
+			Return:      (growth - 1) * 100,
+			CAGR:        math.NaN(),
+			Volatility:  math.Sqrt(sampleVariance(lr)*float64(len(lr))/years) * 100,
+			MaxDrawdown: worstDrawdownPct(pts, []int{0}, []int{n - 1}, 0),
+		}
+		if years >= 1 {
+			res.CAGR = (math.Pow(growth, 1/years) - 1) * 100
+		}
+		out.Funds[f] = res
+	}
+	base := 1 + out.Funds[0].Return/100
+	for f := range out.Funds {
+		out.Funds[f].Relative = ((1+out.Funds[f].Return/100)/base - 1) * 100
+	}
+
+	out.Correlation = make([][]float64, len(series))
+	for a := range logReturns {
+		out.Correlation[a] = make([]float64, len(series))
+		for b := range logReturns {
+			out.Correlation[a][b] = correlation(logReturns[a], logReturns[b])
+		}
+	}
+	return out, nil
+}
+
+// alignDates keeps, for every series, only the points on dates present in all of them.
+func alignDates(series [][]point) [][]point {
+	if len(series) == 0 {
+		return nil
+	}
+	counts := map[time.Time]int{}
+	for _, pts := range series {
+		for _, p := range pts {
+			counts[p.date]++
+		}
+	}
+	out := make([][]point, len(series))
+	for f, pts := range series {
+		for _, p := range pts {
+			if counts[p.date] == len(series) {
+				out[f] = append(out[f], p)
+			}
+		}
+	}
+	return out
+}
+
+func sampleVariance(xs []float64) float64 {
+	n := len(xs)
+	if n < 2 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(n)
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return ss / float64(n-1)
+}
+
+// correlation is the Pearson correlation of two equal-length samples; NaN if either is constant.
+func correlation(xs, ys []float64) float64 {
+	n := len(xs)
+	if n < 2 || len(ys) != n {
+		return math.NaN()
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(n)
+	my /= float64(n)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
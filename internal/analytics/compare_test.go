@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCommonPeriodAlignsDates(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return start.AddDate(0, 0, i) }
+
+	// a starts earlier and has a gap; b doubles a's daily moves, so they're perfectly correlated
+	// on the common dates. c moves opposite to a.
+	var a, b, c []point
+	navA, navB, navC := 100.0, 50.0, 200.0
+	for i := 0; i < 400; i++ {
+		move := 0.01
+		if i%3 == 0 {
+			move = -0.015
+		}
+		navA *= 1 + move
+		navB *= 1 + 2*move
+		navC *= 1 - move
+		if i != 250 {
+			a = append(a, point{date: day(i), nav: navA})
+		}
+		if i >= 100 {
+			b = append(b, point{date: day(i), nav: navB})
+			c = append(c, point{date: day(i), nav: navC})
+		}
+	}
+
+	res, err := commonPeriod([]string{"A", "B", "C"}, [][]point{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Start.Equal(day(100)) || !res.End.Equal(day(399)) || res.Observations != 299 {
+		t.Fatalf("common period %s..%s (%d), want %s..%s (299)", res.Start, res.End, res.Observations, day(100), day(399))
+	}
+	wantA := (a[len(a)-1].nav/navOn(a, day(100)) - 1) * 100
+	if math.Abs(res.Funds[0].Return-wantA) > 1e-9 || res.Funds[0].Relative != 0 {
+		t.Fatalf("fund A: %+v, want return %f and relative 0", res.Funds[0], wantA)
+	}
+	if want := ((1+res.Funds[1].Return/100)/(1+wantA/100) - 1) * 100; math.Abs(res.Funds[1].Relative-want) > 1e-9 {
+		t.Fatalf("fund B relative %f, want %f", res.Funds[1].Relative, want)
+	}
+	if !math.IsNaN(res.Funds[0].CAGR) {
+		t.Fatalf("CAGR should be NaN under a year, got %f", res.Funds[0].CAGR)
+	}
+	if got := res.Correlation[0][1]; got < 0.99 {
+		t.Fatalf("corr(A,B) = %f, want ~1", got)
+	}
+	if got := res.Correlation[0][2]; got > -0.99 {
+		t.Fatalf("corr(A,C) = %f, want ~-1", got)
+	}
+	if res.Correlation[1][1] < 0.999999 || res.Correlation[0][1] != res.Correlation[1][0] {
+		t.Fatalf("correlation matrix not symmetric with unit diagonal: %v", res.Correlation)
+	}
+}
+
+func TestCommonPeriodNoOverlap(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := []point{{date: start, nav: 10}, {date: start.AddDate(0, 0, 1), nav: 11}}
+	b := []point{{date: start.AddDate(0, 0, 5), nav: 10}, {date: start.AddDate(0, 0, 6), nav: 11}}
+	if _, err := commonPeriod([]string{"A", "B"}, [][]point{a, b}); !errors.Is(err, ErrInsufficientHistory) {
+		t.Fatalf("expected ErrInsufficientHistory, got %v", err)
+	}
+}
+
+func navOn(pts []point, d time.Time) float64 {
+	for _, p := range pts {
+		if p.date.Equal(d) {
+			return p.nav
+		}
+	}
+	return math.NaN()
+}
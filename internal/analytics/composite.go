@@ -0,0 +1,197 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RankMetrics are the numeric `fund_analytics` columns funds can be ranked on, mapped to whether
+// a higher value is better. Drawdowns are stored negative, so higher is better for them too.
+var RankMetrics = map[string]bool{
+	"rolling_min":    true,
+	"rolling_max":    true,
+	"rolling_median": true,
+	"rolling_p25":    true,
+	"rolling_p75":    true,
+	"max_drawdown":   true,
+	"cagr_min":       true,
+	"cagr_max":       true,
+	"cagr_median":    true,
+	"volatility":     false,
+	"sharpe":         true,
+	"consistency":    true,
+}
+
+// metricAliases are the names the API accepts for RankMetrics columns besides the columns
+// themselves.
+var metricAliases = map[string]string{
+	"median_return": "rolling_median",
+}
+
+// CanonicalMetric resolves an alias such as median_return to its RankMetrics column; other
+// names are returned unchanged.
+func CanonicalMetric(name string) string {
+	if col, ok := metricAliases[name]; ok {
+		return col
+	}
+	return name
+}
+
+// ScoreMethod is how each metric is standardised before weighting.
+type ScoreMethod string
+
+const (
+	ScoreZ          ScoreMethod = "zscore"     // (x - mean) / stddev across the candidates
+	ScorePercentile ScoreMethod = "percentile" // percent rank 0..100 across the candidates
+)
+
+// MetricWeight is one term of a composite score.
+type MetricWeight struct {
+	Metric string
+	Weight float64
+}
+
+// ParseWeights parses "metric:weight,..." (e.g. "median_return:0.5,max_drawdown:0.3,sharpe:0.2").
+// Metrics may be given by alias; the result always names the column.
+// Metrics must be in RankMetrics; weights must be positive and are normalised to sum to 1.
+func ParseWeights(s string) ([]MetricWeight, error) {
+	var out []MetricWeight
+	seen := map[string]bool{}
+	total := 0.0
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(part, ":")
+		name = CanonicalMetric(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q: want metric:weight", part)
+		}
+		if _, known := RankMetrics[name]; !known {
+			return nil, fmt.Errorf("unknown metric %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate metric %q", name)
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil || w <= 0 || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("invalid weight for %s: must be a positive number", name)
+		}
+		seen[name] = true
+		total += w
+		out = append(out, MetricWeight{Metric: name, Weight: w})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no weights given")
+	}
+	for k := range out {
+		out[k].Weight /= total
+	}
+	return out, nil
+}
+
+// CompositeScores scores candidates on the weighted metrics. values[m][i] is metric weights[m]
+// for candidate i (NaN when missing). Each metric is oriented so higher is better, standardised
+// among the candidates that have it, and multiplied by its weight; contributions[i][m] holds that
+// term and scores[i] their sum. Candidates missing any weighted metric score NaN (and get NaN
+// contributions for the missing metrics) so they can be ranked last.
+func CompositeScores(values [][]float64, weights []MetricWeight, method ScoreMethod) (scores []float64, contributions [][]float64) {
+	n := 0
+	if len(values) > 0 {
+		n = len(values[0])
+	}
+	scores = make([]float64, n)
+	contributions = make([][]float64, n)
+	for i := range contributions {
+		contributions[i] = make([]float64, len(weights))
+	}
+
+	for m, w := range weights {
+		xs := values[m]
+		std := standardise(xs, method)
+		sign := 1.0
+		if !RankMetrics[w.Metric] {
+			sign = -1
+			if method == ScorePercentile {
+				// Invert the rank rather than negating it, so percentiles stay 0..100.
+				for i := range std {
+					std[i] = 100 - std[i]
+				}
+				sign = 1
+			}
+		}
+		for i := range xs {
+			c := sign * std[i] * w.Weight
+			contributions[i][m] = c
+			scores[i] += c
+		}
+	}
+	return scores, contributions
+}
+
+// PercentRanks returns each value's percent rank (0..100, ascending) among the non-NaN values;
+// NaN stays NaN.
+func PercentRanks(xs []float64) []float64 {
+	return standardise(xs, ScorePercentile)
+}
+
+// standardise maps xs to z-scores or percent ranks among the non-NaN values; NaN stays NaN.
+// A metric with no spread (or a single value) standardises to 0 / 100.
+func standardise(xs []float64, method ScoreMethod) []float64 {
+	out := make([]float64, len(xs))
+	var present []float64
+	for _, x := range xs {
+		if !math.IsNaN(x) {
+			present = append(present, x)
+		}
+	}
+
+	switch method {
+	case ScorePercentile:
+		for i, x := range xs {
+			if math.IsNaN(x) {
+				out[i] = math.NaN()
+				continue
+			}
+			if len(present) == 1 {
+				out[i] = 100
+				continue
+			}
+			below := 0
+			for _, y := range present {
+				if y < x {
+					below++
+				}
+			}
+			out[i] = float64(below) / float64(len(present)-1) * 100
+		}
+	default:
+		var mean, sd float64
+		for _, x := range present {
+			mean += x
+		}
+		if len(present) > 0 {
+			mean /= float64(len(present))
+		}
+		for _, x := range present {
+			sd += (x - mean) * (x - mean)
+		}
+		if len(present) > 0 {
+			sd = math.Sqrt(sd / float64(len(present)))
+		}
+		for i, x := range xs {
+			switch {
+			case math.IsNaN(x):
+				out[i] = math.NaN()
+			case sd == 0:
+				out[i] = 0
+			default:
+				out[i] = (x - mean) / sd
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseWeights(t *testing.T) {
+	ws, err := ParseWeights("rolling_median:0.5, max_drawdown:0.3,sharpe:0.2")
+	if err != nil || len(ws) != 3 || ws[0].Metric != "rolling_median" || math.Abs(ws[2].Weight-0.2) > 1e-12 {
+		t.Fatalf("unexpected weights %+v, %v", ws, err)
+	}
+	ws, err = ParseWeights("sharpe:2,volatility:2")
+	if err != nil || ws[0].Weight != 0.5 || ws[1].Weight != 0.5 {
+		t.Fatalf("weights should be normalised: %+v, %v", ws, err)
+	}
+	ws, err = ParseWeights("median_return:0.5,max_drawdown:0.3,sharpe:0.2")
+	if err != nil || ws[0].Metric != "rolling_median" {
+		t.Fatalf("median_return should alias rolling_median: %+v, %v", ws, err)
+	}
+	for _, bad := range []string{"", "sharpe", "sharpe:0", "nav:1", "sharpe:1,sharpe:2", "median_return:1,rolling_median:1"} {
+		if _, err := ParseWeights(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCompositeScores(t *testing.T) {
+	weights := []MetricWeight{{Metric: "rolling_median", Weight: 0.5}, {Metric: "volatility", Weight: 0.5}}
+	values := [][]float64{
+		{10, 20, 30, math.NaN()}, // rolling_median
+		{15, 10, 5, 10},          // volatility: lower is better
+	}
+
+	scores, contrib := CompositeScores(values, weights, ScoreZ)
+	// Fund 2 has the best return and the lowest volatility.
+	if !(scores[2] > scores[1] && scores[1] > scores[0]) || !math.IsNaN(scores[3]) {
+		t.Fatalf("unexpected z scores %v", scores)
+	}
+	if got := contrib[2][0] + contrib[2][1]; math.Abs(got-scores[2]) > 1e-12 {
+		t.Fatalf("contributions %v don't sum to score %v", contrib[2], scores[2])
+	}
+	if contrib[3][1] != 0 {
+		t.Fatalf("volatility 10 is the mean, expected 0 contribution, got %v", contrib[3][1])
+	}
+
+	scores, _ = CompositeScores(values, weights, ScorePercentile)
+	// Percentiles: return 0/50/100, volatility (inverted) 0/66.7/100/66.7.
+	want := []float64{0, 0.5*50 + 0.5*200.0/3, 100}
+	for i, w := range want {
+		if math.Abs(scores[i]-w) > 1e-9 {
+			t.Fatalf("percentile score %d = %v, want %v", i, scores[i], w)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package analytics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -14,35 +16,147 @@ import (
 	"mf-analytics-service/internal/db"
 )
 
+// WindowSpec is a rolling window length. Labels are "<n>M" or "<n>Y" (e.g. 6M, 18M, 7Y).
 type WindowSpec struct {
-	Label string
-	Years int
+	Label  string
+	Months int
 }
 
 var DefaultWindows = []WindowSpec{
-	{Label: "1Y", Years: 1},
-	{Label: "3Y", Years: 3},
-	{Label: "5Y", Years: 5},
-	{Label: "10Y", Years: 10},
+	{Label: "1Y", Months: 12},
+	{Label: "3Y", Months: 36},
+	{Label: "5Y", Months: 60},
+	{Label: "10Y", Months: 120},
 }
 
+// MaxWindowLabelLen matches the width of the "window" columns; precomputed labels must fit.
+const MaxWindowLabelLen = 8
+
+// MaxWindowMonths bounds ad-hoc windows; nothing we track has more than a few decades of history.
+const MaxWindowMonths = 600
+
+// ParseWindow parses a window label such as "6M", "18M", "3Y" or "7Y".
+func ParseWindow(label string) (WindowSpec, error) {
+	if len(label) < 2 {
+		return WindowSpec{}, fmt.Errorf("invalid window %q", label)
+	}
+	n, err := strconv.Atoi(label[:len(label)-1])
+	if err != nil || n <= 0 || strconv.Itoa(n) != label[:len(label)-1] {
+		return WindowSpec{}, fmt.Errorf("invalid window %q", label)
+	}
+	months := n
+	switch label[len(label)-1] {
+	case 'M':
+	case 'Y':
+		months = n * 12
+	default:
+		return WindowSpec{}, fmt.Errorf("invalid window %q: unit must be M or Y", label)
+	}
+	if months > MaxWindowMonths {
+		return WindowSpec{}, fmt.Errorf("invalid window %q: longer than %d months", label, MaxWindowMonths)
+	}
+	return WindowSpec{Label: label, Months: months}, nil
+}
+
+// ErrInsufficientHistory is returned when a scheme has too little usable NAV history for a computation.
+var ErrInsufficientHistory = errors.New("insufficient nav history")
+
 type point struct {
 	date time.Time
 	nav  float64
 }
 
-// ComputeAndUpsert computes analytics for all windows for a scheme and upserts `fund_analytics`.
+// ComputeAndUpsert computes analytics for all windows for a scheme and upserts `fund_analytics`,
+// refreshes the per-window rolling return series in `fund_rolling_returns`, and the trailing and
+// calendar-year returns in `fund_trailing_returns` / `fund_calendar_returns`.
 // If there isn't enough history for a window, it still upserts a row with availability fields and NULL metrics.
-func ComputeAndUpsert(ctx context.Context, pool *pgxpool.Pool, schemeCode string) error {
+// A nil windows slice means DefaultWindows. Sharpe and consistency are measured against
+// riskFreePct (see DefaultRiskFreeRatePct), which is stored with each row.
+func ComputeAndUpsert(ctx context.Context, pool *pgxpool.Pool, schemeCode string, windows []WindowSpec, riskFreePct float64) error {
+	if windows == nil {
+		windows = DefaultWindows
+	}
+
 	q := db.New(pool)
-	rows, err := q.ListNavHistoryForScheme(ctx, schemeCode)
+	pts, err := loadPoints(ctx, q, schemeCode, time.Time{})
 	if err != nil {
 		return err
 	}
+
+	for _, w := range windows {
+		params, series, st := computeWindowParams(schemeCode, pts, w, riskFreePct)
+		if err := persistWindow(ctx, pool, params, series, true, st); err != nil {
+			return err
+		}
+	}
+
+	return upsertPeriodReturns(ctx, q, schemeCode, pts, 0)
+}
+
+// computeWindowParams is the per-window core of ComputeAndUpsert and ComputeWindowOnDemand. It
+// only sees pts, so callers get point-in-time results by cutting pts off first (see pointsAsOf).
+func computeWindowParams(schemeCode string, pts []point, w WindowSpec, riskFreePct float64) (db.UpsertFundAnalyticsParams, []rollingPoint, *windowState) {
+	st := newWindowState(w.Months)
+	series := st.extend(pts)
+	res := st.result(riskFreePct).withTrailingRisk(pts, w.Months, riskFreePct)
+	params := analyticsParams(schemeCode, w.Label, res, pts[0].date, pts[len(pts)-1].date, len(pts))
+	return params, series, st
+}
+
+func analyticsParams(
+	schemeCode, window string,
+	res windowResult,
+	startDate, endDate time.Time,
+	navPoints int,
+) db.UpsertFundAnalyticsParams {
+	return db.UpsertFundAnalyticsParams{
+		SchemeCode: schemeCode,
+		Window:     window,
+
+		RollingMin:    res.rollingMin,
+		RollingMax:    res.rollingMax,
+		RollingMedian: res.rollingMedian,
+		RollingP25:    res.rollingP25,
+		RollingP75:    res.rollingP75,
+
+		MaxDrawdown: res.maxDrawdown,
+
+		CagrMin:    res.cagrMin,
+		CagrMax:    res.cagrMax,
+		CagrMedian: res.cagrMedian,
+
+		DataStartDate:  pgtype.Date{Time: startDate, Valid: true},
+		DataEndDate:    pgtype.Date{Time: endDate, Valid: true},
+		NavPoints:      pgtype.Int4{Int32: int32(navPoints), Valid: true},
+		RollingPeriods: pgtype.Int4{Int32: int32(res.rollingPeriods), Valid: true},
+		Distribution:   res.distribution,
+		Volatility:     res.volatility,
+		Sharpe:         res.sharpe,
+		Consistency:    res.consistency,
+		RiskFreeRate:   res.riskFreeRate,
+	}
+}
+
+// loadPoints reads a scheme's usable NAV history (valid dates, positive NAVs) in date order,
+// up to and including asOf unless it is zero.
+func loadPoints(ctx context.Context, q *db.Queries, schemeCode string, asOf time.Time) ([]point, error) {
+	rows, err := q.ListNavHistoryForScheme(ctx, schemeCode)
+	if err != nil {
+		return nil, err
+	}
 	if len(rows) == 0 {
-		return fmt.Errorf("no nav history for scheme_code=%s", schemeCode)
+		return nil, fmt.Errorf("%w: no nav history for scheme_code=%s", ErrInsufficientHistory, schemeCode)
 	}
 
+	pts := pointsAsOf(toPoints(rows), asOf)
+	if len(pts) < 2 {
+		return nil, fmt.Errorf("%w: too few usable nav points for scheme_code=%s", ErrInsufficientHistory, schemeCode)
+	}
+	return pts, nil
+}
+
+// toPoints keeps rows with valid dates and positive NAVs, in date order.
+func toPoints(rows []db.NavHistory) []point {
 	pts := make([]point, 0, len(rows))
 	for _, r := range rows {
 		if !r.NavDate.Valid {
@@ -55,47 +169,20 @@ func ComputeAndUpsert(ctx context.Context, pool *pgxpool.Pool, schemeCode string
 		}
 		pts = append(pts, point{date: r.NavDate.Time.UTC(), nav: f})
 	}
-	if len(pts) < 2 {
-		return fmt.Errorf("insufficient usable nav points for scheme_code=%s", schemeCode)
-	}
 
 	// Ensure sorted (db query should already do it).
 	sort.Slice(pts, func(i, j int) bool { return pts[i].date.Before(pts[j].date) })
+	return pts
+}
 
-	startDate := pts[0].date
-	endDate := pts[len(pts)-1].date
-
-	for _, w := range DefaultWindows {
-		res := computeWindow(pts, w.Years)
-
-		params := db.UpsertFundAnalyticsParams{
-			SchemeCode: schemeCode,
-			Window:     w.Label,
-
-			RollingMin:    res.rollingMin,
-			RollingMax:    res.rollingMax,
-			RollingMedian: res.rollingMedian,
-			RollingP25:    res.rollingP25,
-			RollingP75:    res.rollingP75,
-
-			MaxDrawdown: res.maxDrawdown,
-
-			CagrMin:    res.cagrMin,
-			CagrMax:    res.cagrMax,
-			CagrMedian: res.cagrMedian,
-
-			DataStartDate:  pgtype.Date{Time: startDate, Valid: true},
-			DataEndDate:    pgtype.Date{Time: endDate, Valid: true},
-			NavPoints:      pgtype.Int4{Int32: int32(len(pts)), Valid: true},
-			RollingPeriods: pgtype.Int4{Int32: int32(res.rollingPeriods), Valid: true},
-		}
-
-		if err := q.UpsertFundAnalytics(ctx, params); err != nil {
-			return err
-		}
+// pointsAsOf drops NAVs dated after asOf, so nothing computed from the result can look ahead.
+// A zero asOf keeps everything.
+func pointsAsOf(pts []point, asOf time.Time) []point {
+	if asOf.IsZero() {
+		return pts
 	}
-
-	return nil
+	n := sort.Search(len(pts), func(i int) bool { return pts[i].date.After(asOf) })
+	return pts[:n]
 }
 
 type windowResult struct {
@@ -112,53 +199,37 @@ type windowResult struct {
 	cagrMin    pgtype.Numeric
 	cagrMax    pgtype.Numeric
 	cagrMedian pgtype.Numeric
-}
 
-func computeWindow(pts []point, years int) windowResult {
-	// Collect per-period rolling returns and CAGRs.
-	returns := make([]float64, 0, len(pts))
-	cagrs := make([]float64, 0, len(pts))
+	consistency pgtype.Numeric
 
-	// Worst drawdown across all rolling windows of this length.
-	worstDrawdown := math.Inf(1) // we'll take min (more negative)
+	// volatility and sharpe are trailing-window metrics, filled in by withTrailingRisk.
+	volatility pgtype.Numeric
+	sharpe     pgtype.Numeric
+	// riskFreeRate is the rate sharpe and consistency were measured against.
+	riskFreeRate pgtype.Numeric
 
-	// i is the index of the NAV at or before (endDate - years).
-	i := 0
-	for j := 0; j < len(pts); j++ {
-		startNeed := pts[j].date.AddDate(-years, 0, 0)
-		for i+1 < j && !pts[i+1].date.After(startNeed) {
-			i++
-		}
-
-		// If we don't have a point at/before startNeed, skip.
-		if pts[0].date.After(startNeed) {
-			continue
-		}
-		if i >= j {
-			continue
-		}
-
-		startNav := pts[i].nav
-		endNav := pts[j].nav
-		if startNav <= 0 || endNav <= 0 {
-			continue
-		}
+	distribution []byte // JSON-encoded Distribution; nil when there are no periods
+}
 
-		r := (endNav/startNav - 1.0) * 100.0
-		returns = append(returns, r)
+func computeWindow(pts []point, months int) windowResult {
+	st := newWindowState(months)
+	st.extend(pts)
+	return st.result(DefaultRiskFreeRatePct)
+}
 
-		c := (math.Pow(endNav/startNav, 1.0/float64(years)) - 1.0) * 100.0
-		if !math.IsNaN(c) && !math.IsInf(c, 0) {
+// summarizeWindow turns the per-period returns/CAGRs (NaN CAGRs are ignored) and the worst
+// drawdown (+Inf if no period) into the stored percentiles; consistency is measured against
+// riskFreePct.
+func summarizeWindow(periodReturns, periodCagrs []float64, worstDrawdown, riskFreePct float64) windowResult {
+	returns := append([]float64(nil), periodReturns...)
+	cagrs := make([]float64, 0, len(periodCagrs))
+	for _, c := range periodCagrs {
+		if !math.IsNaN(c) {
 			cagrs = append(cagrs, c)
 		}
-
-		dd := maxDrawdownPct(pts[i : j+1])
-		if dd < worstDrawdown {
-			worstDrawdown = dd
-		}
 	}
 
-	res := windowResult{rollingPeriods: len(returns)}
+	res := windowResult{rollingPeriods: len(returns), riskFreeRate: mustNumeric(riskFreePct)}
 
 	if len(returns) == 0 {
 		// Not enough history for this window. Leave metrics NULL but keep rollingPeriods=0.
@@ -171,6 +242,7 @@ func computeWindow(pts []point, years int) windowResult {
 		res.cagrMin = pgtype.Numeric{Valid: false}
 		res.cagrMax = pgtype.Numeric{Valid: false}
 		res.cagrMedian = pgtype.Numeric{Valid: false}
+		res.consistency = pgtype.Numeric{Valid: false}
 		return res
 	}
 
@@ -180,6 +252,7 @@ func computeWindow(pts []point, years int) windowResult {
 	res.rollingP25 = mustNumeric(percentileSorted(returns, 0.25))
 	res.rollingMedian = mustNumeric(percentileSorted(returns, 0.50))
 	res.rollingP75 = mustNumeric(percentileSorted(returns, 0.75))
+	res.distribution = encodeDistribution(newDistribution(returns))
 
 	if math.IsInf(worstDrawdown, 1) {
 		res.maxDrawdown = pgtype.Numeric{Valid: false}
@@ -197,26 +270,76 @@ func computeWindow(pts []point, years int) windowResult {
 		res.cagrMax = mustNumeric(cagrs[len(cagrs)-1])
 		res.cagrMedian = mustNumeric(percentileSorted(cagrs, 0.50))
 	}
+	res.consistency = numericOrNull(consistencyPct(cagrs, riskFreePct))
+
+	return res
+}
 
+// withTrailingRisk adds the trailing volatility and Sharpe ratio, measured on pts (the full
+// history, or any tail covering the window before the latest NAV).
+func (res windowResult) withTrailingRisk(pts []point, months int, riskFreePct float64) windowResult {
+	vol, sharpe := trailingRisk(pts, months, riskFreePct)
+	res.volatility = numericOrNull(vol)
+	res.sharpe = numericOrNull(sharpe)
 	return res
 }
 
-func maxDrawdownPct(window []point) float64 {
-	peak := window[0].nav
-	worst := 0.0
-	for _, p := range window {
-		if p.nav > peak {
-			peak = p.nav
+// forEachRollingPeriod walks every rolling period of the given length, calling fn with the
+// start/end indexes into pts, the absolute return and the CAGR (NaN when it is undefined).
+func forEachRollingPeriod(pts []point, months int, fn func(i, j int, ret, cagr float64)) {
+	years := float64(months) / 12.0
+
+	// i is the index of the NAV at or before (endDate - window).
+	i := 0
+	for j := 0; j < len(pts); j++ {
+		startNeed := monthsBefore(pts[j].date, months)
+		for i+1 < j && !pts[i+1].date.After(startNeed) {
+			i++
+		}
+
+		// If we don't have a point at/before startNeed, skip.
+		if pts[0].date.After(startNeed) {
+			continue
+		}
+		if i >= j {
+			continue
 		}
-		if peak <= 0 {
+
+		startNav := pts[i].nav
+		endNav := pts[j].nav
+		if startNav <= 0 || endNav <= 0 {
 			continue
 		}
-		dd := (p.nav/peak - 1.0) * 100.0
-		if dd < worst {
-			worst = dd
+
+		r := (endNav/startNav - 1.0) * 100.0
+		c := (math.Pow(endNav/startNav, 1.0/years) - 1.0) * 100.0
+		if math.IsInf(c, 0) {
+			c = math.NaN()
 		}
+		fn(i, j, r, c)
 	}
-	return worst
+}
+
+// rollingPoint is a single observation of the rolling return series.
+type rollingPoint struct {
+	startDate time.Time
+	endDate   time.Time
+	ret       float64
+	cagr      float64 // NaN when undefined
+}
+
+// rollingSeries returns the rolling return series that computeWindow summarises.
+func rollingSeries(pts []point, months int) []rollingPoint {
+	out := make([]rollingPoint, 0, len(pts))
+	forEachRollingPeriod(pts, months, func(i, j int, r, c float64) {
+		out = append(out, rollingPoint{
+			startDate: pts[i].date,
+			endDate:   pts[j].date,
+			ret:       r,
+			cagr:      c,
+		})
+	})
+	return out
 }
 
 func percentileSorted(sorted []float64, p float64) float64 {
@@ -248,11 +371,20 @@ func mustNumeric(v float64) pgtype.Numeric {
 	return n
 }
 
+// numericOrNull is mustNumeric with NaN (undefined metric) mapped to NULL.
+func numericOrNull(v float64) pgtype.Numeric {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return pgtype.Numeric{Valid: false}
+	}
+	return mustNumeric(v)
+}
+
+// decimalToFloat converts a NAV to float64. Most decimal NAVs (45.6789) have no exact binary
+// form; the nearest float64 is fine for percent metrics, so only NaN and Inf are rejected.
 func decimalToFloat(d decimal.Decimal) (float64, bool) {
-	f, exact := d.Float64()
-	// float64 conversion can be inexact; that's fine for percent metrics.
+	f := d.InexactFloat64()
 	if math.IsNaN(f) || math.IsInf(f, 0) {
 		return 0, false
 	}
-	return f, exact
+	return f, true
 }
@@ -2,8 +2,14 @@ package analytics
 
 import (
 	"math"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"mf-analytics-service/internal/db"
 )
 
 func TestMaxDrawdownPct(t *testing.T) {
@@ -20,6 +26,25 @@ func TestMaxDrawdownPct(t *testing.T) {
 	}
 }
 
+func TestToPointsKeepsInexactNavs(t *testing.T) {
+	day := func(d int) pgtype.Date {
+		return pgtype.Date{Time: time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+	rows := []db.NavHistory{
+		{NavDate: day(2), NavValue: decimal.RequireFromString("45.6789")},
+		{NavDate: day(1), NavValue: decimal.RequireFromString("12.5")},
+		{NavDate: day(3), NavValue: decimal.RequireFromString("0")},
+		{NavDate: pgtype.Date{}, NavValue: decimal.RequireFromString("10")},
+	}
+	pts := toPoints(rows)
+	if len(pts) != 2 {
+		t.Fatalf("expected 2 usable points, got %d", len(pts))
+	}
+	if pts[0].nav != 12.5 || math.Abs(pts[1].nav-45.6789) > 1e-9 {
+		t.Fatalf("unexpected navs: %+v", pts)
+	}
+}
+
 func TestPercentileSorted(t *testing.T) {
 	x := []float64{1, 2, 3, 4}
 	if got := percentileSorted(x, 0.50); got != 2.5 {
@@ -33,3 +58,103 @@ func TestPercentileSorted(t *testing.T) {
 	}
 }
 
+func TestRollingSeries(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pts := []point{
+		{date: start, nav: 100},
+		{date: start.AddDate(0, 6, 0), nav: 105},
+		{date: start.AddDate(1, 0, 0), nav: 110},
+		{date: start.AddDate(1, 0, 3), nav: 121},
+	}
+	series := rollingSeries(pts, 12)
+	if len(series) != 2 {
+		t.Fatalf("expected 2 rolling periods, got %d", len(series))
+	}
+	// Both periods start at the first NAV (the last one at/before end-1Y).
+	if !series[1].startDate.Equal(start) || math.Abs(series[1].ret-21.0) > 0.0001 {
+		t.Fatalf("unexpected last period: %+v", series[1])
+	}
+	if res := computeWindow(pts, 12); res.rollingPeriods != len(series) {
+		t.Fatalf("computeWindow analyzed %d periods, series has %d", res.rollingPeriods, len(series))
+	}
+}
+
+func TestRollingPeriodsClampMonthEnd(t *testing.T) {
+	d := func(m time.Month, day int) time.Time { return time.Date(2024, m, day, 0, 0, 0, 0, time.UTC) }
+	pts := []point{
+		{date: d(2, 29), nav: 10},
+		{date: d(3, 1), nav: 20},
+		{date: d(5, 31), nav: 11},
+	}
+	// 31 May - 3 months is 29 Feb; AddDate's 2 Mar would start the period at the 1 Mar NAV.
+	series := rollingSeries(pts, 3)
+	if len(series) != 1 || !series[0].startDate.Equal(d(2, 29)) || math.Abs(series[0].ret-10.0) > 1e-9 {
+		t.Fatalf("unexpected rolling series: %+v", series)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for label, months := range map[string]int{"6M": 6, "18M": 18, "1Y": 12, "7Y": 84, "10Y": 120} {
+		w, err := ParseWindow(label)
+		if err != nil || w.Months != months || w.Label != label {
+			t.Fatalf("ParseWindow(%q) = %+v, %v; want %d months", label, w, err, months)
+		}
+	}
+	for _, label := range []string{"", "Y", "0Y", "-1Y", "3W", "03Y", "1.5Y", "51Y"} {
+		if _, err := ParseWindow(label); err == nil {
+			t.Fatalf("ParseWindow(%q): expected error", label)
+		}
+	}
+}
+
+// maxDrawdownPct is the original O(n) per-window drawdown scan. Applied to every rolling
+// window it is O(n²), but it's the reference the sliding-window algorithm is checked against.
+func maxDrawdownPct(window []point) float64 {
+	peak := window[0].nav
+	worst := 0.0
+	for _, p := range window {
+		if p.nav > peak {
+			peak = p.nav
+		}
+		if peak <= 0 {
+			continue
+		}
+		dd := (p.nav/peak - 1.0) * 100.0
+		if dd < worst {
+			worst = dd
+		}
+	}
+	return worst
+}
+
+func TestPointsAsOfHasNoLookahead(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var pts []point
+	for i := 0; i < 900; i++ {
+		pts = append(pts, point{date: start.AddDate(0, 0, i), nav: 100 + float64(i%37)})
+	}
+	asOf := start.AddDate(0, 0, 600)
+
+	cut := pointsAsOf(pts, asOf)
+	if len(cut) != 601 || !cut[len(cut)-1].date.Equal(asOf) {
+		t.Fatalf("expected NAVs through %s inclusive, got %d ending %s", asOf, len(cut), cut[len(cut)-1].date)
+	}
+	if got := pointsAsOf(pts, time.Time{}); len(got) != len(pts) {
+		t.Fatalf("zero asOf should keep all %d points, got %d", len(pts), len(got))
+	}
+
+	want, _, _ := computeWindowParams("X", cut, WindowSpec{Label: "1Y", Months: 12}, DefaultRiskFreeRatePct)
+
+	// Rewriting everything after the cutoff must not change the point-in-time result.
+	future := append([]point(nil), pts...)
+	for i := 601; i < len(future); i++ {
+		future[i].nav = 1
+	}
+	got, _, _ := computeWindowParams("X", pointsAsOf(future, asOf), WindowSpec{Label: "1Y", Months: 12}, DefaultRiskFreeRatePct)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal("as-of result changed when NAVs after the cutoff changed")
+	}
+	if !got.DataEndDate.Time.Equal(asOf) {
+		t.Fatalf("data end date %s, want %s", got.DataEndDate.Time, asOf)
+	}
+}
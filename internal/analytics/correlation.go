@@ -0,0 +1,175 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// Return frequencies the correlation job measures.
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// CorrelationConfig is what the scheduled correlation job computes.
+type CorrelationConfig struct {
+	Windows     []WindowSpec
+	Frequencies []string // FrequencyDaily and/or FrequencyWeekly
+	// MinObservations is the fewest common returns a pair needs; pairs below it are stored as
+	// insufficient, without a correlation.
+	MinObservations int
+}
+
+// DefaultCorrelationConfig is used when nothing is configured.
+var DefaultCorrelationConfig = CorrelationConfig{
+	Windows:         []WindowSpec{{Label: "1Y", Months: 12}, {Label: "3Y", Months: 36}},
+	Frequencies:     []string{FrequencyDaily, FrequencyWeekly},
+	MinObservations: 30,
+}
+
+// pairCorrelation is one fund pair's correlation over the dates both have a NAV for.
+type pairCorrelation struct {
+	codeA, codeB string
+	corr         float64 // NaN when insufficient or either series is flat
+	observations int     // common returns
+	sufficient   bool
+	start, end   time.Time
+}
+
+// RefreshCorrelations rebuilds `fund_correlations` for every tracked fund pair, window and
+// frequency. Each window ends at the latest NAV across all funds, so every pair is measured over
+// the same calendar range; the table is swapped in one transaction.
+func RefreshCorrelations(ctx context.Context, pool *pgxpool.Pool, cfg CorrelationConfig) error {
+	q := db.New(pool)
+	funds, err := q.ListFunds(ctx, db.ListFundsParams{})
+	if err != nil {
+		return err
+	}
+
+	codes := make([]string, 0, len(funds))
+	series := map[string][]point{}
+	var end time.Time
+	for _, f := range funds {
+		pts, err := loadPoints(ctx, q, f.SchemeCode, time.Time{})
+		if errors.Is(err, ErrInsufficientHistory) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		codes = append(codes, f.SchemeCode)
+		series[f.SchemeCode] = pts
+		if last := pts[len(pts)-1].date; last.After(end) {
+			end = last
+		}
+	}
+	sort.Strings(codes)
+
+	var batches []db.InsertFundCorrelationsParams
+	for _, w := range cfg.Windows {
+		start := end.AddDate(0, -w.Months, 0)
+		for _, freq := range cfg.Frequencies {
+			navs := make(map[string][]point, len(codes))
+			for _, code := range codes {
+				navs[code] = resamplePoints(series[code], start, end, freq)
+			}
+			batch := db.InsertFundCorrelationsParams{Window: w.Label, Frequency: freq}
+			for i, a := range codes {
+				for _, b := range codes[i+1:] {
+					appendPair(&batch, correlatePair(a, b, navs[a], navs[b], cfg.MinObservations))
+				}
+			}
+			batches = append(batches, batch)
+		}
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := db.New(tx)
+	if err := qtx.DeleteFundCorrelations(ctx); err != nil {
+		return err
+	}
+	for _, batch := range batches {
+		if err := qtx.InsertFundCorrelations(ctx, batch); err != nil {
+			return fmt.Errorf("insert correlations window=%s frequency=%s: %w", batch.Window, batch.Frequency, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// resamplePoints keeps the NAVs in [start, end] at the given frequency. Weekly keeps the last NAV
+// of each ISO week, dated to the week's Monday so weeks line up whichever day they closed on.
+func resamplePoints(pts []point, start, end time.Time, freq string) []point {
+	var out []point
+	for _, p := range pts {
+		if p.date.Before(start) || p.date.After(end) {
+			continue
+		}
+		if freq != FrequencyWeekly {
+			out = append(out, p)
+			continue
+		}
+		wk := weekStart(p.date)
+		if n := len(out); n > 0 && out[n-1].date.Equal(wk) {
+			out[n-1].nav = p.nav
+			continue
+		}
+		out = append(out, point{date: wk, nav: p.nav})
+	}
+	return out
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Monday = 0
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// correlatePair correlates the log returns of a and b between consecutive common dates, so a
+// holiday or missing NAV in either series spans the same interval in both.
+func correlatePair(codeA, codeB string, a, b []point, minObservations int) pairCorrelation {
+	pc := pairCorrelation{codeA: codeA, codeB: codeB, corr: math.NaN()}
+	aligned := alignDates([][]point{a, b})
+	ca, cb := aligned[0], aligned[1]
+	if len(ca) < 2 {
+		return pc
+	}
+	pc.start, pc.end = ca[0].date, ca[len(ca)-1].date
+	pc.observations = len(ca) - 1
+	if pc.observations < minObservations {
+		return pc
+	}
+	pc.sufficient = true
+
+	ra := make([]float64, pc.observations)
+	rb := make([]float64, pc.observations)
+	for k := 1; k < len(ca); k++ {
+		ra[k-1] = math.Log(ca[k].nav / ca[k-1].nav)
+		rb[k-1] = math.Log(cb[k].nav / cb[k-1].nav)
+	}
+	pc.corr = correlation(ra, rb)
+	return pc
+}
+
+func appendPair(batch *db.InsertFundCorrelationsParams, pc pairCorrelation) {
+	batch.SchemeCodesA = append(batch.SchemeCodesA, pc.codeA)
+	batch.SchemeCodesB = append(batch.SchemeCodesB, pc.codeB)
+	batch.Correlations = append(batch.Correlations, pc.corr)
+	batch.Observations = append(batch.Observations, int32(pc.observations))
+	batch.Sufficient = append(batch.Sufficient, pc.sufficient)
+	batch.PeriodStarts = append(batch.PeriodStarts, pgtype.Date{Time: pc.start, Valid: !pc.start.IsZero()})
+	batch.PeriodEnds = append(batch.PeriodEnds, pgtype.Date{Time: pc.end, Valid: !pc.end.IsZero()})
+}
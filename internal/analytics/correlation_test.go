@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCorrelatePairUsesCommonDates(t *testing.T) {
+	start := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC) // Monday
+	var a, b []point
+	navA, navB := 100.0, 100.0
+	for i := 0; i < 200; i++ {
+		move := 0.004 * math.Sin(float64(i))
+		navA *= 1 + move
+		navB *= 1 + move
+		// Each fund misses different days; b also starts later. On common dates the NAVs move
+		// together, so the correlation must be exactly 1 despite the gaps.
+		if i%7 != 3 {
+			a = append(a, point{date: start.AddDate(0, 0, i), nav: navA})
+		}
+		if i >= 20 && i%11 != 5 {
+			b = append(b, point{date: start.AddDate(0, 0, i), nav: navB})
+		}
+	}
+
+	pc := correlatePair("A", "B", a, b, 30)
+	if !pc.sufficient || math.Abs(pc.corr-1) > 1e-9 {
+		t.Fatalf("expected sufficient correlation 1, got %+v", pc)
+	}
+	if !pc.start.Equal(start.AddDate(0, 0, 20)) {
+		t.Fatalf("period starts %s, want the first common date", pc.start)
+	}
+	common := 0
+	for i := 20; i < 200; i++ {
+		if i%7 != 3 && i%11 != 5 {
+			common++
+		}
+	}
+	if pc.observations != common-1 {
+		t.Fatalf("observations %d, want %d", pc.observations, common-1)
+	}
+
+	short := correlatePair("A", "B", a, b, common)
+	if short.sufficient || !math.IsNaN(short.corr) || short.observations != common-1 {
+		t.Fatalf("expected an insufficient pair with its observation count, got %+v", short)
+	}
+}
+
+func TestResamplePointsWeeklyAlignsWeekdays(t *testing.T) {
+	mon := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+	// a's week closes on Friday, b's on Thursday (Friday holiday): both map to the same week.
+	a := []point{{date: mon, nav: 1}, {date: mon.AddDate(0, 0, 4), nav: 2}, {date: mon.AddDate(0, 0, 7), nav: 3}}
+	b := []point{{date: mon.AddDate(0, 0, 3), nav: 5}, {date: mon.AddDate(0, 0, 8), nav: 6}}
+
+	wa := resamplePoints(a, mon, mon.AddDate(0, 0, 14), FrequencyWeekly)
+	wb := resamplePoints(b, mon, mon.AddDate(0, 0, 14), FrequencyWeekly)
+	if len(wa) != 2 || wa[0].nav != 2 || !wa[0].date.Equal(mon) || !wa[1].date.Equal(mon.AddDate(0, 0, 7)) {
+		t.Fatalf("unexpected weekly series for a: %+v", wa)
+	}
+	if len(wb) != 2 || !wb[0].date.Equal(wa[0].date) || !wb[1].date.Equal(wa[1].date) {
+		t.Fatalf("weekly series don't line up: %+v vs %+v", wa, wb)
+	}
+}
@@ -0,0 +1,178 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// HistogramBaseWidth is the bucket width (percentage points) the histogram is stored at.
+// Coarser histograms are produced on read by merging buckets, so widths must be multiples of it.
+const HistogramBaseWidth = 1.0
+
+// Distribution is the full shape of a window's rolling (absolute) returns, stored as JSONB in
+// `fund_analytics.distribution`. All values are percentages rounded to 2 decimals.
+type Distribution struct {
+	P5  float64 `json:"p5"`
+	P10 float64 `json:"p10"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"sd"`
+	Skewness float64 `json:"skew"`
+
+	// ProbNegative is the share of rolling periods with a return below zero (0..1).
+	ProbNegative float64 `json:"pneg"`
+
+	// Quantiles holds the 0th..100th percentiles, used to answer "probability of beating X%".
+	Quantiles []float64 `json:"q"`
+
+	// Histogram counts periods in [HistStart + k*HistogramBaseWidth, HistStart + (k+1)*HistogramBaseWidth).
+	HistStart float64 `json:"hs"`
+	Hist      []int   `json:"h"`
+}
+
+// HistogramBucket is one bucket of a (possibly merged) histogram: From <= return < To.
+type HistogramBucket struct {
+	From  float64
+	To    float64
+	Count int
+}
+
+// newDistribution summarises sorted returns; it returns nil when there are none.
+func newDistribution(sorted []float64) *Distribution {
+	n := len(sorted)
+	if n == 0 {
+		return nil
+	}
+
+	d := &Distribution{
+		P5:        round2(percentileSorted(sorted, 0.05)),
+		P10:       round2(percentileSorted(sorted, 0.10)),
+		P90:       round2(percentileSorted(sorted, 0.90)),
+		P95:       round2(percentileSorted(sorted, 0.95)),
+		Quantiles: make([]float64, 101),
+	}
+	for k := range d.Quantiles {
+		d.Quantiles[k] = round2(percentileSorted(sorted, float64(k)/100))
+	}
+
+	var sum float64
+	negative := 0
+	for _, r := range sorted {
+		sum += r
+		if r < 0 {
+			negative++
+		}
+	}
+	mean := sum / float64(n)
+	d.Mean = round2(mean)
+	d.ProbNegative = round4(float64(negative) / float64(n))
+
+	// Sample standard deviation; skewness is the moment coefficient (m3 / m2^1.5), 0 when flat.
+	var m2, m3 float64
+	for _, r := range sorted {
+		dev := r - mean
+		m2 += dev * dev
+		m3 += dev * dev * dev
+	}
+	if n > 1 {
+		d.StdDev = round2(math.Sqrt(m2 / float64(n-1)))
+	}
+	if m2 > 0 {
+		m2 /= float64(n)
+		m3 /= float64(n)
+		d.Skewness = round4(m3 / math.Pow(m2, 1.5))
+	}
+
+	d.HistStart = math.Floor(sorted[0]/HistogramBaseWidth) * HistogramBaseWidth
+	buckets := int(math.Floor((sorted[n-1]-d.HistStart)/HistogramBaseWidth)) + 1
+	d.Hist = make([]int, buckets)
+	for _, r := range sorted {
+		k := int(math.Floor((r - d.HistStart) / HistogramBaseWidth))
+		if k >= buckets {
+			k = buckets - 1
+		}
+		d.Hist[k]++
+	}
+	return d
+}
+
+// encodeDistribution marshals d for storage; nil stays NULL.
+func encodeDistribution(d *Distribution) []byte {
+	if d == nil {
+		return nil
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// DecodeDistribution parses a stored distribution. It returns nil, nil for NULL.
+func DecodeDistribution(b []byte) (*Distribution, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var d Distribution
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("decode distribution: %w", err)
+	}
+	return &d, nil
+}
+
+// Histogram merges the stored buckets into buckets of the given width, which must be a positive
+// multiple of HistogramBaseWidth. Buckets are aligned to multiples of width.
+func (d *Distribution) Histogram(width float64) ([]HistogramBucket, error) {
+	per := width / HistogramBaseWidth
+	if width <= 0 || per != math.Trunc(per) {
+		return nil, fmt.Errorf("bucket width must be a positive multiple of %g", HistogramBaseWidth)
+	}
+	if len(d.Hist) == 0 {
+		return []HistogramBucket{}, nil
+	}
+
+	start := math.Floor(d.HistStart/width) * width
+	var out []HistogramBucket
+	for k, c := range d.Hist {
+		from := d.HistStart + float64(k)*HistogramBaseWidth
+		idx := int(math.Floor((from - start) / width))
+		for len(out) <= idx {
+			lo := start + float64(len(out))*width
+			out = append(out, HistogramBucket{From: lo, To: lo + width})
+		}
+		out[idx].Count += c
+	}
+	return out, nil
+}
+
+// ProbAbove estimates the share of rolling periods with a return above x (in %) by inverting
+// the stored quantiles, so it is accurate to about one percentile.
+func (d *Distribution) ProbAbove(x float64) float64 {
+	q := d.Quantiles
+	if len(q) == 0 {
+		return math.NaN()
+	}
+	if x < q[0] {
+		return 1
+	}
+	if x >= q[len(q)-1] {
+		return 0
+	}
+
+	// k is the first quantile above x; x falls in [q[k-1], q[k]).
+	k := sort.Search(len(q), func(i int) bool { return q[i] > x })
+	step := 1.0 / float64(len(q)-1)
+	below := float64(k-1) * step
+	if span := q[k] - q[k-1]; span > 0 {
+		below += (x - q[k-1]) / span * step
+	}
+	return 1 - below
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+
+func round4(v float64) float64 { return math.Round(v*10000) / 10000 }
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistribution(t *testing.T) {
+	returns := []float64{-2, -1, 0, 1, 2, 3, 4, 5, 6, 7}
+	d, err := DecodeDistribution(encodeDistribution(newDistribution(returns)))
+	if err != nil || d == nil {
+		t.Fatalf("round trip: %v, %v", d, err)
+	}
+
+	if d.Mean != 2.5 || d.StdDev != 3.03 || d.Skewness != 0 || d.ProbNegative != 0.2 {
+		t.Fatalf("unexpected moments: %+v", d)
+	}
+	if len(d.Quantiles) != 101 || d.Quantiles[0] != -2 || d.Quantiles[100] != 7 || d.P5 != -1.55 {
+		t.Fatalf("unexpected percentiles: p5=%v q=%v", d.P5, d.Quantiles)
+	}
+
+	for x, want := range map[float64]float64{-3: 1, 2.5: 0.5, 7: 0} {
+		if got := d.ProbAbove(x); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("ProbAbove(%v) = %v, want %v", x, got, want)
+		}
+	}
+
+	buckets, err := d.Histogram(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HistogramBucket{{From: -5, To: 0, Count: 2}, {From: 0, To: 5, Count: 5}, {From: 5, To: 10, Count: 3}}
+	if len(buckets) != len(want) {
+		t.Fatalf("expected %d buckets, got %+v", len(want), buckets)
+	}
+	for k := range want {
+		if buckets[k] != want[k] {
+			t.Fatalf("bucket %d: got %+v, want %+v", k, buckets[k], want[k])
+		}
+	}
+	if _, err := d.Histogram(2.5); err == nil {
+		t.Fatal("expected error for a width that isn't a multiple of the base width")
+	}
+
+	if newDistribution(nil) != nil {
+		t.Fatal("expected nil distribution without periods")
+	}
+}
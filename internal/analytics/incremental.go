@@ -0,0 +1,158 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// ComputeIncrementalAndUpsert brings a scheme's analytics up to date after NAVs were appended,
+// reading only the stored rolling series plus the tail of the NAV history the new periods can
+// reach. Results are identical to ComputeAndUpsert, which it falls back to when a window has no
+// stored state (first run, new window) or the state and series disagree.
+// A nil windows slice means DefaultWindows.
+func ComputeIncrementalAndUpsert(ctx context.Context, pool *pgxpool.Pool, schemeCode string, windows []WindowSpec, riskFreePct float64) error {
+	if windows == nil {
+		windows = DefaultWindows
+	}
+
+	q := db.New(pool)
+	states := make([]*windowState, len(windows))
+	var prevLatest time.Time
+	for k, w := range windows {
+		st, ok, err := loadWindowState(ctx, q, schemeCode, w)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ComputeAndUpsert(ctx, pool, schemeCode, windows, riskFreePct)
+		}
+		states[k] = st
+		if st.lastEnd.After(prevLatest) {
+			prevLatest = st.lastEnd
+		}
+	}
+	if prevLatest.IsZero() {
+		// No window has a single period yet; the tail would be the whole history anyway.
+		return ComputeAndUpsert(ctx, pool, schemeCode, windows, riskFreePct)
+	}
+
+	// The bounds count the same positive NAVs toPoints keeps, so data_start_date and nav_points
+	// match a full recompute.
+	bounds, err := q.GetNavHistoryBounds(ctx, schemeCode)
+	if err != nil {
+		return err
+	}
+	if !bounds.StartDate.Valid || !bounds.EndDate.Valid {
+		return fmt.Errorf("%w: no nav history for scheme_code=%s", ErrInsufficientHistory, schemeCode)
+	}
+
+	// The tail must cover every window's new periods, the longest trailing period and the
+	// calendar years from the previous latest NAV onwards.
+	need := time.Date(prevLatest.Year()-1, 12, 31, 0, 0, 0, 0, time.UTC)
+	for _, spec := range TrailingPeriods {
+		if t := monthsBefore(bounds.EndDate.Time.UTC(), spec.Months); t.Before(need) {
+			need = t
+		}
+	}
+	for _, st := range states {
+		if t := tailAnchor(st.lastEnd, st.months); t.Before(need) {
+			need = t
+		}
+	}
+	from := bounds.StartDate
+	anchor, err := q.GetNavOnOrBefore(ctx, db.GetNavOnOrBeforeParams{
+		SchemeCode: schemeCode,
+		NavDate:    pgtype.Date{Time: need, Valid: true},
+	})
+	switch {
+	case err == nil:
+		from = anchor.NavDate
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	rows, err := q.ListNavHistoryBetween(ctx, db.ListNavHistoryBetweenParams{
+		SchemeCode: schemeCode,
+		NavDate:    from,
+		NavDate_2:  bounds.EndDate,
+	})
+	if err != nil {
+		return err
+	}
+	tail := toPoints(rows)
+	if len(tail) < 2 {
+		return fmt.Errorf("%w: too few usable nav points for scheme_code=%s", ErrInsufficientHistory, schemeCode)
+	}
+
+	for k, w := range windows {
+		st := states[k]
+		series := st.extend(tail)
+		params := analyticsParams(
+			schemeCode, w.Label, st.result(riskFreePct).withTrailingRisk(tail, w.Months, riskFreePct),
+			bounds.StartDate.Time.UTC(), bounds.EndDate.Time.UTC(), int(bounds.NavPoints),
+		)
+		if err := persistWindow(ctx, pool, params, series, false, st); err != nil {
+			return err
+		}
+	}
+
+	return upsertPeriodReturns(ctx, q, schemeCode, tail, prevLatest.Year())
+}
+
+// loadWindowState rebuilds a window's state from `fund_analytics_state` and the stored rolling
+// series. ok is false when there is no usable state and the window needs a full recompute.
+func loadWindowState(ctx context.Context, q *db.Queries, schemeCode string, w WindowSpec) (*windowState, bool, error) {
+	saved, err := q.GetFundAnalyticsState(ctx, db.GetFundAnalyticsStateParams{SchemeCode: schemeCode, Window: w.Label})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	rows, err := q.ListFundRollingReturns(ctx, db.ListFundRollingReturnsParams{SchemeCode: schemeCode, Window: w.Label})
+	if err != nil {
+		return nil, false, err
+	}
+
+	st := newWindowState(w.Months)
+	if len(rows) == 0 {
+		return st, !saved.LastEndDate.Valid && !saved.WorstDrawdown.Valid, nil
+	}
+	if !saved.LastEndDate.Valid || !saved.WorstDrawdown.Valid ||
+		!rows[len(rows)-1].EndDate.Time.Equal(saved.LastEndDate.Time) {
+		return nil, false, nil
+	}
+
+	st.returns = make([]float64, 0, len(rows))
+	st.cagrs = make([]float64, 0, len(rows))
+	for _, row := range rows {
+		c := math.NaN()
+		if row.CagrPct.Valid {
+			c = row.CagrPct.Float64
+		}
+		st.returns = append(st.returns, row.ReturnPct)
+		st.cagrs = append(st.cagrs, c)
+	}
+	st.lastEnd = saved.LastEndDate.Time.UTC()
+	st.worstDrawdown = saved.WorstDrawdown.Float64
+	return st, true, nil
+}
+
+// tailAnchor is the earliest date the start of a period ending after lastEnd can fall on.
+// Period starts only move forward with their end date, so that is lastEnd's own period start.
+// A zero lastEnd means the window has no periods yet and needs the full history.
+func tailAnchor(lastEnd time.Time, months int) time.Time {
+	if lastEnd.IsZero() {
+		return time.Time{}
+	}
+	return monthsBefore(lastEnd, months)
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestIncrementalMatchesFullCompute runs both compute paths over the same NAV history, in a
+// scratch schema of the database at TEST_DATABASE_URL, and requires identical stored results.
+// The history starts with a zero NAV and has another mid-series, which toPoints drops.
+func TestIncrementalMatchesFullCompute(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping integration test")
+	}
+	ctx := context.Background()
+	pool := scratchSchema(t, dsn)
+	const code = "900001"
+
+	exec := func(sql string, args ...any) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, sql, args...); err != nil {
+			t.Fatalf("%v\n%s", err, sql)
+		}
+	}
+	exec(`INSERT INTO funds (scheme_code, scheme_name, amc, category) VALUES ($1, 'Test Fund', 'AMC', 'Equity')`, code)
+	insertNavs := func(from, to string) {
+		t.Helper()
+		exec(`INSERT INTO nav_history (scheme_code, nav_date, nav_value)
+			SELECT $1, d::date, round((20 + 5 * sin(extract(epoch FROM d) / 2e6) + (d::date - DATE '2018-01-01') / 300.0)::numeric, 4)
+			FROM generate_series($2::date, $3::date, INTERVAL '1 day') AS d
+			WHERE extract(isodow FROM d) < 6`, code, from, to)
+	}
+	insertNavs("2018-01-01", "2023-06-30")
+	exec(`UPDATE nav_history SET nav_value = 0 WHERE scheme_code = $1 AND nav_date IN ('2018-01-01', '2020-03-02')`, code)
+
+	windows := []WindowSpec{{Label: "1Y", Months: 12}, {Label: "3Y", Months: 36}}
+	if err := ComputeAndUpsert(ctx, pool, code, windows, DefaultRiskFreeRatePct); err != nil {
+		t.Fatal(err)
+	}
+	insertNavs("2023-07-01", "2024-05-31")
+	if err := ComputeIncrementalAndUpsert(ctx, pool, code, windows, DefaultRiskFreeRatePct); err != nil {
+		t.Fatal(err)
+	}
+	incremental := storedResults(t, pool, code)
+	if err := ComputeAndUpsert(ctx, pool, code, windows, DefaultRiskFreeRatePct); err != nil {
+		t.Fatal(err)
+	}
+	if full := storedResults(t, pool, code); !reflect.DeepEqual(incremental, full) {
+		for table := range full {
+			if !reflect.DeepEqual(incremental[table], full[table]) {
+				t.Errorf("%s differs:\nincremental %v\nfull        %v", table, incremental[table], full[table])
+			}
+		}
+	}
+}
+
+// storedResults reads every row the compute writes for code, as JSON without timestamps.
+func storedResults(t *testing.T, pool *pgxpool.Pool, code string) map[string][]string {
+	t.Helper()
+	out := map[string][]string{}
+	for _, table := range []string{"fund_analytics", "fund_rolling_returns", "fund_trailing_returns", "fund_calendar_returns"} {
+		rows, err := pool.Query(context.Background(), fmt.Sprintf(
+			`SELECT (to_jsonb(t) - 'computed_at' - 'updated_at' - 'created_at')::text FROM %s t
+			WHERE scheme_code = $1 ORDER BY 1`, table), code)
+		if err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				t.Fatal(err)
+			}
+			out[table] = append(out[table], row)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out
+}
+
+// scratchSchema applies every migration to a new schema, dropped after the test, and returns a
+// pool whose search_path is that schema.
+func scratchSchema(t *testing.T, dsn string) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+	schema := fmt.Sprintf("mf_test_%d", time.Now().UnixNano())
+
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse dsn: %v", err)
+	}
+	// public stays on the path for extensions such as pg_trgm.
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrations, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, path := range migrations {
+		ddl, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, string(ddl)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+	}
+	return pool
+}
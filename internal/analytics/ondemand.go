@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// ComputeWindowOnDemand computes analytics for one window without persisting them. It serves
+// ad-hoc windows that aren't in the precomputed set, and point-in-time analytics: a non-zero asOf
+// uses only NAVs up to and including that date. The result mirrors a `fund_analytics` row.
+func ComputeWindowOnDemand(ctx context.Context, pool *pgxpool.Pool, schemeCode string, w WindowSpec, asOf time.Time, riskFreePct float64) (db.FundAnalytic, error) {
+	pts, err := loadPoints(ctx, db.New(pool), schemeCode, asOf)
+	if err != nil {
+		return db.FundAnalytic{}, err
+	}
+	p, _, _ := computeWindowParams(schemeCode, pts, w, riskFreePct)
+	return fundAnalyticFromParams(p), nil
+}
+
+// fundAnalyticFromParams turns freshly computed upsert params into the row they would produce.
+func fundAnalyticFromParams(p db.UpsertFundAnalyticsParams) db.FundAnalytic {
+	return db.FundAnalytic{
+		SchemeCode: p.SchemeCode,
+		Window:     p.Window,
+
+		RollingMin:    p.RollingMin,
+		RollingMax:    p.RollingMax,
+		RollingMedian: p.RollingMedian,
+		RollingP25:    p.RollingP25,
+		RollingP75:    p.RollingP75,
+
+		MaxDrawdown: p.MaxDrawdown,
+
+		CagrMin:    p.CagrMin,
+		CagrMax:    p.CagrMax,
+		CagrMedian: p.CagrMedian,
+
+		DataStartDate:  p.DataStartDate,
+		DataEndDate:    p.DataEndDate,
+		NavPoints:      p.NavPoints,
+		RollingPeriods: p.RollingPeriods,
+		ComputedAt:     pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+		Distribution:   p.Distribution,
+		Volatility:     p.Volatility,
+		Sharpe:         p.Sharpe,
+		Consistency:    p.Consistency,
+		RiskFreeRate:   p.RiskFreeRate,
+	}
+}
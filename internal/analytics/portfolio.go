@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// Portfolio rebalancing frequencies.
+const (
+	RebalanceNone      = "none"
+	RebalanceMonthly   = "monthly"
+	RebalanceQuarterly = "quarterly"
+	RebalanceYearly    = "yearly"
+)
+
+// portfolioBaseNAV is the synthetic NAV a portfolio starts at.
+const portfolioBaseNAV = 100.0
+
+// Holding is one fund in a model portfolio. Weights are relative; they are normalised to sum to 1.
+type Holding struct {
+	SchemeCode string
+	Weight     float64
+}
+
+// NAVPoint is one value of a synthesized NAV series.
+type NAVPoint struct {
+	Date time.Time
+	NAV  float64
+}
+
+// PortfolioNAV synthesizes a portfolio's NAV series from its holdings' `nav_history`. It starts
+// at 100 on the first date every holding has a NAV and is only valued on common dates.
+func PortfolioNAV(ctx context.Context, pool *pgxpool.Pool, holdings []Holding, rebalance string) ([]NAVPoint, error) {
+	pts, err := loadPortfolioPoints(ctx, pool, holdings, rebalance)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]NAVPoint, len(pts))
+	for i, p := range pts {
+		out[i] = NAVPoint{Date: p.date, NAV: p.nav}
+	}
+	return out, nil
+}
+
+// ComputePortfolioWindow runs the fund analytics engine (rolling returns, CAGR, drawdown, risk)
+// over a portfolio's synthesized NAV. The result mirrors a `fund_analytics` row labelled label.
+func ComputePortfolioWindow(ctx context.Context, pool *pgxpool.Pool, label string, holdings []Holding, rebalance string, w WindowSpec, riskFreePct float64) (db.FundAnalytic, error) {
+	pts, err := loadPortfolioPoints(ctx, pool, holdings, rebalance)
+	if err != nil {
+		return db.FundAnalytic{}, err
+	}
+	p, _, _ := computeWindowParams(label, pts, w, riskFreePct)
+	return fundAnalyticFromParams(p), nil
+}
+
+func loadPortfolioPoints(ctx context.Context, pool *pgxpool.Pool, holdings []Holding, rebalance string) ([]point, error) {
+	if len(holdings) == 0 {
+		return nil, fmt.Errorf("%w: portfolio has no holdings", ErrInsufficientHistory)
+	}
+	q := db.New(pool)
+	series := make([][]point, len(holdings))
+	weights := make([]float64, len(holdings))
+	for i, h := range holdings {
+		pts, err := loadPoints(ctx, q, h.SchemeCode, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		series[i] = pts
+		weights[i] = h.Weight
+	}
+	pts := portfolioPoints(series, weights, rebalance)
+	if len(pts) < 2 {
+		return nil, fmt.Errorf("%w: holdings share fewer than 2 nav dates", ErrInsufficientHistory)
+	}
+	return pts, nil
+}
+
+// portfolioPoints values a portfolio on the dates every holding has a NAV. Units are bought at the
+// target weights on the first date and, unless rebalance is none, bought back to the target
+// weights on the first common date of each new rebalancing period.
+func portfolioPoints(series [][]point, weights []float64, rebalance string) []point {
+	aligned := alignDates(series)
+	if len(aligned) == 0 || len(aligned[0]) == 0 {
+		return nil
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	units := make([]float64, len(weights))
+	buy := func(k int, value float64) {
+		for i, w := range weights {
+			units[i] = value * (w / total) / aligned[i][k].nav
+		}
+	}
+
+	n := len(aligned[0])
+	out := make([]point, n)
+	buy(0, portfolioBaseNAV)
+	for k := 0; k < n; k++ {
+		date := aligned[0][k].date
+		var value float64
+		for i := range units {
+			value += units[i] * aligned[i][k].nav
+		}
+		if k > 0 && rebalancePeriod(date, rebalance) != rebalancePeriod(aligned[0][k-1].date, rebalance) {
+			buy(k, value)
+		}
+		out[k] = point{date: date, nav: value}
+	}
+	return out
+}
+
+// rebalancePeriod identifies the rebalancing period t falls in; it is constant for none.
+func rebalancePeriod(t time.Time, rebalance string) int {
+	switch rebalance {
+	case RebalanceMonthly:
+		return t.Year()*12 + int(t.Month()) - 1
+	case RebalanceQuarterly:
+		return t.Year()*4 + (int(t.Month())-1)/3
+	case RebalanceYearly:
+		return t.Year()
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPortfolioPointsRebalancing(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// a doubles every month, b stays flat. b has one extra date a lacks, which must be skipped.
+	a := []point{
+		{date: start, nav: 10},
+		{date: start.AddDate(0, 1, 0), nav: 20},
+		{date: start.AddDate(0, 2, 0), nav: 40},
+	}
+	b := []point{
+		{date: start, nav: 5},
+		{date: start.AddDate(0, 0, 15), nav: 5},
+		{date: start.AddDate(0, 1, 0), nav: 5},
+		{date: start.AddDate(0, 2, 0), nav: 5},
+	}
+
+	hold := portfolioPoints([][]point{a, b}, []float64{50, 50}, RebalanceNone)
+	if len(hold) != 3 {
+		t.Fatalf("expected 3 common dates, got %d", len(hold))
+	}
+	// Buy and hold: 50 in a grows to 200, 50 in b stays 50.
+	if want := []float64{100, 150, 250}; !navsEqual(hold, want) {
+		t.Fatalf("buy-and-hold NAVs %v, want %v", hold, want)
+	}
+
+	monthly := portfolioPoints([][]point{a, b}, []float64{1, 1}, RebalanceMonthly)
+	// Rebalanced to 50/50 at 150 on the second month: 75 in a doubles, 75 in b stays.
+	if want := []float64{100, 150, 225}; !navsEqual(monthly, want) {
+		t.Fatalf("monthly NAVs %v, want %v", monthly, want)
+	}
+}
+
+func navsEqual(pts []point, want []float64) bool {
+	if len(pts) != len(want) {
+		return false
+	}
+	for i := range pts {
+		if math.Abs(pts[i].nav-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// Projection limits.
+const (
+	DefaultProjectionSimulations = 2000
+	MaxProjectionSimulations     = 10000
+	MaxProjectionMonths          = 600
+	// minProjectionSamples is the fewest historical monthly returns worth resampling.
+	minProjectionSamples = 12
+)
+
+// ProjectionParams describes an investment to project forward. Lumpsum is invested at the start;
+// SIP is added at the start of every month.
+type ProjectionParams struct {
+	Lumpsum     float64
+	SIP         float64
+	Months      int
+	Target      float64 // 0 when there is no target corpus
+	Simulations int
+	Seed        int64
+}
+
+// ProjectionPoint is the distribution of simulated values at the end of a month.
+type ProjectionPoint struct {
+	Month    int
+	Invested float64
+	P10      float64
+	P50      float64
+	P90      float64
+}
+
+type ProjectionResult struct {
+	Samples     int // historical monthly returns resampled
+	SampleStart time.Time
+	SampleEnd   time.Time
+	Invested    float64
+	Path        []ProjectionPoint // one per month
+	ProbTarget  float64           // NaN without a target
+}
+
+// ProjectFund projects p over a fund's `nav_history`.
+func ProjectFund(ctx context.Context, pool *pgxpool.Pool, schemeCode string, p ProjectionParams) (ProjectionResult, error) {
+	pts, err := loadPoints(ctx, db.New(pool), schemeCode, time.Time{})
+	if err != nil {
+		return ProjectionResult{}, err
+	}
+	return project(pts, p)
+}
+
+// ProjectPortfolio projects p over a model portfolio's synthesized NAV.
+func ProjectPortfolio(ctx context.Context, pool *pgxpool.Pool, holdings []Holding, rebalance string, p ProjectionParams) (ProjectionResult, error) {
+	pts, err := loadPortfolioPoints(ctx, pool, holdings, rebalance)
+	if err != nil {
+		return ProjectionResult{}, err
+	}
+	return project(pts, p)
+}
+
+// project bootstraps the series' 1-month rolling returns (the same rolling periods computeWindow
+// summarises, one ending on every NAV date): each simulated month draws one historical return at
+// random, with replacement. Percentiles are taken across simulations month by month, so the p50
+// path is the median value at each month rather than one simulated path.
+func project(pts []point, p ProjectionParams) (ProjectionResult, error) {
+	var returns []float64
+	var res ProjectionResult
+	forEachRollingPeriod(pts, 1, func(i, j int, ret, _ float64) {
+		if len(returns) == 0 {
+			res.SampleStart = pts[i].date
+		}
+		res.SampleEnd = pts[j].date
+		returns = append(returns, ret/100.0)
+	})
+	if len(returns) < minProjectionSamples {
+		return ProjectionResult{}, fmt.Errorf("%w: fewer than %d monthly returns", ErrInsufficientHistory, minProjectionSamples)
+	}
+	res.Samples = len(returns)
+
+	sims := p.Simulations
+	if sims <= 0 {
+		sims = DefaultProjectionSimulations
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+	values := make([]float64, sims)
+	for s := range values {
+		values[s] = p.Lumpsum
+	}
+	sorted := make([]float64, sims)
+
+	res.Invested = p.Lumpsum
+	res.Path = make([]ProjectionPoint, 0, p.Months)
+	for m := 1; m <= p.Months; m++ {
+		res.Invested += p.SIP
+		for s := range values {
+			values[s] = (values[s] + p.SIP) * (1 + returns[rng.Intn(len(returns))])
+		}
+		copy(sorted, values)
+		sort.Float64s(sorted)
+		res.Path = append(res.Path, ProjectionPoint{
+			Month:    m,
+			Invested: res.Invested,
+			P10:      percentileSorted(sorted, 0.10),
+			P50:      percentileSorted(sorted, 0.50),
+			P90:      percentileSorted(sorted, 0.90),
+		})
+	}
+
+	res.ProbTarget = math.NaN()
+	if p.Target > 0 {
+		var hits int
+		for _, v := range values {
+			if v >= p.Target {
+				hits++
+			}
+		}
+		res.ProbTarget = float64(hits) / float64(sims)
+	}
+	return res, nil
+}
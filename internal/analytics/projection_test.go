@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func monthlyPoints(n int, growth func(k int) float64) []point {
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	pts := make([]point, n)
+	nav := 100.0
+	for k := range pts {
+		if k > 0 {
+			nav *= 1 + growth(k)
+		}
+		pts[k] = point{date: start.AddDate(0, k, 0), nav: nav}
+	}
+	return pts
+}
+
+func TestProjectConstantReturns(t *testing.T) {
+	pts := monthlyPoints(24, func(int) float64 { return 0.01 })
+	res, err := project(pts, ProjectionParams{Lumpsum: 1000, SIP: 100, Months: 12, Target: 2000, Simulations: 50, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Samples != 23 {
+		t.Fatalf("expected 23 monthly returns, got %d", res.Samples)
+	}
+
+	// Every draw is +1%, so all simulations agree.
+	want := 1000.0
+	for m := 0; m < 12; m++ {
+		want = (want + 100) * 1.01
+	}
+	last := res.Path[len(res.Path)-1]
+	for _, v := range []float64{last.P10, last.P50, last.P90} {
+		if math.Abs(v-want) > 1e-6 {
+			t.Fatalf("final percentiles %+v, want all %v", last, want)
+		}
+	}
+	if res.Invested != 2200 || last.Invested != 2200 {
+		t.Fatalf("invested %v, want 2200", res.Invested)
+	}
+	if res.ProbTarget != 1 {
+		t.Fatalf("probability of reaching 2000 = %v, want 1", res.ProbTarget)
+	}
+}
+
+func TestProjectSeedIsReproducible(t *testing.T) {
+	pts := monthlyPoints(60, func(k int) float64 { return 0.05 * math.Sin(float64(k)) })
+	p := ProjectionParams{SIP: 5000, Months: 36, Target: 200000, Simulations: 500, Seed: 42}
+
+	a, err := project(pts, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := project(pts, p)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed gave different projections")
+	}
+	for _, pt := range a.Path {
+		if !(pt.P10 <= pt.P50 && pt.P50 <= pt.P90) {
+			t.Fatalf("percentiles out of order at month %d: %+v", pt.Month, pt)
+		}
+	}
+
+	p.Seed = 43
+	c, _ := project(pts, p)
+	if reflect.DeepEqual(a.Path, c.Path) {
+		t.Fatal("different seeds gave identical projections")
+	}
+}
+
+func TestProjectInsufficientHistory(t *testing.T) {
+	if _, err := project(monthlyPoints(6, func(int) float64 { return 0.01 }), ProjectionParams{SIP: 1, Months: 1}); err == nil {
+		t.Fatal("expected an error for 5 monthly returns")
+	}
+}
@@ -0,0 +1,175 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"mf-analytics-service/internal/db"
+)
+
+// TrailingSpec is a point-to-point period ending at the latest NAV. Months == 0 means YTD.
+type TrailingSpec struct {
+	Label  string
+	Months int
+}
+
+var TrailingPeriods = []TrailingSpec{
+	{Label: "1M", Months: 1},
+	{Label: "3M", Months: 3},
+	{Label: "6M", Months: 6},
+	{Label: "YTD"},
+	{Label: "1Y", Months: 12},
+	{Label: "3Y", Months: 36},
+	{Label: "5Y", Months: 60},
+}
+
+type trailingResult struct {
+	label      string
+	start, end time.Time
+	absolute   float64
+	annualized float64 // NaN for periods shorter than a year
+	ok         bool
+}
+
+type calendarResult struct {
+	year       int
+	start, end time.Time
+	ret        float64
+	complete   bool
+}
+
+// trailingReturns computes point-to-point returns as of the latest NAV. The start NAV is the
+// last one at or before the period start; periods reaching before inception are not ok.
+func trailingReturns(pts []point) []trailingResult {
+	out := make([]trailingResult, 0, len(TrailingPeriods))
+	if len(pts) == 0 {
+		return out
+	}
+	last := pts[len(pts)-1]
+
+	for _, spec := range TrailingPeriods {
+		res := trailingResult{label: spec.Label, annualized: math.NaN()}
+
+		startNeed := monthsBefore(last.date, spec.Months)
+		if spec.Months == 0 {
+			startNeed = time.Date(last.date.Year()-1, 12, 31, 0, 0, 0, 0, time.UTC)
+		}
+		if i, ok := navOnOrBefore(pts, startNeed); ok && pts[i].date.Before(last.date) {
+			res.ok = true
+			res.start = pts[i].date
+			res.end = last.date
+			res.absolute = (last.nav/pts[i].nav - 1.0) * 100.0
+			if spec.Months >= 12 {
+				res.annualized = (math.Pow(last.nav/pts[i].nav, 12.0/float64(spec.Months)) - 1.0) * 100.0
+			}
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// calendarYearReturns computes the return for every calendar year in the history, measured from
+// the last NAV of the previous year to the last NAV of the year. The inception year (no prior
+// year-end NAV) and the running year are returned but flagged incomplete.
+func calendarYearReturns(pts []point) []calendarResult {
+	if len(pts) < 2 {
+		return nil
+	}
+	last := pts[len(pts)-1]
+
+	var out []calendarResult
+	for y := pts[0].date.Year(); y <= last.date.Year(); y++ {
+		yearEnd := time.Date(y, 12, 31, 0, 0, 0, 0, time.UTC)
+		endIdx, ok := navOnOrBefore(pts, yearEnd)
+		if !ok || pts[endIdx].date.Year() != y {
+			continue // no NAV at all in this year
+		}
+
+		complete := true
+		startIdx, ok := navOnOrBefore(pts, yearEnd.AddDate(-1, 0, 0))
+		if !ok {
+			// Inception year: measure from the first NAV.
+			startIdx = 0
+			complete = false
+		}
+		if last.date.Before(yearEnd) {
+			complete = false
+		}
+		if startIdx >= endIdx {
+			continue
+		}
+
+		out = append(out, calendarResult{
+			year:     y,
+			start:    pts[startIdx].date,
+			end:      pts[endIdx].date,
+			ret:      (pts[endIdx].nav/pts[startIdx].nav - 1.0) * 100.0,
+			complete: complete,
+		})
+	}
+	return out
+}
+
+// navOnOrBefore returns the index of the last point dated at or before t.
+func navOnOrBefore(pts []point, t time.Time) (int, bool) {
+	i := sort.Search(len(pts), func(k int) bool { return pts[k].date.After(t) })
+	if i == 0 {
+		return 0, false
+	}
+	return i - 1, true
+}
+
+// monthsBefore is t moved back months calendar months, clamped to the last day of the target
+// month: 31 May - 3 months is 29 Feb, where AddDate would overflow into 2 Mar.
+func monthsBefore(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m-time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// upsertPeriodReturns stores trailing returns as of the last point and calendar-year returns for
+// years >= fromYear (pts may then be a tail starting at the NAV before that year).
+func upsertPeriodReturns(ctx context.Context, q *db.Queries, schemeCode string, pts []point, fromYear int) error {
+	for _, res := range trailingReturns(pts) {
+		params := db.UpsertFundTrailingReturnParams{
+			SchemeCode: schemeCode,
+			Period:     res.label,
+		}
+		if res.ok {
+			params.StartDate = pgtype.Date{Time: res.start, Valid: true}
+			params.EndDate = pgtype.Date{Time: res.end, Valid: true}
+			params.AbsoluteReturn = mustNumeric(res.absolute)
+			if !math.IsNaN(res.annualized) {
+				params.AnnualizedReturn = mustNumeric(res.annualized)
+			}
+		}
+		if err := q.UpsertFundTrailingReturn(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	for _, res := range calendarYearReturns(pts) {
+		if res.year < fromYear {
+			continue
+		}
+		if err := q.UpsertFundCalendarReturn(ctx, db.UpsertFundCalendarReturnParams{
+			SchemeCode: schemeCode,
+			Year:       int32(res.year),
+			StartDate:  pgtype.Date{Time: res.start, Valid: true},
+			EndDate:    pgtype.Date{Time: res.end, Valid: true},
+			ReturnPct:  decimal.NewFromFloat(res.ret).Round(2),
+			Complete:   res.complete,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
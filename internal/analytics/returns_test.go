@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrailingAndCalendarReturns(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+	pts := []point{
+		{date: d(2021, 6, 1), nav: 10},
+		{date: d(2021, 12, 31), nav: 12},
+		{date: d(2022, 12, 30), nav: 15}, // Dec 31 2022 is a Saturday
+		{date: d(2023, 3, 15), nav: 13.5},
+		{date: d(2023, 6, 1), nav: 18},
+	}
+
+	byLabel := map[string]trailingResult{}
+	for _, r := range trailingReturns(pts) {
+		byLabel[r.label] = r
+	}
+	if r := byLabel["YTD"]; !r.ok || math.Abs(r.absolute-20.0) > 1e-9 {
+		t.Fatalf("YTD: expected 20%%, got %+v", r)
+	}
+	if r := byLabel["1Y"]; !r.ok || !r.start.Equal(d(2021, 12, 31)) || math.Abs(r.annualized-50.0) > 1e-9 {
+		t.Fatalf("1Y: expected 50%% from 2021-12-31, got %+v", r)
+	}
+	if r := byLabel["3M"]; !r.ok || !r.start.Equal(d(2022, 12, 30)) || !math.IsNaN(r.annualized) {
+		t.Fatalf("3M: expected unannualized return from 2022-12-30, got %+v", r)
+	}
+	if r := byLabel["3Y"]; r.ok {
+		t.Fatalf("3Y: expected insufficient history, got %+v", r)
+	}
+
+	years := calendarYearReturns(pts)
+	if len(years) != 3 {
+		t.Fatalf("expected 3 calendar years, got %d", len(years))
+	}
+	if y := years[0]; y.year != 2021 || y.complete || math.Abs(y.ret-20.0) > 1e-9 {
+		t.Fatalf("2021: expected incomplete inception year at 20%%, got %+v", y)
+	}
+	if y := years[1]; y.year != 2022 || !y.complete || math.Abs(y.ret-25.0) > 1e-9 {
+		t.Fatalf("2022: expected complete year at 25%%, got %+v", y)
+	}
+	if y := years[2]; y.year != 2023 || y.complete {
+		t.Fatalf("2023: expected running year to be incomplete, got %+v", y)
+	}
+}
+
+func TestTrailingReturnsClampMonthEnd(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+	if got := monthsBefore(d(2024, 5, 31), 3); !got.Equal(d(2024, 2, 29)) {
+		t.Fatalf("2024-05-31 - 3M = %s, want 2024-02-29", got.Format("2006-01-02"))
+	}
+	if got := monthsBefore(d(2024, 3, 31), 13); !got.Equal(d(2023, 2, 28)) {
+		t.Fatalf("2024-03-31 - 13M = %s, want 2023-02-28", got.Format("2006-01-02"))
+	}
+
+	// AddDate would start the 3M period on 2 Mar and pick the 1 Mar NAV.
+	pts := []point{
+		{date: d(2024, 2, 29), nav: 10},
+		{date: d(2024, 3, 1), nav: 20},
+		{date: d(2024, 5, 31), nav: 11},
+	}
+	for _, r := range trailingReturns(pts) {
+		if r.label == "3M" && (!r.ok || !r.start.Equal(d(2024, 2, 29)) || math.Abs(r.absolute-10.0) > 1e-9) {
+			t.Fatalf("3M: expected 10%% from 2024-02-29, got %+v", r)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package analytics
+
+import "math"
+
+// DefaultRiskFreeRatePct is the annual risk-free rate (roughly the 91-day T-bill yield) used for
+// the Sharpe ratio and for consistency (share of rolling periods whose CAGR beat it) unless
+// analytics.risk_free_rate_pct configures another. The rate used is stored with each row.
+const DefaultRiskFreeRatePct = 6.5
+
+// trailingRisk measures the trailing window ending at the latest NAV: annualised volatility of
+// NAV log returns (%) and the Sharpe ratio of the window's CAGR over riskFreePct. Both are NaN
+// when the history doesn't cover the window.
+//
+// Observations are annualised by their own frequency (points per year over the window), so gaps
+// for holidays and weekly NAVs are handled alike.
+func trailingRisk(pts []point, months int, riskFreePct float64) (volatility, sharpe float64) {
+	volatility, sharpe = math.NaN(), math.NaN()
+	if len(pts) < 3 {
+		return
+	}
+	last := len(pts) - 1
+	start, ok := navOnOrBefore(pts, monthsBefore(pts[last].date, months))
+	if !ok || last-start < 2 {
+		return
+	}
+
+	n := last - start
+	var sum, sumSq float64
+	for k := start + 1; k <= last; k++ {
+		lr := math.Log(pts[k].nav / pts[k-1].nav)
+		sum += lr
+		sumSq += lr * lr
+	}
+	mean := sum / float64(n)
+	variance := (sumSq - float64(n)*mean*mean) / float64(n-1)
+	if variance < 0 {
+		variance = 0
+	}
+
+	years := pts[last].date.Sub(pts[start].date).Hours() / 24 / 365.25
+	perYear := float64(n) / years
+	volatility = math.Sqrt(variance*perYear) * 100
+
+	cagr := (math.Pow(pts[last].nav/pts[start].nav, 1/years) - 1) * 100
+	if volatility > 0 {
+		sharpe = (cagr - riskFreePct) / volatility
+	}
+	return
+}
+
+// consistencyPct is the share (%) of rolling periods whose CAGR beat riskFreePct; NaN CAGRs are
+// skipped. It is NaN when no period has a CAGR.
+func consistencyPct(cagrs []float64, riskFreePct float64) float64 {
+	beat, total := 0, 0
+	for _, c := range cagrs {
+		if math.IsNaN(c) {
+			continue
+		}
+		total++
+		if c > riskFreePct {
+			beat++
+		}
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	return float64(beat) / float64(total) * 100
+}
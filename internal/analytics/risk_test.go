@@ -0,0 +1,41 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrailingRisk(t *testing.T) {
+	// NAV alternates +1% / -1% daily for two years: volatility is ~1% per observation.
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pts := []point{{date: start, nav: 100}}
+	for d := 1; d <= 730; d++ {
+		f := 1.01
+		if d%2 == 0 {
+			f = 1 / 1.01
+		}
+		pts = append(pts, point{date: start.AddDate(0, 0, d), nav: pts[d-1].nav * f})
+	}
+
+	vol, sharpe := trailingRisk(pts, 12, 4)
+	wantVol := math.Log(1.01) * math.Sqrt(365) * 100
+	if math.Abs(vol-wantVol) > 0.5 {
+		t.Fatalf("volatility %v, want ~%v", vol, wantVol)
+	}
+	// The year runs from a 101 NAV (day 365) to a 100 NAV (day 730).
+	wantSharpe := ((100.0/101-1)*100 - 4) / vol
+	if math.Abs(sharpe-wantSharpe) > 0.01 {
+		t.Fatalf("sharpe %v, want ~%v", sharpe, wantSharpe)
+	}
+
+	if vol, _ := trailingRisk(pts, 36, 4); !math.IsNaN(vol) {
+		t.Fatalf("expected NaN volatility without 3Y of history, got %v", vol)
+	}
+	if got := consistencyPct([]float64{5, 7, math.NaN(), 10}, DefaultRiskFreeRatePct); math.Abs(got-200.0/3) > 1e-9 {
+		t.Fatalf("consistency %v", got)
+	}
+	if got := consistencyPct([]float64{5, 7, math.NaN(), 10}, 8); math.Abs(got-100.0/3) > 1e-9 {
+		t.Fatalf("consistency at 8%% %v", got)
+	}
+}
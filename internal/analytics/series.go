@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"context"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// persistWindow writes one window's summary (keeping the superseded one as the previous
+// computation, plus a dated snapshot in the history), its rolling return series and its
+// incremental state in a single transaction, so they never disagree after a crash. With replace
+// the stored series is swapped for `series`; otherwise `series` is appended to it.
+// The series is precomputed so charting reads stay a single indexed range scan.
+func persistWindow(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	params db.UpsertFundAnalyticsParams,
+	series []rollingPoint,
+	replace bool,
+	st *windowState,
+) error {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := db.New(tx)
+	if err := q.SnapshotPreviousFundAnalytics(ctx, db.SnapshotPreviousFundAnalyticsParams{
+		SchemeCode:     params.SchemeCode,
+		Window:         params.Window,
+		NewDataEndDate: params.DataEndDate,
+	}); err != nil {
+		return err
+	}
+	if err := q.UpsertFundAnalytics(ctx, params); err != nil {
+		return err
+	}
+	if err := q.SnapshotFundAnalyticsHistory(ctx, db.SnapshotFundAnalyticsHistoryParams{
+		SchemeCode: params.SchemeCode,
+		Window:     params.Window,
+	}); err != nil {
+		return err
+	}
+
+	if replace {
+		if err := q.DeleteFundRollingReturns(ctx, db.DeleteFundRollingReturnsParams{
+			SchemeCode: params.SchemeCode,
+			Window:     params.Window,
+		}); err != nil {
+			return err
+		}
+	}
+	if len(series) > 0 {
+		if err := q.InsertFundRollingReturns(ctx, seriesParams(params.SchemeCode, params.Window, series)); err != nil {
+			return err
+		}
+	}
+
+	state := db.UpsertFundAnalyticsStateParams{
+		SchemeCode: params.SchemeCode,
+		Window:     params.Window,
+	}
+	if !st.lastEnd.IsZero() {
+		state.LastEndDate = pgtype.Date{Time: st.lastEnd, Valid: true}
+	}
+	if !math.IsInf(st.worstDrawdown, 1) {
+		state.WorstDrawdown = pgtype.Float8{Float64: st.worstDrawdown, Valid: true}
+	}
+	if err := q.UpsertFundAnalyticsState(ctx, state); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func seriesParams(schemeCode, window string, series []rollingPoint) db.InsertFundRollingReturnsParams {
+	params := db.InsertFundRollingReturnsParams{
+		SchemeCode: schemeCode,
+		Window:     window,
+		EndDates:   make([]pgtype.Date, 0, len(series)),
+		StartDates: make([]pgtype.Date, 0, len(series)),
+		ReturnPcts: make([]float64, 0, len(series)),
+		CagrPcts:   make([]float64, 0, len(series)),
+	}
+	for _, p := range series {
+		params.EndDates = append(params.EndDates, pgtype.Date{Time: p.endDate, Valid: true})
+		params.StartDates = append(params.StartDates, pgtype.Date{Time: p.startDate, Valid: true})
+		params.ReturnPcts = append(params.ReturnPcts, p.ret)
+		params.CagrPcts = append(params.CagrPcts, p.cagr)
+	}
+	return params
+}
@@ -0,0 +1,189 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// SIPParams describes a monthly SIP. Zero From/To default to the first/latest NAV date.
+type SIPParams struct {
+	Amount float64
+	Day    int // day of month; clamped to the month's last day
+	From   time.Time
+	To     time.Time
+}
+
+type SIPInstallment struct {
+	ScheduledDate time.Time
+	NavDate       time.Time
+	NAV           float64
+	Amount        float64
+	Units         float64
+}
+
+type SIPResult struct {
+	Installments      []SIPInstallment
+	Invested          float64
+	Units             float64
+	ValuationDate     time.Time
+	ValuationNAV      float64
+	CurrentValue      float64
+	AbsoluteGain      float64
+	AbsoluteReturnPct float64
+	XIRRPct           float64 // NaN when no rate solves the cash flows
+}
+
+// RollingSIPResult is the distribution of SIP XIRRs over every start month in the history.
+type RollingSIPResult struct {
+	Months       int
+	Periods      int
+	Min          float64
+	P25          float64
+	Median       float64
+	P75          float64
+	Max          float64
+	Mean         float64
+	ProbNegative float64
+}
+
+// SimulateSIP simulates monthly purchases on a scheme's stored NAV history.
+func SimulateSIP(ctx context.Context, pool *pgxpool.Pool, schemeCode string, p SIPParams) (SIPResult, error) {
+	pts, err := loadPoints(ctx, db.New(pool), schemeCode, time.Time{})
+	if err != nil {
+		return SIPResult{}, err
+	}
+	return simulateSIP(pts, p)
+}
+
+// RollingSIP simulates a SIP of the given length starting in every month of the history and
+// summarises the resulting XIRRs.
+func RollingSIP(ctx context.Context, pool *pgxpool.Pool, schemeCode string, months, day int) (RollingSIPResult, error) {
+	pts, err := loadPoints(ctx, db.New(pool), schemeCode, time.Time{})
+	if err != nil {
+		return RollingSIPResult{}, err
+	}
+	return rollingSIP(pts, months, day)
+}
+
+func simulateSIP(pts []point, p SIPParams) (SIPResult, error) {
+	if len(pts) == 0 {
+		return SIPResult{}, ErrInsufficientHistory
+	}
+	from, to := p.From, p.To
+	if from.IsZero() || from.Before(pts[0].date) {
+		from = pts[0].date
+	}
+	if to.IsZero() || to.After(pts[len(pts)-1].date) {
+		to = pts[len(pts)-1].date
+	}
+
+	var res SIPResult
+	flows := make([]CashFlow, 0, 64)
+	for m := 0; ; m++ {
+		sched := sipDate(from, m, p.Day)
+		if sched.Before(from) {
+			continue
+		}
+		if sched.After(to) {
+			break
+		}
+		// Holidays and weekends roll forward to the next available NAV.
+		i := sort.Search(len(pts), func(k int) bool { return !pts[k].date.Before(sched) })
+		if i == len(pts) || pts[i].date.After(to) {
+			break
+		}
+		units := p.Amount / pts[i].nav
+		res.Installments = append(res.Installments, SIPInstallment{
+			ScheduledDate: sched,
+			NavDate:       pts[i].date,
+			NAV:           pts[i].nav,
+			Amount:        p.Amount,
+			Units:         units,
+		})
+		res.Invested += p.Amount
+		res.Units += units
+		flows = append(flows, CashFlow{Date: pts[i].date, Amount: -p.Amount})
+	}
+	if len(res.Installments) == 0 {
+		return SIPResult{}, ErrInsufficientHistory
+	}
+
+	v, _ := navOnOrBefore(pts, to)
+	res.ValuationDate = pts[v].date
+	res.ValuationNAV = pts[v].nav
+	res.CurrentValue = res.Units * pts[v].nav
+	res.AbsoluteGain = res.CurrentValue - res.Invested
+	res.AbsoluteReturnPct = res.AbsoluteGain / res.Invested * 100.0
+
+	flows = append(flows, CashFlow{Date: res.ValuationDate, Amount: res.CurrentValue})
+	res.XIRRPct = math.NaN()
+	if r, err := XIRR(flows); err == nil {
+		res.XIRRPct = r * 100.0
+	}
+	return res, nil
+}
+
+func rollingSIP(pts []point, months, day int) (RollingSIPResult, error) {
+	out := RollingSIPResult{Months: months}
+	if len(pts) < 2 || months <= 0 {
+		return out, ErrInsufficientHistory
+	}
+	first, last := pts[0].date, pts[len(pts)-1].date
+
+	xirrs := make([]float64, 0, 128)
+	for m := 0; ; m++ {
+		start := sipDate(first, m, day)
+		if start.Before(first) {
+			continue
+		}
+		// The SIP runs `months` installments and is valued one month after the last one.
+		end := sipDate(start, months, day)
+		if end.After(last) {
+			break
+		}
+		res, err := simulateSIP(pts, SIPParams{Amount: 1, Day: day, From: start, To: end.AddDate(0, 0, -1)})
+		if err != nil || math.IsNaN(res.XIRRPct) {
+			continue
+		}
+		xirrs = append(xirrs, res.XIRRPct)
+	}
+	if len(xirrs) == 0 {
+		return out, ErrInsufficientHistory
+	}
+
+	sort.Float64s(xirrs)
+	var sum float64
+	var neg int
+	for _, x := range xirrs {
+		sum += x
+		if x < 0 {
+			neg++
+		}
+	}
+	out.Periods = len(xirrs)
+	out.Min = xirrs[0]
+	out.P25 = percentileSorted(xirrs, 0.25)
+	out.Median = percentileSorted(xirrs, 0.50)
+	out.P75 = percentileSorted(xirrs, 0.75)
+	out.Max = xirrs[len(xirrs)-1]
+	out.Mean = sum / float64(len(xirrs))
+	out.ProbNegative = float64(neg) / float64(len(xirrs))
+	return out, nil
+}
+
+// sipDate returns the installment date m months after base's month, on the given day
+// (clamped to the month's last day).
+func sipDate(base time.Time, m, day int) time.Time {
+	y, mon := base.Year(), base.Month()+time.Month(m)
+	lastDay := time.Date(y, mon+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(y, mon, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// windowState is everything needed to summarise one rolling window and to extend it with newly
+// appended NAVs without rescanning the full history: the per-period returns/CAGRs in end-date
+// order, the last period end date, and the worst drawdown seen so far.
+type windowState struct {
+	months        int
+	returns       []float64
+	cagrs         []float64 // NaN where undefined; aligned with returns
+	lastEnd       time.Time // zero until the first period
+	worstDrawdown float64   // +Inf until the first period
+}
+
+func newWindowState(months int) *windowState {
+	return &windowState{months: months, worstDrawdown: math.Inf(1)}
+}
+
+// extend adds every rolling period ending after st.lastEnd and returns those periods.
+//
+// pts must be ascending and, when extending existing state, must start at the last NAV at or
+// before (first new date - window) so every new period's start and every peak a new trough can
+// be measured against is present (see tailAnchor). For fresh state pass the full history.
+func (st *windowState) extend(pts []point) []rollingPoint {
+	var (
+		added  []rollingPoint
+		starts []int
+		ends   []int
+	)
+	forEachRollingPeriod(pts, st.months, func(i, j int, r, c float64) {
+		if !pts[j].date.After(st.lastEnd) {
+			return
+		}
+		st.returns = append(st.returns, r)
+		st.cagrs = append(st.cagrs, c)
+		starts = append(starts, i)
+		ends = append(ends, j)
+		added = append(added, rollingPoint{startDate: pts[i].date, endDate: pts[j].date, ret: r, cagr: c})
+	})
+	if len(ends) == 0 {
+		return nil
+	}
+
+	firstNew := 0
+	for firstNew < len(pts) && !pts[firstNew].date.After(st.lastEnd) {
+		firstNew++
+	}
+	if dd := worstDrawdownPct(pts, starts, ends, firstNew); dd < st.worstDrawdown {
+		st.worstDrawdown = dd
+	}
+	st.lastEnd = pts[ends[len(ends)-1]].date
+	return added
+}
+
+func (st *windowState) result(riskFreePct float64) windowResult {
+	return summarizeWindow(st.returns, st.cagrs, st.worstDrawdown, riskFreePct)
+}
+
+// worstDrawdownPct returns the worst peak-to-trough decline (in %) contained in any of the
+// rolling periods [starts[k], ends[k]], considering only troughs at index >= firstT.
+//
+// A peak p and trough t fall in a common period iff p >= lo(t), where lo(t) is the start of the
+// earliest period ending at or after t. Periods are generated in order, so lo is non-decreasing
+// and max(nav[lo(t)..t]) is a sliding-window maximum: a monotonic deque gives it in O(n) total,
+// instead of rescanning every period (O(n²)).
+func worstDrawdownPct(pts []point, starts, ends []int, firstT int) float64 {
+	worst := math.Inf(1)
+	if len(ends) == 0 {
+		return worst
+	}
+
+	// dq holds indexes with strictly decreasing NAVs; dq[0] is the max of the current range.
+	dq := make([]int, 0, 64)
+	k := 0
+	for t := 0; t <= ends[len(ends)-1]; t++ {
+		for len(dq) > 0 && pts[dq[len(dq)-1]].nav <= pts[t].nav {
+			dq = dq[:len(dq)-1]
+		}
+		dq = append(dq, t)
+
+		if t < firstT {
+			continue
+		}
+		for ends[k] < t {
+			k++
+		}
+		lo := starts[k]
+		if t < lo {
+			continue // before every period that could contain it
+		}
+		for dq[0] < lo {
+			dq = dq[1:]
+		}
+
+		if dd := (pts[t].nav/pts[dq[0]].nav - 1.0) * 100.0; dd < worst {
+			worst = dd
+		}
+	}
+	return worst
+}
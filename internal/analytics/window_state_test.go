@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randomNavs returns a business-day-ish NAV series with gaps, so month ends, weekends and
+// holidays all show up in the rolling period boundaries.
+func randomNavs(rng *rand.Rand, n int) []point {
+	d := time.Date(2015, 1, 1+rng.Intn(28), 0, 0, 0, 0, time.UTC)
+	nav := 10 + rng.Float64()*90
+	pts := make([]point, 0, n)
+	for len(pts) < n {
+		d = d.AddDate(0, 0, 1+rng.Intn(4))
+		nav *= math.Exp(rng.NormFloat64()*0.02 + 0.0003)
+		pts = append(pts, point{date: d, nav: nav})
+	}
+	return pts
+}
+
+func TestWorstDrawdownMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+	for iter := 0; iter < 200; iter++ {
+		pts := randomNavs(rng, 50+rng.Intn(400))
+		months := 1 + rng.Intn(18)
+
+		want := math.Inf(1)
+		forEachRollingPeriod(pts, months, func(i, j int, _, _ float64) {
+			if dd := maxDrawdownPct(pts[i : j+1]); dd < want {
+				want = dd
+			}
+		})
+
+		st := newWindowState(months)
+		st.extend(pts)
+		if got := st.worstDrawdown; got != want && math.Abs(got-want) > 1e-9 {
+			t.Fatalf("iter %d (%dM, %d navs): worst drawdown %v, brute force %v", iter, months, len(pts), got, want)
+		}
+	}
+}
+
+func TestIncrementalExtendMatchesFullCompute(t *testing.T) {
+	rng := rand.New(rand.NewSource(2024))
+	for iter := 0; iter < 200; iter++ {
+		pts := randomNavs(rng, 100+rng.Intn(500))
+		months := 1 + rng.Intn(24)
+
+		full := newWindowState(months)
+		fullSeries := full.extend(pts)
+
+		// Replay the history in a few appends, each extending from the tail the incremental
+		// compute would load.
+		inc := newWindowState(months)
+		var incSeries []rollingPoint
+		for n := 2 + rng.Intn(len(pts)/2); ; n += 1 + rng.Intn(40) {
+			if n > len(pts) {
+				n = len(pts)
+			}
+			from := 0
+			if i, ok := navOnOrBefore(pts[:n], tailAnchor(inc.lastEnd, months)); ok {
+				from = i
+			}
+			incSeries = append(incSeries, inc.extend(pts[from:n])...)
+			if n == len(pts) {
+				break
+			}
+		}
+
+		if len(incSeries) != len(fullSeries) {
+			t.Fatalf("iter %d (%dM): %d incremental periods, %d full", iter, months, len(incSeries), len(fullSeries))
+		}
+		for k := range fullSeries {
+			a, b := incSeries[k], fullSeries[k]
+			if !a.startDate.Equal(b.startDate) || !a.endDate.Equal(b.endDate) || a.ret != b.ret ||
+				(a.cagr != b.cagr && !(math.IsNaN(a.cagr) && math.IsNaN(b.cagr))) {
+				t.Fatalf("iter %d (%dM): period %d differs: incremental %+v, full %+v", iter, months, k, a, b)
+			}
+		}
+		if inc.worstDrawdown != full.worstDrawdown || !inc.lastEnd.Equal(full.lastEnd) {
+			t.Fatalf("iter %d (%dM): state differs: incremental (%v, %v), full (%v, %v)",
+				iter, months, inc.worstDrawdown, inc.lastEnd, full.worstDrawdown, full.lastEnd)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// CashFlow is a dated amount; investments are negative and redemptions/valuations positive.
+type CashFlow struct {
+	Date   time.Time
+	Amount float64
+}
+
+var ErrXIRRNoSolution = errors.New("xirr: no solution")
+
+const (
+	xirrTolerance = 1e-9
+	xirrMaxIter   = 100
+	xirrMinRate   = -0.999999
+)
+
+// XIRR returns the annualised internal rate of return (as a fraction) of irregular cash flows,
+// using an Actual/365 day count. Newton's method is tried first; if it fails to converge or
+// leaves the valid domain, it falls back to bisection over a bracketed root.
+func XIRR(flows []CashFlow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, ErrXIRRNoSolution
+	}
+	var hasNeg, hasPos bool
+	t0 := flows[0].Date
+	for _, f := range flows {
+		if f.Date.Before(t0) {
+			t0 = f.Date
+		}
+		hasNeg = hasNeg || f.Amount < 0
+		hasPos = hasPos || f.Amount > 0
+	}
+	if !hasNeg || !hasPos {
+		return 0, ErrXIRRNoSolution
+	}
+
+	years := make([]float64, len(flows))
+	for i, f := range flows {
+		years[i] = f.Date.Sub(t0).Hours() / 24 / 365
+	}
+
+	npv := func(r float64) (v, dv float64) {
+		for i, f := range flows {
+			d := math.Pow(1+r, -years[i])
+			v += f.Amount * d
+			dv -= years[i] * f.Amount * d / (1 + r)
+		}
+		return v, dv
+	}
+
+	r := 0.1
+	for k := 0; k < xirrMaxIter; k++ {
+		v, dv := npv(r)
+		if math.Abs(v) < xirrTolerance {
+			return r, nil
+		}
+		if dv == 0 || math.IsNaN(dv) || math.IsInf(dv, 0) {
+			break
+		}
+		next := r - v/dv
+		if next <= xirrMinRate || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-r) < xirrTolerance {
+			return next, nil
+		}
+		r = next
+	}
+
+	return xirrBisect(npv)
+}
+
+func xirrBisect(npv func(float64) (float64, float64)) (float64, error) {
+	lo, hi := xirrMinRate, 1.0
+	vlo, _ := npv(lo)
+	vhi, _ := npv(hi)
+	for k := 0; vlo*vhi > 0 && k < 60; k++ {
+		hi *= 2
+		vhi, _ = npv(hi)
+	}
+	if vlo*vhi > 0 || math.IsNaN(vlo*vhi) {
+		return 0, ErrXIRRNoSolution
+	}
+
+	for k := 0; k < 200; k++ {
+		mid := (lo + hi) / 2
+		vmid, _ := npv(mid)
+		if math.Abs(vmid) < xirrTolerance || (hi-lo)/2 < xirrTolerance {
+			return mid, nil
+		}
+		if vlo*vmid < 0 {
+			hi = mid
+		} else {
+			lo, vlo = mid, vmid
+		}
+	}
+	return (lo + hi) / 2, nil
+}
@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestXIRR(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+
+	// Reference example from the spreadsheet XIRR documentation.
+	flows := []CashFlow{
+		{Date: d(2008, 1, 1), Amount: -10000},
+		{Date: d(2008, 3, 1), Amount: 2750},
+		{Date: d(2008, 10, 30), Amount: 4250},
+		{Date: d(2009, 2, 15), Amount: 3250},
+		{Date: d(2009, 4, 1), Amount: 2750},
+	}
+	r, err := XIRR(flows)
+	if err != nil {
+		t.Fatalf("XIRR: %v", err)
+	}
+	if math.Abs(r-0.373362535) > 1e-6 {
+		t.Fatalf("expected 0.373362535, got %.9f", r)
+	}
+
+	// A large loss drives Newton out of its domain; bisection must still find the root.
+	r, err = XIRR([]CashFlow{
+		{Date: d(2020, 1, 1), Amount: -1000},
+		{Date: d(2020, 2, 1), Amount: 500},
+	})
+	if err != nil {
+		t.Fatalf("XIRR loss: %v", err)
+	}
+	if want := math.Pow(0.5, 365.0/31) - 1; math.Abs(r-want) > 1e-6 {
+		t.Fatalf("expected %f, got %f", want, r)
+	}
+
+	if _, err := XIRR([]CashFlow{{Date: d(2020, 1, 1), Amount: -1000}}); err == nil {
+		t.Fatalf("expected an error without a positive flow")
+	}
+}
+
+func TestSimulateSIPRollsForwardOverHolidays(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+	pts := []point{
+		{date: d(2024, 1, 5), nav: 10},
+		{date: d(2024, 2, 6), nav: 20}, // Feb 5 is missing: buy on the 6th
+		{date: d(2024, 3, 5), nav: 25},
+		{date: d(2024, 4, 5), nav: 20},
+	}
+
+	res, err := simulateSIP(pts, SIPParams{Amount: 1000, Day: 5})
+	if err != nil {
+		t.Fatalf("simulateSIP: %v", err)
+	}
+	if len(res.Installments) != 4 {
+		t.Fatalf("expected 4 installments, got %d", len(res.Installments))
+	}
+	if !res.Installments[1].NavDate.Equal(d(2024, 2, 6)) {
+		t.Fatalf("expected the February installment on 2024-02-06, got %s", res.Installments[1].NavDate)
+	}
+	wantUnits := 100.0 + 50 + 40 + 50
+	if math.Abs(res.Units-wantUnits) > 1e-9 || res.Invested != 4000 {
+		t.Fatalf("expected %.0f units for 4000 invested, got %f units for %f", wantUnits, res.Units, res.Invested)
+	}
+	if math.Abs(res.CurrentValue-wantUnits*20) > 1e-9 {
+		t.Fatalf("unexpected current value %f", res.CurrentValue)
+	}
+	if math.IsNaN(res.XIRRPct) || res.XIRRPct <= 0 {
+		t.Fatalf("expected a positive XIRR, got %f", res.XIRRPct)
+	}
+}
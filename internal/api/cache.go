@@ -0,0 +1,143 @@
+package api
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cacheMetrics publishes per-cache counters at /debug/vars under "api_cache".
+var cacheMetrics = expvar.NewMap("api_cache")
+
+// lruCache is a bounded in-process cache. Entries expire after ttl; when full, the least
+// recently used entry is evicted. Purge drops everything and bumps the generation, so a value
+// computed from data read before the purge can be refused by SetIfGeneration.
+type lruCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	max        int
+	ll         *list.List // front is most recently used
+	items      map[string]*list.Element
+	generation uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+type cacheEntry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+// newLRUCache builds a cache and publishes its counters under name; a later cache with the
+// same name (a new Server in tests) replaces the earlier one's metrics.
+func newLRUCache(name string, ttl time.Duration, max int) *lruCache {
+	c := &lruCache{ttl: ttl, max: max, ll: list.New(), items: make(map[string]*list.Element)}
+	cacheMetrics.Set(name, expvar.Func(func() any { return c.Stats() }))
+	return c
+}
+
+func (c *lruCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+func (c *lruCache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// SetIfGeneration stores value only if the cache hasn't been purged since Generation returned
+// gen, so a response built while a sync finished doesn't outlive the invalidation.
+func (c *lruCache) SetIfGeneration(key string, value any, gen uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != gen {
+		return false
+	}
+	c.set(key, value)
+	return true
+}
+
+func (c *lruCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Purge drops every entry. Dropped entries don't count as evictions.
+func (c *lruCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.generation++
+}
+
+func (c *lruCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// cacheStats is the expvar view of one cache.
+type cacheStats struct {
+	Entries   int     `json:"entries"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+func (c *lruCache) Stats() cacheStats {
+	st := cacheStats{
+		Entries:   c.Len(),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+	if total := st.Hits + st.Misses; total > 0 {
+		st.HitRatio = float64(st.Hits) / float64(total)
+	}
+	return st
+}
+
+func (c *lruCache) set(key string, value any) {
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*cacheEntry)
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+	for c.ll.Len() >= c.max && c.ll.Len() > 0 {
+		c.remove(c.ll.Back())
+		c.evictions.Add(1)
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRUCache("test_lru", time.Minute, 2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok { // a is now more recent than b
+		t.Fatal("a missing")
+	}
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("%s missing", k)
+		}
+	}
+	st := c.Stats()
+	if st.Entries != 2 || st.Hits != 3 || st.Misses != 1 || st.Evictions != 1 || st.HitRatio != 0.75 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestLRUCacheExpiresEntries(t *testing.T) {
+	c := newLRUCache("test_ttl", time.Millisecond, 8)
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expired entry returned")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expired entry kept, len %d", c.Len())
+	}
+}
+
+func TestLRUCachePurgeRejectsStaleSets(t *testing.T) {
+	c := newLRUCache("test_purge", time.Minute, 8)
+	c.Set("a", 1)
+	gen := c.Generation()
+	c.Purge()
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("purged entry returned")
+	}
+	if c.SetIfGeneration("b", 2, gen) {
+		t.Fatal("value computed before the purge was stored")
+	}
+	if !c.SetIfGeneration("b", 2, c.Generation()) {
+		t.Fatal("current generation rejected")
+	}
+}
+
+func TestCachedServesRepeatedRequests(t *testing.T) {
+	s := &Server{responses: newLRUCache("test_responses", time.Minute, 8)}
+	calls := 0
+	h := s.cached(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("window") == "bad" {
+			writeInvalidParam(w, r, "window", "bad window")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"calls": calls})
+	})
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	first := get("/funds/1/analytics?window=3Y&detail=full")
+	// Same parameters in another order hit the same entry.
+	second := get("/funds/1/analytics?detail=full&window=3Y")
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("X-Cache %q then %q", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("cached response differs: %q %q", second.Body, second.Header().Get("Content-Type"))
+	}
+
+	get("/funds/1/analytics?window=bad")
+	rec := get("/funds/1/analytics?window=bad")
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("error response was cached: %d %q", rec.Code, rec.Header().Get("X-Cache"))
+	}
+
+	s.responses.Purge()
+	if rec := get("/funds/1/analytics?window=3Y&detail=full"); rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatal("purge did not invalidate the response")
+	}
+	if calls != 4 {
+		t.Fatalf("handler ran %d times, want 4", calls)
+	}
+}
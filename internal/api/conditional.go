@@ -0,0 +1,112 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validators identify one version of an analytics response: the ETag digests what the body is
+// derived from (computed_at timestamps, NAV dates), and lastModified is the newest computation.
+type validators struct {
+	etag         string
+	lastModified time.Time
+}
+
+// newValidators builds a strong ETag from parts. A zero lastModified leaves Last-Modified unset,
+// for responses computed on request that have no stored computation time.
+func newValidators(lastModified time.Time, parts ...string) validators {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return validators{
+		etag:         `"` + hex.EncodeToString(sum[:12]) + `"`,
+		lastModified: lastModified,
+	}
+}
+
+// timestampPart formats a computed_at for newValidators; nanoseconds so two computations within
+// one second still differ.
+func timestampPart(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// notModified sets the validators and Cache-Control on w and reports whether the request's
+// If-None-Match (or, without one, If-Modified-Since) shows the client's copy is current. If so it
+// has answered 304 and the handler must not write a body.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request, v validators) bool {
+	h := w.Header()
+	h.Set("Cache-Control", s.cacheControl(time.Now()))
+	if s.keys != nil {
+		// The response depends on who asked: a cache must not replay it to another key.
+		h.Add("Vary", "Authorization")
+		h.Add("Vary", "X-API-Key")
+	}
+	if v.etag != "" {
+		h.Set("ETag", v.etag)
+	}
+	if !v.lastModified.IsZero() {
+		h.Set("Last-Modified", v.lastModified.UTC().Format(http.TimeFormat))
+	}
+	if !v.match(r) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// match evaluates the preconditions as RFC 9110 orders them: If-Modified-Since is ignored when
+// If-None-Match is present, and ETags compare weakly.
+func (v validators) match(r *http.Request) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || (v.etag != "" && tag == v.etag) {
+				return true
+			}
+		}
+		return false
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || v.lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have second precision.
+	return !v.lastModified.Truncate(time.Second).After(t)
+}
+
+// validatorsFromHeader recovers the validators a handler set, for responses replayed from cache.
+func validatorsFromHeader(h http.Header) validators {
+	v := validators{etag: h.Get("ETag")}
+	if lm := h.Get("Last-Modified"); lm != "" {
+		v.lastModified, _ = http.ParseTime(lm)
+	}
+	return v
+}
+
+func (v validators) isZero() bool {
+	return v.etag == "" && v.lastModified.IsZero()
+}
+
+// cacheControl lets clients reuse analytics until the next scheduled incremental sync, the
+// earliest they change on their own. Without a schedule clients revalidate on every use. With
+// API keys responses are private, so shared proxies never serve them to other callers.
+func (s *Server) cacheControl(now time.Time) string {
+	if s.syncSchedule == nil {
+		return "no-cache"
+	}
+	maxAge := int(s.syncSchedule.Next(now).Sub(now).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	visibility := "public"
+	if s.keys != nil {
+		visibility = "private"
+	}
+	return visibility + ", max-age=" + strconv.Itoa(maxAge)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestNotModified(t *testing.T) {
+	computed := time.Date(2026, 3, 10, 2, 14, 5, 500, time.UTC)
+	v := newValidators(computed, "119551", "3Y", timestampPart(computed))
+	other := newValidators(computed, "119551", "3Y", timestampPart(computed.Add(time.Nanosecond)))
+	if v.etag == other.etag {
+		t.Fatal("etag ignores sub-second computed_at changes")
+	}
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"unconditional", nil, false},
+		{"etag match", map[string]string{"If-None-Match": v.etag}, true},
+		{"weak etag in list", map[string]string{"If-None-Match": `"x", W/` + v.etag}, true},
+		{"star", map[string]string{"If-None-Match": "*"}, true},
+		{"stale etag", map[string]string{"If-None-Match": other.etag}, false},
+		{"modified since", map[string]string{"If-Modified-Since": computed.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"not modified since", map[string]string{"If-Modified-Since": computed.Format(http.TimeFormat)}, true},
+		{"etag wins over date", map[string]string{
+			"If-None-Match":     other.etag,
+			"If-Modified-Since": computed.Format(http.TimeFormat),
+		}, false},
+	}
+	s := &Server{}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/funds/119551/analytics?window=3Y", nil)
+			for k, val := range tc.headers {
+				r.Header.Set(k, val)
+			}
+			rec := httptest.NewRecorder()
+			if got := s.notModified(rec, r, v); got != tc.want {
+				t.Fatalf("notModified = %v, want %v", got, tc.want)
+			}
+			if tc.want && rec.Code != http.StatusNotModified {
+				t.Fatalf("status %d", rec.Code)
+			}
+			if rec.Header().Get("ETag") != v.etag || rec.Header().Get("Last-Modified") != computed.Format(http.TimeFormat) {
+				t.Fatalf("validators not set: %v", rec.Header())
+			}
+		})
+	}
+}
+
+func TestCacheControlFollowsSyncSchedule(t *testing.T) {
+	s := &Server{}
+	if got := s.cacheControl(time.Now()); got != "no-cache" {
+		t.Fatalf("without a schedule: %q", got)
+	}
+
+	sched, err := cron.ParseStandard("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.syncSchedule = sched
+	now := time.Date(2026, 3, 10, 23, 30, 0, 0, time.Local)
+	if got := s.cacheControl(now); got != "public, max-age=9000" {
+		t.Fatalf("got %q, want max-age until 02:00", got)
+	}
+
+	s.keys = fakeKeys{}
+	if got := s.cacheControl(now); got != "private, max-age=9000" {
+		t.Fatalf("with API keys: %q", got)
+	}
+	rec := httptest.NewRecorder()
+	s.notModified(rec, httptest.NewRequest(http.MethodGet, "/funds/rank?window=3Y", nil), newValidators(now, "v1"))
+	if vary := rec.Header().Values("Vary"); len(vary) != 2 || vary[0] != "Authorization" || vary[1] != "X-API-Key" {
+		t.Fatalf("Vary %q", vary)
+	}
+}
+
+func TestCachedAnswersConditionalHits(t *testing.T) {
+	s := &Server{responses: newLRUCache("test_conditional", time.Minute, 8)}
+	v := newValidators(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC), "v1")
+	h := s.cached(func(w http.ResponseWriter, r *http.Request) {
+		if s.notModified(w, r, v) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"ok": "yes"})
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/funds/rank?window=3Y", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first request: %d %q", rec.Code, rec.Header().Get("X-Cache"))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/funds/rank?window=3Y", nil)
+	r.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	h(rec, r)
+	if rec.Code != http.StatusNotModified || rec.Header().Get("X-Cache") != "HIT" || rec.Body.Len() != 0 {
+		t.Fatalf("conditional hit: %d %q %q", rec.Code, rec.Header().Get("X-Cache"), rec.Body)
+	}
+	if rec.Header().Get("ETag") != v.etag || rec.Header().Get("Cache-Control") == "" {
+		t.Fatalf("304 without validators: %v", rec.Header())
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"mf-analytics-service/internal/analytics"
+)
+
+const defaultBucketWidth = 5.0
+
+type distributionParams struct {
+	full        bool
+	bucketWidth float64
+	beat        *float64
+}
+
+// parseDistributionParams reads detail=summary|full, and for full detail the optional
+// bucket_width (percentage points) and beat (a return threshold in %).
+func parseDistributionParams(r *http.Request) (distributionParams, error) {
+	q := r.URL.Query()
+	p := distributionParams{bucketWidth: defaultBucketWidth}
+
+	switch strings.TrimSpace(q.Get("detail")) {
+	case "", "summary":
+	case "full":
+		p.full = true
+	default:
+		return p, FieldError{Field: "detail", Message: "detail must be summary|full"}
+	}
+
+	if v := strings.TrimSpace(q.Get("bucket_width")); v != "" {
+		f, err := strconvParseFloat(v)
+		per := f / analytics.HistogramBaseWidth
+		if err != nil || f <= 0 || f > 100 || per != math.Trunc(per) {
+			return p, FieldError{
+				Field:   "bucket_width",
+				Message: fmt.Sprintf("bucket_width must be a multiple of %g up to 100", analytics.HistogramBaseWidth),
+			}
+		}
+		p.bucketWidth = f
+	}
+	if v := strings.TrimSpace(q.Get("beat")); v != "" {
+		f, err := strconvParseFloat(v)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return p, FieldError{Field: "beat", Message: "beat must be a number (percent)"}
+		}
+		p.beat = &f
+	}
+	if !p.full && (q.Has("bucket_width") || q.Has("beat")) {
+		return p, FieldError{Field: "detail", Message: "bucket_width and beat require detail=full"}
+	}
+	return p, nil
+}
+
+type DistributionResponse struct {
+	P5  float64 `json:"p5"`
+	P10 float64 `json:"p10"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"std_dev"`
+	Skewness float64 `json:"skewness"`
+
+	ProbNegative float64   `json:"prob_negative"`
+	ProbBeat     *ProbBeat `json:"prob_beat,omitempty"`
+
+	Histogram struct {
+		BucketWidth float64           `json:"bucket_width"`
+		Buckets     []HistogramBucket `json:"buckets"`
+	} `json:"histogram"`
+}
+
+type ProbBeat struct {
+	Threshold   float64 `json:"threshold"`
+	Probability float64 `json:"probability"`
+}
+
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// newDistributionResp renders a stored distribution; NULL (no rolling periods) renders as nil.
+func newDistributionResp(raw []byte, p distributionParams) (*DistributionResponse, error) {
+	d, err := analytics.DecodeDistribution(raw)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	out := &DistributionResponse{
+		P5:           d.P5,
+		P10:          d.P10,
+		P90:          d.P90,
+		P95:          d.P95,
+		Mean:         d.Mean,
+		StdDev:       d.StdDev,
+		Skewness:     d.Skewness,
+		ProbNegative: d.ProbNegative,
+	}
+	if p.beat != nil {
+		out.ProbBeat = &ProbBeat{Threshold: *p.beat, Probability: round(d.ProbAbove(*p.beat), 4)}
+	}
+
+	buckets, err := d.Histogram(p.bucketWidth)
+	if err != nil {
+		return nil, err
+	}
+	out.Histogram.BucketWidth = p.bucketWidth
+	out.Histogram.Buckets = make([]HistogramBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out.Histogram.Buckets = append(out.Histogram.Buckets, HistogramBucket{From: b.From, To: b.To, Count: b.Count})
+	}
+	return out, nil
+}
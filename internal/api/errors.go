@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Error codes are stable identifiers clients can branch on; messages are for people and may change.
+const (
+	CodeInvalidParameter    = "INVALID_PARAMETER"
+	CodeInvalidWindow       = "INVALID_WINDOW"
+	CodeInvalidBody         = "INVALID_BODY"
+	CodeFundNotFound        = "FUND_NOT_FOUND"
+	CodeCategoryNotFound    = "CATEGORY_NOT_FOUND"
+	CodePortfolioNotFound   = "PORTFOLIO_NOT_FOUND"
+	CodeNoTransactions      = "NO_TRANSACTIONS"
+	CodeAnalyticsNotReady   = "ANALYTICS_NOT_READY"
+	CodeInsufficientHistory = "INSUFFICIENT_HISTORY"
+	CodeUnknownFund         = "UNKNOWN_FUND"
+	CodeInvalidTransactions = "INVALID_TRANSACTIONS"
+	CodeNAVUnavailable      = "NAV_UNAVAILABLE"
+	CodeNoTaxRule           = "NO_TAX_RULE"
+	CodeSyncAlreadyRunning  = "SYNC_ALREADY_RUNNING"
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeInternal            = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body of every 4xx and 5xx JSON response.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RequestID matches the request_id of the server's log lines for the request.
+	RequestID string       `json:"request_id,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
+}
+
+// FieldError is a validation failure of one query parameter or body field. It doubles as the
+// error parse helpers return, so the field survives to the response.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string { return e.Message }
+
+func newErrorResponse(r *http.Request, code, message string, details ...FieldError) ErrorResponse {
+	return ErrorResponse{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: middleware.GetReqID(r.Context()),
+		Details:   details,
+	}}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, details ...FieldError) {
+	writeJSON(w, status, newErrorResponse(r, code, message, details...))
+}
+
+// writeInvalidParam rejects one query or path parameter with a 400.
+func writeInvalidParam(w http.ResponseWriter, r *http.Request, field, message string) {
+	code := CodeInvalidParameter
+	if field == "window" || field == "windows" {
+		code = CodeInvalidWindow
+	}
+	writeError(w, r, http.StatusBadRequest, code, message, FieldError{Field: field, Message: message})
+}
+
+// writeInvalid is a 400 for err, with its field when it is a FieldError.
+func writeInvalid(w http.ResponseWriter, r *http.Request, code string, err error) {
+	var fe FieldError
+	if errors.As(err, &fe) {
+		if code == CodeInvalidParameter {
+			writeInvalidParam(w, r, fe.Field, fe.Message)
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, code, fe.Message, fe)
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, code, err.Error())
+}
+
+// writeInternalError logs err in full and answers with a generic 500, so database and driver
+// errors never reach clients.
+func (s *Server) writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	if s.log != nil {
+		s.log.Error("request failed",
+			"request_id", middleware.GetReqID(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+			"key_id", keyIDFromContext(r.Context()),
+			"err", err,
+		)
+	}
+	writeError(w, r, http.StatusInternalServerError, CodeInternal, "internal server error")
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestWriteInternalErrorIsSanitized(t *testing.T) {
+	var logs bytes.Buffer
+	s := &Server{log: slog.New(slog.NewTextHandler(&logs, nil))}
+	cause := errors.New(`ERROR: relation "fund_analytics" does not exist (SQLSTATE 42P01)`)
+
+	h := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.writeInternalError(w, r, cause)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/funds", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "SQLSTATE") {
+		t.Fatalf("response leaks the cause: %s", rec.Body)
+	}
+	var got ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Error.Code != CodeInternal || got.Error.RequestID == "" {
+		t.Fatalf("unexpected body %+v", got)
+	}
+	if !strings.Contains(logs.String(), "SQLSTATE 42P01") || !strings.Contains(logs.String(), got.Error.RequestID) {
+		t.Fatalf("log should carry the cause and request id: %s", logs.String())
+	}
+}
@@ -1,118 +1,161 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 
+	"mf-analytics-service/internal/analytics"
 	"mf-analytics-service/internal/db"
 )
 
-func (s *Server) handleFundAnalytics() http.HandlerFunc {
-	type resp struct {
-		FundCode string `json:"fund_code"`
-		FundName string `json:"fund_name"`
-		Category string `json:"category"`
-		AMC      string `json:"amc"`
-		Window   string `json:"window"`
-
-		DataAvailability struct {
-			StartDate     string `json:"start_date,omitempty"`
-			EndDate       string `json:"end_date,omitempty"`
-			TotalDays     int    `json:"total_days,omitempty"`
-			NavDataPoints int    `json:"nav_data_points,omitempty"`
-		} `json:"data_availability"`
-
-		RollingPeriodsAnalyzed int `json:"rolling_periods_analyzed"`
-
-		RollingReturns struct {
-			Min    *float64 `json:"min,omitempty"`
-			Max    *float64 `json:"max,omitempty"`
-			Median *float64 `json:"median,omitempty"`
-			P25    *float64 `json:"p25,omitempty"`
-			P75    *float64 `json:"p75,omitempty"`
-		} `json:"rolling_returns"`
-
-		MaxDrawdown *float64 `json:"max_drawdown,omitempty"`
-
-		CAGR struct {
-			Min    *float64 `json:"min,omitempty"`
-			Max    *float64 `json:"max,omitempty"`
-			Median *float64 `json:"median,omitempty"`
-		} `json:"cagr"`
-
-		ComputedAt string `json:"computed_at,omitempty"`
-	}
+// FundAnalyticsResponse is the body of GET /funds/{code}/analytics.
+type FundAnalyticsResponse struct {
+	FundCode string `json:"fund_code"`
+	FundName string `json:"fund_name"`
+	Category string `json:"category"`
+	AMC      string `json:"amc"`
+	Window   string `json:"window"`
+	AsOf     string `json:"as_of,omitempty"`
+
+	DataAvailability DataAvailability `json:"data_availability"`
+
+	RollingPeriodsAnalyzed int `json:"rolling_periods_analyzed"`
+
+	RollingReturns struct {
+		Min    *float64 `json:"min,omitempty"`
+		Max    *float64 `json:"max,omitempty"`
+		Median *float64 `json:"median,omitempty"`
+		P25    *float64 `json:"p25,omitempty"`
+		P75    *float64 `json:"p75,omitempty"`
+	} `json:"rolling_returns"`
 
+	MaxDrawdown  *float64 `json:"max_drawdown,omitempty"`
+	Volatility   *float64 `json:"volatility,omitempty"`
+	Sharpe       *float64 `json:"sharpe,omitempty"`
+	Consistency  *float64 `json:"consistency,omitempty"`
+	RiskFreeRate *float64 `json:"risk_free_rate,omitempty"`
+
+	CAGR struct {
+		Min    *float64 `json:"min,omitempty"`
+		Max    *float64 `json:"max,omitempty"`
+		Median *float64 `json:"median,omitempty"`
+	} `json:"cagr"`
+
+	// CategoryRanking is keyed by metric; omitted for ad-hoc windows and as_of.
+	CategoryRanking map[string]CategoryRank `json:"category_ranking,omitempty"`
+
+	// Distribution is only included with detail=full.
+	Distribution *DistributionResponse `json:"distribution,omitempty"`
+
+	ComputedAt string `json:"computed_at,omitempty"`
+}
+
+func (s *Server) handleFundAnalytics() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		code := chi.URLParam(r, "code")
 		window := strings.TrimSpace(r.URL.Query().Get("window"))
 		if code == "" {
-			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing fund code"})
+			writeInvalidParam(w, r, "code", "missing fund code")
 			return
 		}
-		if !isValidWindow(window) {
-			writeJSON(
-				w,
-				http.StatusBadRequest,
-				map[string]any{"error": "window must be one of 1Y|3Y|5Y|10Y"},
-			)
+		detail, err := parseDistributionParams(r)
+		if err != nil {
+			writeInvalid(w, r, CodeInvalidParameter, err)
 			return
 		}
+		asOf, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("as_of")))
+		if err != nil {
+			writeInvalidParam(w, r, "as_of", "as_of must be YYYY-MM-DD")
+			return
+		}
+		// onDemand is set when the window is computed from nav_history on request: ad-hoc windows,
+		// and any window as of a past date.
+		var onDemand analytics.WindowSpec
+		if !s.isPrecomputedWindow(window) || asOf.Valid {
+			spec, err := analytics.ParseWindow(window)
+			if err != nil {
+				writeInvalidParam(w, r, "window", "window must be one of "+s.windowChoices()+" or any <n>M|<n>Y")
+				return
+			}
+			onDemand = spec
+		}
 
 		q := db.New(s.pool)
 		f, err := q.GetFund(r.Context(), code)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
-				writeJSON(w, http.StatusNotFound, map[string]any{"error": "fund not found"})
+				writeError(w, r, http.StatusNotFound, CodeFundNotFound, "fund not found")
 				return
 			}
-			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			s.writeInternalError(w, r, err)
 			return
 		}
 
-		a, err := q.GetFundAnalytics(
-			r.Context(),
-			db.GetFundAnalyticsParams{SchemeCode: code, Window: window},
-		)
+		var a db.FundAnalytic
+		if onDemand.Label != "" {
+			a, err = s.onDemandAnalytics(r.Context(), code, onDemand, asOf)
+		} else {
+			a, err = q.GetFundAnalytics(
+				r.Context(),
+				db.GetFundAnalyticsParams{SchemeCode: code, Window: window},
+			)
+		}
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				writeJSON(
+			if asOf.Valid && errors.Is(err, analytics.ErrInsufficientHistory) {
+				writeError(
 					w,
+					r,
 					http.StatusNotFound,
-					map[string]any{"error": "analytics not computed yet"},
+					CodeInsufficientHistory,
+					"not enough nav history on or before as_of",
 				)
 				return
 			}
-			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, analytics.ErrInsufficientHistory) {
+				writeError(w, r, http.StatusNotFound, CodeAnalyticsNotReady, "analytics not computed yet")
+				return
+			}
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		// Category ranks move when peers are recomputed even if this fund isn't, so their
+		// computed_at is part of the version too.
+		var ranks []db.FundCategoryRank
+		if onDemand.Label == "" {
+			ranks, err = q.ListFundCategoryRanks(
+				r.Context(),
+				db.ListFundCategoryRanksParams{SchemeCode: code, Window: window},
+			)
+			if err != nil {
+				s.writeInternalError(w, r, err)
+				return
+			}
+		}
+		if s.notModified(w, r, fundAnalyticsValidators(a, ranks, onDemand.Label != "")) {
 			return
 		}
 
-		out := resp{
+		out := FundAnalyticsResponse{
 			FundCode: code,
 			FundName: f.SchemeName,
 			Category: f.Category,
 			AMC:      f.Amc,
 			Window:   window,
 		}
-
-		if a.DataStartDate.Valid {
-			out.DataAvailability.StartDate = a.DataStartDate.Time.UTC().Format("2006-01-02")
-		}
-		if a.DataEndDate.Valid {
-			out.DataAvailability.EndDate = a.DataEndDate.Time.UTC().Format("2006-01-02")
-		}
-		if a.DataStartDate.Valid && a.DataEndDate.Valid {
-			out.DataAvailability.TotalDays = int(
-				a.DataEndDate.Time.Sub(a.DataStartDate.Time).Hours()/24,
-			) + 1
-		}
-		if a.NavPoints.Valid {
-			out.DataAvailability.NavDataPoints = int(a.NavPoints.Int32)
+		if asOf.Valid {
+			out.AsOf = asOf.Time.Format(dateLayout)
 		}
+
+		out.DataAvailability = newDataAvailability(a.DataStartDate, a.DataEndDate, a.NavPoints)
 		if a.RollingPeriods.Valid {
 			out.RollingPeriodsAnalyzed = int(a.RollingPeriods.Int32)
 		}
@@ -124,11 +167,33 @@ func (s *Server) handleFundAnalytics() http.HandlerFunc {
 		out.RollingReturns.P75 = numericPtr(a.RollingP75)
 
 		out.MaxDrawdown = numericPtr(a.MaxDrawdown)
+		out.Volatility = numericPtr(a.Volatility)
+		out.Sharpe = numericPtr(a.Sharpe)
+		out.Consistency = numericPtr(a.Consistency)
+		out.RiskFreeRate = numericPtr(a.RiskFreeRate)
 
 		out.CAGR.Min = numericPtr(a.CagrMin)
 		out.CAGR.Max = numericPtr(a.CagrMax)
 		out.CAGR.Median = numericPtr(a.CagrMedian)
 
+		if len(ranks) > 0 {
+			out.CategoryRanking = make(map[string]CategoryRank, len(ranks))
+		}
+		for _, rk := range ranks {
+			out.CategoryRanking[rk.Metric] = CategoryRank{
+				CategoryPercentile: round(rk.Percentile, 1),
+				Quartile:           int(rk.Quartile),
+			}
+		}
+
+		if detail.full {
+			out.Distribution, err = newDistributionResp(a.Distribution, detail)
+			if err != nil {
+				s.writeInternalError(w, r, err)
+				return
+			}
+		}
+
 		if a.ComputedAt.Valid {
 			out.ComputedAt = a.ComputedAt.Time.UTC().Format(timeRFC3339)
 		}
@@ -137,11 +202,103 @@ func (s *Server) handleFundAnalytics() http.HandlerFunc {
 	}
 }
 
-func isValidWindow(w string) bool {
-	switch w {
-	case "1Y", "3Y", "5Y", "10Y":
-		return true
-	default:
-		return false
+// fundAnalyticsValidators versions a fund's analytics by its computation and NAV coverage. Windows
+// computed on demand get a fresh computed_at on every computation, so they are versioned by the
+// NAVs they cover only.
+func fundAnalyticsValidators(a db.FundAnalytic, ranks []db.FundCategoryRank, onDemand bool) validators {
+	parts := []string{
+		a.SchemeCode,
+		a.Window,
+		a.DataStartDate.Time.Format(dateLayout),
+		a.DataEndDate.Time.Format(dateLayout),
+		strconv.Itoa(int(a.NavPoints.Int32)),
+	}
+	if onDemand {
+		return newValidators(time.Time{}, parts...)
+	}
+	lastModified := a.ComputedAt.Time
+	parts = append(parts, timestampPart(a.ComputedAt.Time))
+	for _, rk := range ranks {
+		parts = append(parts, timestampPart(rk.ComputedAt.Time))
+		if rk.ComputedAt.Time.After(lastModified) {
+			lastModified = rk.ComputedAt.Time
+		}
+	}
+	return newValidators(lastModified, parts...)
+}
+
+// CategoryRank places a fund's metric within its category: percentile 100 / quartile 1 is best.
+type CategoryRank struct {
+	CategoryPercentile float64 `json:"category_percentile"`
+	Quartile           int     `json:"quartile"`
+}
+
+// isPrecomputedWindow reports whether w is one of the configured windows kept in `fund_analytics`.
+func (s *Server) isPrecomputedWindow(w string) bool {
+	for _, spec := range s.windows {
+		if spec.Label == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) windowChoices() string {
+	labels := make([]string, 0, len(s.windows))
+	for _, spec := range s.windows {
+		labels = append(labels, spec.Label)
+	}
+	return strings.Join(labels, "|")
+}
+
+// onDemandAnalytics computes a window from nav_history, caching it until the fund's latest NAV
+// changes (the key includes the latest NAV date) or the entry expires. With asOf only NAVs up to
+// that date are used; the key is the cutoff itself, since later NAVs can't change the result.
+func (s *Server) onDemandAnalytics(ctx context.Context, code string, w analytics.WindowSpec, asOf pgtype.Date) (db.FundAnalytic, error) {
+	var key string
+	if asOf.Valid {
+		key = code + "|" + w.Label + "|as_of=" + asOf.Time.Format(dateLayout)
+	} else {
+		latest, err := db.New(s.pool).GetLatestNav(ctx, code)
+		if err != nil {
+			return db.FundAnalytic{}, err
+		}
+		key = code + "|" + w.Label + "|" + latest.NavDate.Time.Format(dateLayout)
+	}
+	if v, ok := s.onDemand.Get(key); ok {
+		return v.(db.FundAnalytic), nil
+	}
+
+	a, err := analytics.ComputeWindowOnDemand(ctx, s.pool, code, w, asOf.Time, s.riskFreePct)
+	if err != nil {
+		return db.FundAnalytic{}, err
+	}
+	s.onDemand.Set(key, a)
+	return a, nil
+}
+
+// DataAvailability describes the NAV history a response was derived from. It is shared by
+// every endpoint that reports coverage so the shapes (and day counting) stay identical.
+type DataAvailability struct {
+	StartDate     string `json:"start_date,omitempty"`
+	EndDate       string `json:"end_date,omitempty"`
+	TotalDays     int    `json:"total_days,omitempty"`
+	NavDataPoints int    `json:"nav_data_points,omitempty"`
+}
+
+func newDataAvailability(start, end pgtype.Date, points pgtype.Int4) DataAvailability {
+	var out DataAvailability
+	if start.Valid {
+		out.StartDate = start.Time.UTC().Format("2006-01-02")
+	}
+	if end.Valid {
+		out.EndDate = end.Time.UTC().Format("2006-01-02")
+	}
+	if start.Valid && end.Valid {
+		out.TotalDays = int(end.Time.Sub(start.Time).Hours()/24) + 1
+	}
+	if points.Valid {
+		out.NavDataPoints = int(points.Int32)
 	}
+	return out
 }
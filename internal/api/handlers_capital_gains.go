@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"mf-analytics-service/internal/investor"
+)
+
+var fyPattern = regexp.MustCompile(`^\d{4}-\d{2}$`)
+
+type RealizedLot struct {
+	FundCode        string  `json:"fund_code"`
+	Acquired        string  `json:"acquired"`
+	Sold            string  `json:"sold"`
+	Units           float64 `json:"units"`
+	Cost            float64 `json:"cost"`
+	AcquisitionCost float64 `json:"acquisition_cost"`
+	SaleValue       float64 `json:"sale_value"`
+	Gain            float64 `json:"gain"`
+	Term            string  `json:"term"`
+	Grandfathered   bool    `json:"grandfathered"`
+	RuleVersion     string  `json:"rule_version"`
+	Rate            float64 `json:"rate"`
+}
+
+type TaxBucket struct {
+	Term    string  `json:"term"`
+	Rate    float64 `json:"rate"`
+	Cess    float64 `json:"cess_rate"`
+	Net     float64 `json:"net"`
+	Taxable float64 `json:"taxable"`
+	Tax     float64 `json:"tax"`
+}
+
+type CapitalGainsYear struct {
+	FY             string        `json:"fy"`
+	STCG           float64       `json:"stcg"`
+	STCL           float64       `json:"stcl"`
+	LTCG           float64       `json:"ltcg"`
+	LTCL           float64       `json:"ltcl"`
+	ExemptionLimit float64       `json:"ltcg_exemption_limit"`
+	ExemptionUsed  float64       `json:"ltcg_exemption_used"`
+	Buckets        []TaxBucket   `json:"buckets"`
+	Tax            float64       `json:"tax"`
+	Cess           float64       `json:"cess"`
+	TotalTax       float64       `json:"total_tax"`
+	UnabsorbedSTCL float64       `json:"unabsorbed_stcl"`
+	UnabsorbedLTCL float64       `json:"unabsorbed_ltcl"`
+	Lots           []RealizedLot `json:"lots,omitempty"`
+}
+
+// CapitalGainsResponse is the body of GET /users/{id}/capital-gains.
+type CapitalGainsResponse struct {
+	UserID string             `json:"user_id"`
+	Years  []CapitalGainsYear `json:"years"`
+	// ExcludedFunds are non-equity funds, whose gains aren't computed.
+	ExcludedFunds []string `json:"excluded_funds"`
+}
+
+func (s *Server) handleUserCapitalGains() http.HandlerFunc {
+	term := func(longTerm bool) string {
+		if longTerm {
+			return "long"
+		}
+		return "short"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(w, r)
+		if !ok {
+			return
+		}
+		fy := strings.TrimSpace(r.URL.Query().Get("fy"))
+		if fy != "" && !fyPattern.MatchString(fy) {
+			writeInvalidParam(w, r, "fy", "fy must be YYYY-YY, e.g. 2024-25")
+			return
+		}
+
+		report, err := investor.UserCapitalGains(r.Context(), s.pool, userID)
+		var oversold *investor.OversoldError
+		var noRule *investor.NoTaxRuleError
+		switch {
+		case errors.As(err, &oversold):
+			writeError(w, r, http.StatusUnprocessableEntity, CodeInvalidTransactions, err.Error())
+			return
+		case errors.As(err, &noRule):
+			writeError(w, r, http.StatusUnprocessableEntity, CodeNoTaxRule, err.Error())
+			return
+		case err != nil:
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		out := CapitalGainsResponse{UserID: userID, Years: []CapitalGainsYear{}, ExcludedFunds: report.Excluded}
+		if out.ExcludedFunds == nil {
+			out.ExcludedFunds = []string{}
+		}
+		for _, y := range report.Years {
+			// fy narrows the report to one year, which then also lists its lots.
+			if fy != "" && y.FY != fy {
+				continue
+			}
+			yr := CapitalGainsYear{
+				FY:             y.FY,
+				STCG:           round(y.STCG, 2),
+				STCL:           round(y.STCL, 2),
+				LTCG:           round(y.LTCG, 2),
+				LTCL:           round(y.LTCL, 2),
+				ExemptionLimit: round(y.ExemptionLimit, 2),
+				ExemptionUsed:  round(y.ExemptionUsed, 2),
+				Buckets:        make([]TaxBucket, len(y.Buckets)),
+				Tax:            round(y.Tax, 2),
+				Cess:           round(y.Cess, 2),
+				TotalTax:       round(y.Tax+y.Cess, 2),
+				UnabsorbedSTCL: round(y.UnabsorbedSTCL, 2),
+				UnabsorbedLTCL: round(y.UnabsorbedLTCL, 2),
+			}
+			for i, b := range y.Buckets {
+				yr.Buckets[i] = TaxBucket{
+					Term:    term(b.LongTerm),
+					Rate:    b.RatePct,
+					Cess:    b.CessPct,
+					Net:     round(b.Net, 2),
+					Taxable: round(b.Taxable, 2),
+					Tax:     round(b.Tax, 2),
+				}
+			}
+			if fy != "" {
+				yr.Lots = make([]RealizedLot, len(y.Lots))
+				for i, l := range y.Lots {
+					yr.Lots[i] = RealizedLot{
+						FundCode:        l.SchemeCode,
+						Acquired:        l.Acquired.Format(dateLayout),
+						Sold:            l.Sold.Format(dateLayout),
+						Units:           round(l.Units, 4),
+						Cost:            round(l.Cost, 2),
+						AcquisitionCost: round(l.AcquisitionCost, 2),
+						SaleValue:       round(l.SaleValue, 2),
+						Gain:            round(l.Gain, 2),
+						Term:            term(l.LongTerm),
+						Grandfathered:   l.Grandfathered,
+						RuleVersion:     l.RuleVersion,
+						Rate:            l.RatePct,
+					}
+				}
+			}
+			out.Years = append(out.Years, yr)
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mf-analytics-service/internal/db"
+)
+
+type CategoryMetric struct {
+	Funds  int     `json:"funds"`
+	Mean   float64 `json:"mean"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+}
+
+// CategoryAnalyticsResponse is the body of GET /categories/{category}/analytics.
+type CategoryAnalyticsResponse struct {
+	Category   string                    `json:"category"`
+	Window     string                    `json:"window"`
+	TotalFunds int64                     `json:"total_funds"`
+	Metrics    map[string]CategoryMetric `json:"metrics"`
+	ComputedAt string                    `json:"computed_at,omitempty"`
+}
+
+func (s *Server) handleCategoryAnalytics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Category names may contain encoded slashes; chi leaves them escaped.
+		category, err := url.PathUnescape(chi.URLParam(r, "category"))
+		if err != nil || strings.TrimSpace(category) == "" {
+			writeInvalidParam(w, r, "category", "invalid category")
+			return
+		}
+		window := strings.TrimSpace(r.URL.Query().Get("window"))
+		if !s.isPrecomputedWindow(window) {
+			writeInvalidParam(w, r, "window", "window must be one of "+s.windowChoices())
+			return
+		}
+
+		q := db.New(s.pool)
+		total, err := q.CountFundsByCategory(r.Context(), category)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		if total == 0 {
+			writeError(w, r, http.StatusNotFound, CodeCategoryNotFound, "category not found")
+			return
+		}
+
+		rows, err := q.ListCategoryAnalytics(
+			r.Context(),
+			db.ListCategoryAnalyticsParams{Category: category, Window: window},
+		)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		if len(rows) == 0 {
+			writeError(w, r, http.StatusNotFound, CodeAnalyticsNotReady, "category analytics not computed yet")
+			return
+		}
+
+		// Category stats are rebuilt together after each sync run, so their computed_at versions
+		// the response; total covers funds joining or leaving the category.
+		var lastModified time.Time
+		parts := []string{category, window, strconv.FormatInt(total, 10)}
+		for _, row := range rows {
+			parts = append(parts, row.Metric, timestampPart(row.ComputedAt.Time))
+			if row.ComputedAt.Time.After(lastModified) {
+				lastModified = row.ComputedAt.Time
+			}
+		}
+		if s.notModified(w, r, newValidators(lastModified, parts...)) {
+			return
+		}
+
+		out := CategoryAnalyticsResponse{
+			Category:   category,
+			Window:     window,
+			TotalFunds: total,
+			Metrics:    make(map[string]CategoryMetric, len(rows)),
+		}
+		for _, row := range rows {
+			out.Metrics[row.Metric] = CategoryMetric{
+				Funds:  int(row.FundCount),
+				Mean:   round(row.Mean, 2),
+				P25:    round(row.P25, 2),
+				Median: round(row.Median, 2),
+				P75:    round(row.P75, 2),
+			}
+			if row.ComputedAt.Valid && out.ComputedAt == "" {
+				out.ComputedAt = row.ComputedAt.Time.UTC().Format(timeRFC3339)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+const (
+	minCompareFunds = 2
+	maxCompareFunds = 5
+)
+
+type ComparedFund struct {
+	FundCode string `json:"fund_code"`
+	FundName string `json:"fund_name"`
+	Category string `json:"category"`
+	AMC      string `json:"amc"`
+}
+
+type CompareWindow struct {
+	// DataEndDate and each metric list hold one entry per fund, in the order of funds.
+	DataEndDate []*string             `json:"data_end_date"`
+	Metrics     map[string][]*float64 `json:"metrics"`
+}
+
+type CommonPeriodFund struct {
+	FundCode        string   `json:"fund_code"`
+	Return          *float64 `json:"return"`
+	CAGR            *float64 `json:"cagr"`
+	Volatility      *float64 `json:"volatility"`
+	MaxDrawdown     *float64 `json:"max_drawdown"`
+	RelativeToFirst *float64 `json:"relative_to_first"`
+}
+
+type CommonPeriod struct {
+	StartDate    string             `json:"start_date"`
+	EndDate      string             `json:"end_date"`
+	Observations int                `json:"observations"`
+	Funds        []CommonPeriodFund `json:"funds"`
+	Correlation  [][]*float64       `json:"correlation"`
+}
+
+// FundsCompareResponse is the body of GET /funds/compare.
+type FundsCompareResponse struct {
+	Funds        []ComparedFund           `json:"funds"`
+	Windows      map[string]CompareWindow `json:"windows"`
+	CommonPeriod *CommonPeriod            `json:"common_period"`
+}
+
+func (s *Server) handleFundsCompare() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		codes := splitParam(r.URL.Query().Get("codes"))
+		if len(codes) < minCompareFunds || len(codes) > maxCompareFunds {
+			writeInvalidParam(w, r, "codes", "codes must list 2 to 5 distinct fund codes")
+			return
+		}
+		windows := splitParam(r.URL.Query().Get("windows"))
+		if len(windows) == 0 {
+			for _, spec := range s.windows {
+				windows = append(windows, spec.Label)
+			}
+		}
+		for _, window := range windows {
+			if !s.isPrecomputedWindow(window) {
+				writeInvalidParam(w, r, "windows", "windows must be among "+s.windowChoices())
+				return
+			}
+		}
+
+		q := db.New(s.pool)
+
+		out := FundsCompareResponse{
+			Funds:   make([]ComparedFund, 0, len(codes)),
+			Windows: make(map[string]CompareWindow, len(windows)),
+		}
+		for _, code := range codes {
+			f, err := q.GetFund(r.Context(), code)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					writeError(w, r, http.StatusNotFound, CodeFundNotFound, "fund not found: "+code)
+					return
+				}
+				s.writeInternalError(w, r, err)
+				return
+			}
+			out.Funds = append(out.Funds, ComparedFund{
+				FundCode: f.SchemeCode,
+				FundName: f.SchemeName,
+				Category: f.Category,
+				AMC:      f.Amc,
+			})
+		}
+
+		rows, err := q.ListFundAnalyticsForSchemes(r.Context(), db.ListFundAnalyticsForSchemesParams{
+			SchemeCodes: codes,
+			Windows:     windows,
+		})
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		metrics := rankMetricNames()
+		position := make(map[string]int, len(codes))
+		for i, code := range codes {
+			position[code] = i
+		}
+		for _, window := range windows {
+			wm := CompareWindow{
+				DataEndDate: make([]*string, len(codes)),
+				Metrics:     make(map[string][]*float64, len(metrics)),
+			}
+			for _, m := range metrics {
+				wm.Metrics[m] = make([]*float64, len(codes))
+			}
+			out.Windows[window] = wm
+		}
+		for _, a := range rows {
+			wm := out.Windows[a.Window]
+			i := position[a.SchemeCode]
+			if a.DataEndDate.Valid {
+				d := a.DataEndDate.Time.UTC().Format(dateLayout)
+				wm.DataEndDate[i] = &d
+			}
+			for name, col := range metricColumns(a) {
+				wm.Metrics[name][i] = numericPtr(col)
+			}
+		}
+
+		cp, err := analytics.CompareCommonPeriod(r.Context(), s.pool, codes)
+		switch {
+		case errors.Is(err, analytics.ErrInsufficientHistory):
+			// No overlapping history: common_period stays null.
+		case err != nil:
+			s.writeInternalError(w, r, err)
+			return
+		default:
+			out.CommonPeriod = &CommonPeriod{
+				StartDate:    cp.Start.Format(dateLayout),
+				EndDate:      cp.End.Format(dateLayout),
+				Observations: cp.Observations,
+				Funds:        make([]CommonPeriodFund, len(cp.Funds)),
+				Correlation:  make([][]*float64, len(cp.Correlation)),
+			}
+			for i, f := range cp.Funds {
+				out.CommonPeriod.Funds[i] = CommonPeriodFund{
+					FundCode:        f.SchemeCode,
+					Return:          roundPtr(f.Return, 2),
+					CAGR:            roundPtr(f.CAGR, 2),
+					Volatility:      roundPtr(f.Volatility, 2),
+					MaxDrawdown:     roundPtr(f.MaxDrawdown, 2),
+					RelativeToFirst: roundPtr(f.Relative, 2),
+				}
+			}
+			for i, row := range cp.Correlation {
+				out.CommonPeriod.Correlation[i] = make([]*float64, len(row))
+				for j, c := range row {
+					out.CommonPeriod.Correlation[i][j] = roundPtr(c, 4)
+				}
+			}
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
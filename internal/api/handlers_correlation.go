@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+type CorrelationFund struct {
+	FundCode string `json:"fund_code"`
+	FundName string `json:"fund_name"`
+}
+
+type CorrelationPair struct {
+	FundA        string   `json:"fund_a"`
+	FundB        string   `json:"fund_b"`
+	Correlation  *float64 `json:"correlation"`
+	Observations int      `json:"observations"`
+	Insufficient bool     `json:"insufficient"`
+	StartDate    string   `json:"start_date,omitempty"`
+	EndDate      string   `json:"end_date,omitempty"`
+}
+
+// CorrelationResponse is the body of GET /analytics/correlation.
+type CorrelationResponse struct {
+	Window    string            `json:"window"`
+	Frequency string            `json:"frequency"`
+	Category  string            `json:"category,omitempty"`
+	Funds     []CorrelationFund `json:"funds"`
+	// Matrix is aligned with Funds; null where the pair is insufficient or a series is flat.
+	Matrix            [][]*float64      `json:"matrix"`
+	Pairs             []CorrelationPair `json:"pairs"`
+	InsufficientPairs int               `json:"insufficient_pairs"`
+	ComputedAt        string            `json:"computed_at,omitempty"`
+}
+
+func (s *Server) handleCorrelationMatrix() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		window := strings.TrimSpace(r.URL.Query().Get("window"))
+		if _, err := analytics.ParseWindow(window); err != nil {
+			writeInvalidParam(w, r, "window", "window must be <n>M|<n>Y")
+			return
+		}
+		frequency := strings.TrimSpace(r.URL.Query().Get("frequency"))
+		if frequency == "" {
+			frequency = analytics.FrequencyDaily
+		}
+		if frequency != analytics.FrequencyDaily && frequency != analytics.FrequencyWeekly {
+			writeInvalidParam(w, r, "frequency", "frequency must be daily|weekly")
+			return
+		}
+		category := strings.TrimSpace(r.URL.Query().Get("category"))
+
+		q := db.New(s.pool)
+
+		rows, err := q.ListFundCorrelations(r.Context(), db.ListFundCorrelationsParams{
+			Window:    window,
+			Frequency: frequency,
+			Category:  pgtype.Text{String: category, Valid: category != ""},
+		})
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		if len(rows) == 0 && category == "" {
+			writeError(
+				w,
+				r,
+				http.StatusNotFound,
+				CodeAnalyticsNotReady,
+				"correlations not computed for this window and frequency",
+			)
+			return
+		}
+
+		funds, err := q.ListFunds(r.Context(), db.ListFundsParams{
+			Category: pgtype.Text{String: category, Valid: category != ""},
+		})
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		names := make(map[string]string, len(funds))
+		for _, f := range funds {
+			names[f.SchemeCode] = f.SchemeName
+		}
+
+		out := CorrelationResponse{
+			Window:    window,
+			Frequency: frequency,
+			Category:  category,
+			Funds:     []CorrelationFund{},
+			Matrix:    [][]*float64{},
+			Pairs:     make([]CorrelationPair, 0, len(rows)),
+		}
+
+		seen := map[string]bool{}
+		var codes []string
+		for _, row := range rows {
+			for _, code := range []string{row.SchemeCodeA, row.SchemeCodeB} {
+				if !seen[code] {
+					seen[code] = true
+					codes = append(codes, code)
+				}
+			}
+		}
+		sort.Strings(codes)
+		index := make(map[string]int, len(codes))
+		one := 1.0
+		for i, code := range codes {
+			index[code] = i
+			out.Funds = append(out.Funds, CorrelationFund{FundCode: code, FundName: names[code]})
+			rowVals := make([]*float64, len(codes))
+			rowVals[i] = &one
+			out.Matrix = append(out.Matrix, rowVals)
+		}
+
+		for _, row := range rows {
+			p := CorrelationPair{
+				FundA:        row.SchemeCodeA,
+				FundB:        row.SchemeCodeB,
+				Correlation:  numericPtr(row.Correlation),
+				Observations: int(row.Observations),
+				Insufficient: !row.Sufficient,
+			}
+			if row.PeriodStart.Valid {
+				p.StartDate = row.PeriodStart.Time.UTC().Format(dateLayout)
+			}
+			if row.PeriodEnd.Valid {
+				p.EndDate = row.PeriodEnd.Time.UTC().Format(dateLayout)
+			}
+			if p.Insufficient {
+				out.InsufficientPairs++
+			}
+			out.Pairs = append(out.Pairs, p)
+
+			a, b := index[row.SchemeCodeA], index[row.SchemeCodeB]
+			out.Matrix[a][b] = p.Correlation
+			out.Matrix[b][a] = p.Correlation
+
+			if row.ComputedAt.Valid {
+				out.ComputedAt = row.ComputedAt.Time.UTC().Format(timeRFC3339)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
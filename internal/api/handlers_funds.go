@@ -1,9 +1,15 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
@@ -12,56 +18,245 @@ import (
 	"mf-analytics-service/internal/db"
 )
 
-func (s *Server) handleFundsList() http.HandlerFunc {
-	type fund struct {
-		SchemeCode string `json:"scheme_code"`
-		SchemeName string `json:"scheme_name"`
-		AMC        string `json:"amc"`
-		Category   string `json:"category"`
+// Paging and sorting of GET /funds.
+const (
+	defaultFundsPageSize = 50
+	maxFundsPageSize     = 200
+)
+
+var fundSorts = map[string]bool{"name": true, "amc": true, "nav": true, "inception": true}
+
+// fundsCursor is the sort key of the last fund on a page. It is tied to the sort and the filters
+// it was issued for.
+type fundsCursor struct {
+	Sort   string  `json:"s"`
+	Desc   bool    `json:"d"`
+	Filter string  `json:"f"`
+	Text   string  `json:"t"`
+	Num    float64 `json:"n"`
+	Code   string  `json:"c"`
+}
+
+func (c fundsCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeFundsCursor(s string) (fundsCursor, error) {
+	var c fundsCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
 	}
+	if err != nil || c.Code == "" {
+		return fundsCursor{}, errors.New("invalid cursor")
+	}
+	return c, nil
+}
+
+// fundsFilterHash identifies the q, category and amc filters of a page, so a cursor cannot be
+// replayed against a different result set.
+func fundsFilterHash(q, category, amc string) string {
+	h := sha256.Sum256([]byte(q + "\x00" + category + "\x00" + amc))
+	return hex.EncodeToString(h[:8])
+}
 
+// likePattern escapes ILIKE wildcards so user input matches literally.
+var likePattern = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type LatestNAV struct {
+	Date string  `json:"date"`
+	NAV  float64 `json:"nav"`
+}
+
+type FundListItem struct {
+	SchemeCode    string              `json:"scheme_code"`
+	SchemeName    string              `json:"scheme_name"`
+	AMC           string              `json:"amc"`
+	Category      string              `json:"category"`
+	InceptionDate string              `json:"inception_date,omitempty"`
+	LatestNAV     *LatestNAV          `json:"latest_nav,omitempty"`
+	Metrics       map[string]*float64 `json:"metrics,omitempty"`
+}
+
+// FundsListResponse is the body of GET /funds.
+type FundsListResponse struct {
+	Funds      []FundListItem `json:"funds"`
+	Sort       string         `json:"sort"`
+	Order      string         `json:"order"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	// MetricsWindow is the window include=metrics reads.
+	MetricsWindow string `json:"metrics_window,omitempty"`
+}
+
+func (s *Server) handleFundsList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		category := r.URL.Query().Get("category")
-		amc := r.URL.Query().Get("amc")
+		query := r.URL.Query()
+		filter := func(name string) pgtype.Text {
+			v := strings.TrimSpace(query.Get(name))
+			return pgtype.Text{String: likePattern.Replace(v), Valid: v != ""}
+		}
+		// The trigram match compares the raw query; only ILIKE takes the escaped pattern.
+		search := strings.TrimSpace(query.Get("q"))
+		filterHash := fundsFilterHash(search, strings.TrimSpace(query.Get("category")), strings.TrimSpace(query.Get("amc")))
+
+		sortBy := strings.TrimSpace(query.Get("sort"))
+		if sortBy == "" {
+			sortBy = "name"
+		}
+		if !fundSorts[sortBy] {
+			writeInvalidParam(w, r, "sort", "sort must be name|amc|nav|inception")
+			return
+		}
+		order := strings.TrimSpace(query.Get("order"))
+		if order == "" {
+			order = "asc"
+		}
+		if order != "asc" && order != "desc" {
+			writeInvalidParam(w, r, "order", "order must be asc|desc")
+			return
+		}
+		limit, err := parseLimit(strings.TrimSpace(query.Get("limit")), defaultFundsPageSize)
+		if err != nil || limit > maxFundsPageSize {
+			writeInvalidParam(w, r, "limit", fmt.Sprintf("limit must be between 1 and %d", maxFundsPageSize))
+			return
+		}
+
+		var includeNAV, includeMetrics bool
+		for _, inc := range splitParam(query.Get("include")) {
+			switch inc {
+			case "latest_nav":
+				includeNAV = true
+			case "metrics":
+				includeMetrics = true
+			default:
+				writeInvalidParam(w, r, "include", "include must list latest_nav|metrics")
+				return
+			}
+		}
+		window := strings.TrimSpace(query.Get("window"))
+		if includeMetrics {
+			if window == "" && len(s.windows) > 0 {
+				window = s.windows[0].Label
+			}
+			if !s.isPrecomputedWindow(window) {
+				writeInvalidParam(w, r, "window", "window must be one of "+s.windowChoices())
+				return
+			}
+		}
+
+		params := db.SearchFundsParams{
+			Sort:       sortBy,
+			Category:   filter("category"),
+			Amc:        filter("amc"),
+			QPattern:   filter("q"),
+			Q:          pgtype.Text{String: search, Valid: search != ""},
+			Descending: order == "desc",
+			// One extra row tells whether there is a next page.
+			PageLimit: limit + 1,
+		}
+		if v := strings.TrimSpace(query.Get("cursor")); v != "" {
+			c, err := decodeFundsCursor(v)
+			if err == nil && (c.Sort != sortBy || c.Desc != params.Descending) {
+				err = errors.New("cursor was issued for a different sort")
+			} else if err == nil && c.Filter != filterHash {
+				err = errors.New("cursor was issued for different filters")
+			}
+			if err != nil {
+				writeInvalidParam(w, r, "cursor", err.Error())
+				return
+			}
+			params.AfterCode = pgtype.Text{String: c.Code, Valid: true}
+			params.AfterText = c.Text
+			params.AfterNum = c.Num
+		}
 
 		q := db.New(s.pool)
-		rows, err := q.ListFunds(r.Context(), db.ListFundsParams{
-			Category: pgtype.Text{String: category, Valid: category != ""},
-			Amc:      pgtype.Text{String: amc, Valid: amc != ""},
-		})
+		rows, err := q.SearchFunds(r.Context(), params)
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			s.writeInternalError(w, r, err)
 			return
 		}
 
-		out := make([]fund, 0, len(rows))
+		out := FundsListResponse{Funds: make([]FundListItem, 0, len(rows)), Sort: sortBy, Order: order}
+		if len(rows) > int(limit) {
+			rows = rows[:limit]
+			last := rows[len(rows)-1]
+			out.NextCursor = fundsCursor{
+				Sort:   sortBy,
+				Desc:   params.Descending,
+				Filter: filterHash,
+				Text:   last.SortText,
+				Num:    last.SortNum,
+				Code:   last.SchemeCode,
+			}.encode()
+		}
+
+		position := make(map[string]int, len(rows))
 		for _, f := range rows {
-			out = append(out, fund{
+			item := FundListItem{
 				SchemeCode: f.SchemeCode,
 				SchemeName: f.SchemeName,
 				AMC:        f.Amc,
 				Category:   f.Category,
+			}
+			if f.InceptionDate.Valid {
+				item.InceptionDate = f.InceptionDate.Time.UTC().Format(dateLayout)
+			}
+			if includeNAV && f.LatestNavDate.Valid {
+				if nav := numericPtr(f.LatestNav); nav != nil {
+					item.LatestNAV = &LatestNAV{Date: f.LatestNavDate.Time.UTC().Format(dateLayout), NAV: *nav}
+				}
+			}
+			if includeMetrics {
+				item.Metrics = map[string]*float64{}
+			}
+			position[f.SchemeCode] = len(out.Funds)
+			out.Funds = append(out.Funds, item)
+		}
+
+		if includeMetrics && len(rows) > 0 {
+			out.MetricsWindow = window
+			codes := make([]string, len(rows))
+			for i, f := range rows {
+				codes[i] = f.SchemeCode
+			}
+			analyticsRows, err := q.ListFundAnalyticsForSchemes(r.Context(), db.ListFundAnalyticsForSchemesParams{
+				SchemeCodes: codes,
+				Windows:     []string{window},
 			})
+			if err != nil {
+				s.writeInternalError(w, r, err)
+				return
+			}
+			for _, a := range analyticsRows {
+				m := out.Funds[position[a.SchemeCode]].Metrics
+				m["cagr_median"] = numericPtr(a.CagrMedian)
+				m["max_drawdown"] = numericPtr(a.MaxDrawdown)
+				m["volatility"] = numericPtr(a.Volatility)
+				m["sharpe"] = numericPtr(a.Sharpe)
+			}
 		}
 
-		writeJSON(w, http.StatusOK, map[string]any{"funds": out})
+		writeJSON(w, http.StatusOK, out)
 	}
 }
 
-func (s *Server) handleFundDetails() http.HandlerFunc {
-	type resp struct {
-		SchemeCode string  `json:"scheme_code"`
-		SchemeName string  `json:"scheme_name"`
-		AMC        string  `json:"amc"`
-		Category   string  `json:"category"`
-		LatestNAV  float64 `json:"latest_nav,omitempty"`
-		NAVDate    string  `json:"nav_date,omitempty"`
-	}
+// FundDetailsResponse is the body of GET /funds/{code}.
+type FundDetailsResponse struct {
+	SchemeCode string  `json:"scheme_code"`
+	SchemeName string  `json:"scheme_name"`
+	AMC        string  `json:"amc"`
+	Category   string  `json:"category"`
+	LatestNAV  float64 `json:"latest_nav,omitempty"`
+	NAVDate    string  `json:"nav_date,omitempty"`
+}
 
+func (s *Server) handleFundDetails() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		code := chi.URLParam(r, "code")
 		if code == "" {
-			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing fund code"})
+			writeInvalidParam(w, r, "code", "missing fund code")
 			return
 		}
 
@@ -69,14 +264,14 @@ func (s *Server) handleFundDetails() http.HandlerFunc {
 		f, err := q.GetFund(r.Context(), code)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
-				writeJSON(w, http.StatusNotFound, map[string]any{"error": "fund not found"})
+				writeError(w, r, http.StatusNotFound, CodeFundNotFound, "fund not found")
 				return
 			}
-			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			s.writeInternalError(w, r, err)
 			return
 		}
 
-		out := resp{
+		out := FundDetailsResponse{
 			SchemeCode: f.SchemeCode,
 			SchemeName: f.SchemeName,
 			AMC:        f.Amc,
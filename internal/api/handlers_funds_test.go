@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+// TestFundsCursorBoundToQuery replays a cursor under a different sort or filter; both are
+// rejected before any query runs, so no database is needed.
+func TestFundsCursorBoundToQuery(t *testing.T) {
+	s := newTestServer(t, nil)
+	cursor := fundsCursor{
+		Sort:   "name",
+		Filter: fundsFilterHash("bluechip", "equity", ""),
+		Text:   "Axis Bluechip Fund",
+		Code:   "120465",
+	}.encode()
+
+	for _, query := range []string{
+		"q=midcap&category=equity",
+		"q=bluechip&category=debt",
+		"q=bluechip&category=equity&amc=axis",
+		"q=bluechip&category=equity&sort=nav",
+		"q=bluechip&category=equity&order=desc",
+	} {
+		t.Run(query, func(t *testing.T) {
+			rec := serve(s, "GET", "/funds?"+query+"&cursor="+url.QueryEscape(cursor), "")
+			if rec.Code != 400 {
+				t.Fatalf("status %d, want 400: %s", rec.Code, rec.Body)
+			}
+			var got ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Error.Details) != 1 || got.Error.Details[0].Field != "cursor" {
+				t.Errorf("details %+v, want field cursor", got.Error.Details)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mf-analytics-service/internal/db"
+)
+
+type NAVPoint struct {
+	Date   string  `json:"date"`
+	NAV    float64 `json:"nav"`
+	Filled bool    `json:"filled,omitempty"`
+}
+
+// FundNAVResponse is the body of GET /funds/{code}/nav.
+type FundNAVResponse struct {
+	FundCode         string           `json:"fund_code"`
+	Freq             string           `json:"freq"`
+	Fill             string           `json:"fill"`
+	From             string           `json:"from,omitempty"`
+	To               string           `json:"to,omitempty"`
+	DataAvailability DataAvailability `json:"data_availability"`
+	Points           int              `json:"points"`
+	Data             []NAVPoint       `json:"data"`
+}
+
+func (s *Server) handleFundNav() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		if code == "" {
+			writeInvalidParam(w, r, "code", "missing fund code")
+			return
+		}
+		from, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("from")))
+		if err != nil {
+			writeInvalidParam(w, r, "from", "from must be YYYY-MM-DD")
+			return
+		}
+		to, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("to")))
+		if err != nil {
+			writeInvalidParam(w, r, "to", "to must be YYYY-MM-DD")
+			return
+		}
+		if from.Valid && to.Valid && to.Time.Before(from.Time) {
+			writeInvalidParam(w, r, "to", "to must not be before from")
+			return
+		}
+		freq, err := parseFreq(strings.TrimSpace(r.URL.Query().Get("freq")), freqDaily)
+		if err != nil {
+			writeInvalidParam(w, r, "freq", "freq "+err.Error())
+			return
+		}
+		fill := strings.TrimSpace(r.URL.Query().Get("fill"))
+		if fill == "" {
+			fill = "none"
+		}
+		if fill != "none" && fill != "ffill" {
+			writeInvalidParam(w, r, "fill", "fill must be one of none|ffill")
+			return
+		}
+
+		if _, ok := s.lookupFund(w, r, code); !ok {
+			return
+		}
+
+		q := db.New(s.pool)
+
+		bounds, err := q.GetNavHistoryBounds(r.Context(), code)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		out := FundNAVResponse{
+			FundCode: code,
+			Freq:     freq,
+			Fill:     fill,
+			DataAvailability: newDataAvailability(
+				bounds.StartDate,
+				bounds.EndDate,
+				pgtype.Int4{Int32: bounds.NavPoints, Valid: bounds.NavPoints > 0},
+			),
+			Data: []NAVPoint{},
+		}
+
+		var obs []navObs
+		if bounds.NavPoints > 0 {
+			from, to, err = clampToHistory(from, to, bounds)
+			if err != nil {
+				writeInvalid(w, r, CodeInvalidParameter, err)
+				return
+			}
+			out.From = from.Time.UTC().Format(dateLayout)
+			out.To = to.Time.UTC().Format(dateLayout)
+
+			rows, err := q.ListNavHistoryBetween(r.Context(), db.ListNavHistoryBetweenParams{
+				SchemeCode: code,
+				NavDate:    from,
+				NavDate_2:  to,
+			})
+			if err != nil {
+				s.writeInternalError(w, r, err)
+				return
+			}
+
+			// Non-positive NAVs are skipped as in the analytics compute, so data and
+			// data_availability count the same rows as the analytics block.
+			for _, row := range rows {
+				if row.NavValue.IsPositive() {
+					obs = append(obs, navObs{date: row.NavDate.Time, nav: row.NavValue.InexactFloat64()})
+				}
+			}
+
+			// With ffill, seed the range start from the last NAV before it so the
+			// series begins exactly at `from`.
+			if fill == "ffill" && (len(obs) == 0 || obs[0].date.After(from.Time)) {
+				seed, err := q.GetNavOnOrBefore(r.Context(), db.GetNavOnOrBeforeParams{
+					SchemeCode: code,
+					NavDate:    from,
+				})
+				if err == nil && seed.NavValue.IsPositive() {
+					obs = append([]navObs{{date: from.Time, nav: seed.NavValue.InexactFloat64(), filled: true}}, obs...)
+				} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+					s.writeInternalError(w, r, err)
+					return
+				}
+			}
+			obs = resampleNav(obs, freq, fill == "ffill", to.Time)
+		}
+
+		for _, o := range obs {
+			out.Data = append(out.Data, NAVPoint{
+				Date:   o.date.UTC().Format(dateLayout),
+				NAV:    o.nav,
+				Filled: o.filled,
+			})
+		}
+		out.Points = len(out.Data)
+
+		if wantsCSV(r) {
+			w.Header().Set("X-Data-Start-Date", out.DataAvailability.StartDate)
+			w.Header().Set("X-Data-End-Date", out.DataAvailability.EndDate)
+			records := make([][]string, 0, len(out.Data))
+			for _, p := range out.Data {
+				records = append(records, []string{
+					p.Date,
+					strconv.FormatFloat(p.NAV, 'f', 4, 64),
+					strconv.FormatBool(p.Filled),
+				})
+			}
+			writeCSV(w, http.StatusOK, []string{"date", "nav", "filled"}, records)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// clampToHistory clamps the requested range to the stored history; we never extrapolate past
+// it. A range entirely outside the history is a FieldError, since ffill would otherwise emit a
+// point dated beyond the data.
+func clampToHistory(from, to pgtype.Date, bounds db.GetNavHistoryBoundsRow) (pgtype.Date, pgtype.Date, error) {
+	if from.Valid && from.Time.After(bounds.EndDate.Time) {
+		return from, to, FieldError{
+			Field:   "from",
+			Message: "from is after the last NAV date " + bounds.EndDate.Time.UTC().Format(dateLayout),
+		}
+	}
+	if to.Valid && to.Time.Before(bounds.StartDate.Time) {
+		return from, to, FieldError{
+			Field:   "to",
+			Message: "to is before the first NAV date " + bounds.StartDate.Time.UTC().Format(dateLayout),
+		}
+	}
+	if !from.Valid || from.Time.Before(bounds.StartDate.Time) {
+		from = bounds.StartDate
+	}
+	if !to.Valid || to.Time.After(bounds.EndDate.Time) {
+		to = bounds.EndDate
+	}
+	return from, to, nil
+}
+
+// wantsCSV reports whether the client prefers CSV over the default JSON representation.
+func wantsCSV(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		switch mt {
+		case "text/csv":
+			return true
+		case "application/json":
+			return false
+		}
+	}
+	return false
+}
+
+func writeCSV(w http.ResponseWriter, status int, header []string, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(status)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(header)
+	_ = cw.WriteAll(records)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mf-analytics-service/internal/db"
+)
+
+func TestClampToHistory(t *testing.T) {
+	date := func(s string) pgtype.Date {
+		if s == "" {
+			return pgtype.Date{}
+		}
+		d, err := time.Parse(dateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pgtype.Date{Time: d, Valid: true}
+	}
+	bounds := db.GetNavHistoryBoundsRow{StartDate: date("2020-01-01"), EndDate: date("2024-06-28"), NavPoints: 1000}
+
+	cases := []struct {
+		name, from, to   string
+		wantFrom, wantTo string
+		wantErrField     string
+	}{
+		{name: "open range", wantFrom: "2020-01-01", wantTo: "2024-06-28"},
+		{name: "inside", from: "2021-03-01", to: "2022-03-01", wantFrom: "2021-03-01", wantTo: "2022-03-01"},
+		{name: "overhanging", from: "2019-01-01", to: "2025-01-01", wantFrom: "2020-01-01", wantTo: "2024-06-28"},
+		{name: "from on last NAV", from: "2024-06-28", wantFrom: "2024-06-28", wantTo: "2024-06-28"},
+		{name: "from past last NAV", from: "2024-07-01", wantErrField: "from"},
+		{name: "to before first NAV", to: "2019-12-31", wantErrField: "to"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, err := clampToHistory(date(tc.from), date(tc.to), bounds)
+			if tc.wantErrField != "" {
+				var fe FieldError
+				if !errors.As(err, &fe) || fe.Field != tc.wantErrField {
+					t.Fatalf("err %v, want a FieldError on %s", err, tc.wantErrField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := from.Time.Format(dateLayout); got != tc.wantFrom {
+				t.Errorf("from %s, want %s", got, tc.wantFrom)
+			}
+			if got := to.Time.Format(dateLayout); got != tc.wantTo {
+				t.Errorf("to %s, want %s", got, tc.wantTo)
+			}
+		})
+	}
+}
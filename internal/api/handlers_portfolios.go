@@ -0,0 +1,513 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+const maxPortfolioHoldings = 20
+
+type PortfolioHolding struct {
+	FundCode string  `json:"fund_code"`
+	Weight   float64 `json:"weight"`
+}
+
+// Portfolio is both the request body for create/update and the response shape.
+type Portfolio struct {
+	ID        int64              `json:"id,omitempty"`
+	Name      string             `json:"name"`
+	Rebalance string             `json:"rebalance"`
+	Holdings  []PortfolioHolding `json:"holdings"`
+	CreatedAt string             `json:"created_at,omitempty"`
+	UpdatedAt string             `json:"updated_at,omitempty"`
+}
+
+func newPortfolio(p db.Portfolio, holdings []db.PortfolioHolding) Portfolio {
+	out := Portfolio{
+		ID:        p.ID,
+		Name:      p.Name,
+		Rebalance: p.Rebalance,
+		Holdings:  make([]PortfolioHolding, 0, len(holdings)),
+	}
+	for _, h := range holdings {
+		out.Holdings = append(out.Holdings, PortfolioHolding{
+			FundCode: h.SchemeCode,
+			Weight:   h.Weight.InexactFloat64(),
+		})
+	}
+	if p.CreatedAt.Valid {
+		out.CreatedAt = p.CreatedAt.Time.UTC().Format(timeRFC3339)
+	}
+	if p.UpdatedAt.Valid {
+		out.UpdatedAt = p.UpdatedAt.Time.UTC().Format(timeRFC3339)
+	}
+	return out
+}
+
+// PortfoliosListResponse is the body of GET /portfolios.
+type PortfoliosListResponse struct {
+	Portfolios []Portfolio `json:"portfolios"`
+}
+
+func (s *Server) handlePortfoliosList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := db.New(s.pool)
+		portfolios, err := q.ListPortfolios(r.Context())
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		ids := make([]int64, len(portfolios))
+		for i, p := range portfolios {
+			ids[i] = p.ID
+		}
+		holdings, err := q.ListPortfolioHoldings(r.Context(), ids)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		byPortfolio := map[int64][]db.PortfolioHolding{}
+		for _, h := range holdings {
+			byPortfolio[h.PortfolioID] = append(byPortfolio[h.PortfolioID], h)
+		}
+
+		out := PortfoliosListResponse{Portfolios: make([]Portfolio, 0, len(portfolios))}
+		for _, p := range portfolios {
+			out.Portfolios = append(out.Portfolios, newPortfolio(p, byPortfolio[p.ID]))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+func (s *Server) handlePortfolioGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, holdings, ok := s.lookupPortfolio(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, newPortfolio(p, holdings))
+	}
+}
+
+func (s *Server) handlePortfolioCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodePortfolio(w, r)
+		if !ok {
+			return
+		}
+		p, holdings, err := s.savePortfolio(r.Context(), 0, req)
+		if err != nil {
+			s.writePortfolioSaveError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newPortfolio(p, holdings))
+	}
+}
+
+func (s *Server) handlePortfolioUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := portfolioID(w, r)
+		if !ok {
+			return
+		}
+		req, ok := decodePortfolio(w, r)
+		if !ok {
+			return
+		}
+		p, holdings, err := s.savePortfolio(r.Context(), id, req)
+		if err != nil {
+			s.writePortfolioSaveError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newPortfolio(p, holdings))
+	}
+}
+
+func (s *Server) handlePortfolioDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := portfolioID(w, r)
+		if !ok {
+			return
+		}
+		n, err := db.New(s.pool).DeletePortfolio(r.Context(), id)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		if n == 0 {
+			writeError(w, r, http.StatusNotFound, CodePortfolioNotFound, "portfolio not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PortfolioAnalyticsResponse is the body of GET /portfolios/{id}/analytics.
+type PortfolioAnalyticsResponse struct {
+	PortfolioID int64  `json:"portfolio_id"`
+	Name        string `json:"name"`
+	Rebalance   string `json:"rebalance"`
+	Window      string `json:"window"`
+
+	DataAvailability DataAvailability `json:"data_availability"`
+
+	RollingPeriodsAnalyzed int `json:"rolling_periods_analyzed"`
+
+	RollingReturns struct {
+		Min    *float64 `json:"min,omitempty"`
+		Max    *float64 `json:"max,omitempty"`
+		Median *float64 `json:"median,omitempty"`
+		P25    *float64 `json:"p25,omitempty"`
+		P75    *float64 `json:"p75,omitempty"`
+	} `json:"rolling_returns"`
+
+	MaxDrawdown  *float64 `json:"max_drawdown,omitempty"`
+	Volatility   *float64 `json:"volatility,omitempty"`
+	Sharpe       *float64 `json:"sharpe,omitempty"`
+	Consistency  *float64 `json:"consistency,omitempty"`
+	RiskFreeRate *float64 `json:"risk_free_rate,omitempty"`
+
+	CAGR struct {
+		Min    *float64 `json:"min,omitempty"`
+		Max    *float64 `json:"max,omitempty"`
+		Median *float64 `json:"median,omitempty"`
+	} `json:"cagr"`
+
+	ComputedAt string `json:"computed_at,omitempty"`
+}
+
+func (s *Server) handlePortfolioAnalytics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		window := strings.TrimSpace(r.URL.Query().Get("window"))
+		spec, err := analytics.ParseWindow(window)
+		if err != nil {
+			writeInvalidParam(w, r, "window", "window must be <n>M|<n>Y")
+			return
+		}
+		p, holdings, ok := s.lookupPortfolio(w, r)
+		if !ok {
+			return
+		}
+
+		a, err := analytics.ComputePortfolioWindow(
+			r.Context(), s.pool, "portfolio:"+strconv.FormatInt(p.ID, 10), portfolioHoldings(holdings), p.Rebalance, spec, s.riskFreePct,
+		)
+		if err != nil {
+			if errors.Is(err, analytics.ErrInsufficientHistory) {
+				writeError(
+					w,
+					r,
+					http.StatusNotFound,
+					CodeInsufficientHistory,
+					"not enough common nav history for the holdings",
+				)
+				return
+			}
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		out := PortfolioAnalyticsResponse{
+			PortfolioID: p.ID,
+			Name:        p.Name,
+			Rebalance:   p.Rebalance,
+			Window:      spec.Label,
+		}
+		out.DataAvailability = newDataAvailability(a.DataStartDate, a.DataEndDate, a.NavPoints)
+		if a.RollingPeriods.Valid {
+			out.RollingPeriodsAnalyzed = int(a.RollingPeriods.Int32)
+		}
+
+		out.RollingReturns.Min = numericPtr(a.RollingMin)
+		out.RollingReturns.Max = numericPtr(a.RollingMax)
+		out.RollingReturns.Median = numericPtr(a.RollingMedian)
+		out.RollingReturns.P25 = numericPtr(a.RollingP25)
+		out.RollingReturns.P75 = numericPtr(a.RollingP75)
+
+		out.MaxDrawdown = numericPtr(a.MaxDrawdown)
+		out.Volatility = numericPtr(a.Volatility)
+		out.Sharpe = numericPtr(a.Sharpe)
+		out.Consistency = numericPtr(a.Consistency)
+		out.RiskFreeRate = numericPtr(a.RiskFreeRate)
+
+		out.CAGR.Min = numericPtr(a.CagrMin)
+		out.CAGR.Max = numericPtr(a.CagrMax)
+		out.CAGR.Median = numericPtr(a.CagrMedian)
+
+		if a.ComputedAt.Valid {
+			out.ComputedAt = a.ComputedAt.Time.UTC().Format(timeRFC3339)
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+type PortfolioNAVPoint struct {
+	Date string  `json:"date"`
+	NAV  float64 `json:"nav"`
+}
+
+// PortfolioNAVResponse is the body of GET /portfolios/{id}/nav.
+type PortfolioNAVResponse struct {
+	PortfolioID int64               `json:"portfolio_id"`
+	Rebalance   string              `json:"rebalance"`
+	From        string              `json:"from,omitempty"`
+	To          string              `json:"to,omitempty"`
+	Step        string              `json:"step"`
+	Points      int                 `json:"points"`
+	Series      []PortfolioNAVPoint `json:"series"`
+}
+
+func (s *Server) handlePortfolioNav() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("from")))
+		if err != nil {
+			writeInvalidParam(w, r, "from", "from must be YYYY-MM-DD")
+			return
+		}
+		to, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("to")))
+		if err != nil {
+			writeInvalidParam(w, r, "to", "to must be YYYY-MM-DD")
+			return
+		}
+		step, err := parseFreq(strings.TrimSpace(r.URL.Query().Get("step")), freqDaily)
+		if err != nil {
+			writeInvalidParam(w, r, "step", "step "+err.Error())
+			return
+		}
+		p, holdings, ok := s.lookupPortfolio(w, r)
+		if !ok {
+			return
+		}
+
+		navs, err := analytics.PortfolioNAV(r.Context(), s.pool, portfolioHoldings(holdings), p.Rebalance)
+		if err != nil {
+			if errors.Is(err, analytics.ErrInsufficientHistory) {
+				writeError(
+					w,
+					r,
+					http.StatusNotFound,
+					CodeInsufficientHistory,
+					"not enough common nav history for the holdings",
+				)
+				return
+			}
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		var inRange []analytics.NAVPoint
+		for _, n := range navs {
+			if (from.Valid && n.Date.Before(from.Time)) || (to.Valid && n.Date.After(to.Time)) {
+				continue
+			}
+			inRange = append(inRange, n)
+		}
+		dates := make([]time.Time, len(inRange))
+		for i, n := range inRange {
+			dates[i] = n.Date
+		}
+
+		out := PortfolioNAVResponse{
+			PortfolioID: p.ID,
+			Rebalance:   p.Rebalance,
+			Step:        step,
+			Series:      []PortfolioNAVPoint{},
+		}
+		if from.Valid {
+			out.From = from.Time.Format(dateLayout)
+		}
+		if to.Valid {
+			out.To = to.Time.Format(dateLayout)
+		}
+		for _, i := range periodEnds(dates, step) {
+			out.Series = append(out.Series, PortfolioNAVPoint{
+				Date: inRange[i].Date.UTC().Format(dateLayout),
+				NAV:  round(inRange[i].NAV, 4),
+			})
+		}
+		out.Points = len(out.Series)
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// unknownFundError is returned by savePortfolio for a holding that isn't a tracked fund.
+type unknownFundError struct{ code string }
+
+func (e unknownFundError) Error() string { return "fund not found: " + e.code }
+
+func (s *Server) writePortfolioSaveError(w http.ResponseWriter, r *http.Request, err error) {
+	var unknown unknownFundError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, r, http.StatusNotFound, CodePortfolioNotFound, "portfolio not found")
+	case errors.As(err, &unknown):
+		writeError(w, r, http.StatusBadRequest, CodeUnknownFund, unknown.Error(), FieldError{
+			Field:   "holdings[].fund_code",
+			Message: unknown.Error(),
+		})
+	default:
+		s.writeInternalError(w, r, err)
+	}
+}
+
+// savePortfolio creates (id 0) or replaces a portfolio and its holdings in one transaction.
+func (s *Server) savePortfolio(ctx context.Context, id int64, req Portfolio) (db.Portfolio, []db.PortfolioHolding, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return db.Portfolio{}, nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := db.New(tx)
+	params := db.InsertPortfolioHoldingsParams{
+		SchemeCodes: make([]string, 0, len(req.Holdings)),
+		Weights:     make([]float64, 0, len(req.Holdings)),
+	}
+	for _, h := range req.Holdings {
+		if _, err := q.GetFund(ctx, h.FundCode); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return db.Portfolio{}, nil, unknownFundError{code: h.FundCode}
+			}
+			return db.Portfolio{}, nil, err
+		}
+		params.SchemeCodes = append(params.SchemeCodes, h.FundCode)
+		params.Weights = append(params.Weights, h.Weight)
+	}
+
+	var p db.Portfolio
+	if id == 0 {
+		p, err = q.CreatePortfolio(ctx, db.CreatePortfolioParams{Name: req.Name, Rebalance: req.Rebalance})
+	} else {
+		p, err = q.UpdatePortfolio(ctx, db.UpdatePortfolioParams{ID: id, Name: req.Name, Rebalance: req.Rebalance})
+	}
+	if err != nil {
+		return db.Portfolio{}, nil, err
+	}
+	params.PortfolioID = p.ID
+
+	if err := q.DeletePortfolioHoldings(ctx, p.ID); err != nil {
+		return db.Portfolio{}, nil, err
+	}
+	if err := q.InsertPortfolioHoldings(ctx, params); err != nil {
+		return db.Portfolio{}, nil, err
+	}
+	holdings, err := q.ListPortfolioHoldings(ctx, []int64{p.ID})
+	if err != nil {
+		return db.Portfolio{}, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.Portfolio{}, nil, err
+	}
+	return p, holdings, nil
+}
+
+// decodePortfolio reads and validates a create/update body, writing 400 itself.
+func decodePortfolio(w http.ResponseWriter, r *http.Request) (Portfolio, bool) {
+	var req Portfolio
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "invalid JSON body")
+		return req, false
+	}
+	if err := normalizePortfolio(&req); err != nil {
+		writeInvalid(w, r, CodeInvalidBody, err)
+		return req, false
+	}
+	return req, true
+}
+
+// normalizePortfolio trims the body and checks it. Weights are percentages that must sum to 100;
+// rebalance defaults to none.
+func normalizePortfolio(req *Portfolio) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return FieldError{Field: "name", Message: "name is required"}
+	}
+	req.Rebalance = strings.TrimSpace(req.Rebalance)
+	switch req.Rebalance {
+	case "":
+		req.Rebalance = analytics.RebalanceNone
+	case analytics.RebalanceNone, analytics.RebalanceMonthly, analytics.RebalanceQuarterly, analytics.RebalanceYearly:
+	default:
+		return FieldError{Field: "rebalance", Message: "rebalance must be none|monthly|quarterly|yearly"}
+	}
+	if len(req.Holdings) == 0 || len(req.Holdings) > maxPortfolioHoldings {
+		return FieldError{Field: "holdings", Message: fmt.Sprintf("holdings must list 1 to %d funds", maxPortfolioHoldings)}
+	}
+
+	seen := map[string]bool{}
+	var total float64
+	for i := range req.Holdings {
+		h := &req.Holdings[i]
+		h.FundCode = strings.TrimSpace(h.FundCode)
+		switch {
+		case h.FundCode == "":
+			return FieldError{Field: "holdings[].fund_code", Message: "holdings[].fund_code is required"}
+		case seen[h.FundCode]:
+			return FieldError{Field: "holdings[].fund_code", Message: "duplicate holding " + h.FundCode}
+		case !(h.Weight > 0 && h.Weight <= 100):
+			return FieldError{Field: "holdings[].weight", Message: "holdings[].weight must be in (0, 100]"}
+		}
+		seen[h.FundCode] = true
+		total += h.Weight
+	}
+	if math.Abs(total-100) > 0.01 {
+		return FieldError{Field: "holdings[].weight", Message: fmt.Sprintf("holding weights must sum to 100, got %g", total)}
+	}
+	return nil
+}
+
+func portfolioID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeInvalidParam(w, r, "id", "invalid portfolio id")
+		return 0, false
+	}
+	return id, true
+}
+
+// lookupPortfolio loads the {id} portfolio and its holdings, writing 400/404/500 itself.
+func (s *Server) lookupPortfolio(w http.ResponseWriter, r *http.Request) (db.Portfolio, []db.PortfolioHolding, bool) {
+	id, ok := portfolioID(w, r)
+	if !ok {
+		return db.Portfolio{}, nil, false
+	}
+	q := db.New(s.pool)
+	p, err := q.GetPortfolio(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, r, http.StatusNotFound, CodePortfolioNotFound, "portfolio not found")
+			return db.Portfolio{}, nil, false
+		}
+		s.writeInternalError(w, r, err)
+		return db.Portfolio{}, nil, false
+	}
+	holdings, err := q.ListPortfolioHoldings(r.Context(), []int64{id})
+	if err != nil {
+		s.writeInternalError(w, r, err)
+		return db.Portfolio{}, nil, false
+	}
+	return p, holdings, true
+}
+
+func portfolioHoldings(holdings []db.PortfolioHolding) []analytics.Holding {
+	out := make([]analytics.Holding, len(holdings))
+	for i, h := range holdings {
+		out[i] = analytics.Holding{SchemeCode: h.SchemeCode, Weight: h.Weight.InexactFloat64()}
+	}
+	return out
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"mf-analytics-service/internal/analytics"
+)
+
+// defaultProjectionSeed keeps repeated requests reproducible unless a seed is given.
+const defaultProjectionSeed = 1
+
+type ProjectionPoint struct {
+	Month    int     `json:"month"`
+	Invested float64 `json:"invested"`
+	P10      float64 `json:"p10"`
+	P50      float64 `json:"p50"`
+	P90      float64 `json:"p90"`
+}
+
+type Projection struct {
+	Lumpsum     float64           `json:"lumpsum"`
+	SIP         float64           `json:"sip"`
+	Months      int               `json:"months"`
+	Target      *float64          `json:"target,omitempty"`
+	Simulations int               `json:"simulations"`
+	Seed        int64             `json:"seed"`
+	Samples     int               `json:"samples"`
+	SampleFrom  string            `json:"sample_from"`
+	SampleTo    string            `json:"sample_to"`
+	Invested    float64           `json:"invested"`
+	Final       ProjectionPoint   `json:"final"`
+	ProbTarget  *float64          `json:"prob_target,omitempty"`
+	Path        []ProjectionPoint `json:"path"`
+}
+
+// FundProjectionResponse is the body of GET /funds/{code}/projection.
+type FundProjectionResponse struct {
+	FundCode string `json:"fund_code"`
+	FundName string `json:"fund_name"`
+	Projection
+}
+
+func (s *Server) handleFundProjection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		p, ok := parseProjectionParams(w, r)
+		if !ok {
+			return
+		}
+		f, ok := s.lookupFund(w, r, code)
+		if !ok {
+			return
+		}
+
+		res, err := analytics.ProjectFund(r.Context(), s.pool, code, p)
+		if err != nil {
+			s.writeProjectionError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, FundProjectionResponse{FundCode: code, FundName: f.SchemeName, Projection: projectionOut(p, res)})
+	}
+}
+
+// PortfolioProjectionResponse is the body of GET /portfolios/{id}/projection.
+type PortfolioProjectionResponse struct {
+	PortfolioID int64  `json:"portfolio_id"`
+	Name        string `json:"name"`
+	Rebalance   string `json:"rebalance"`
+	Projection
+}
+
+func (s *Server) handlePortfolioProjection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := parseProjectionParams(w, r)
+		if !ok {
+			return
+		}
+		pf, holdings, ok := s.lookupPortfolio(w, r)
+		if !ok {
+			return
+		}
+
+		res, err := analytics.ProjectPortfolio(r.Context(), s.pool, portfolioHoldings(holdings), pf.Rebalance, p)
+		if err != nil {
+			s.writeProjectionError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, PortfolioProjectionResponse{
+			PortfolioID: pf.ID,
+			Name:        pf.Name,
+			Rebalance:   pf.Rebalance,
+			Projection:  projectionOut(p, res),
+		})
+	}
+}
+
+// parseProjectionParams reads sip and/or lumpsum (at least one), months (default 120), target,
+// simulations and seed; it writes the 400 itself.
+func parseProjectionParams(w http.ResponseWriter, r *http.Request) (analytics.ProjectionParams, bool) {
+	p := analytics.ProjectionParams{
+		Months:      120,
+		Simulations: analytics.DefaultProjectionSimulations,
+		Seed:        defaultProjectionSeed,
+	}
+	amounts := []struct {
+		name string
+		dst  *float64
+	}{{"sip", &p.SIP}, {"lumpsum", &p.Lumpsum}, {"target", &p.Target}}
+	for _, a := range amounts {
+		v := strings.TrimSpace(r.URL.Query().Get(a.name))
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || math.IsInf(f, 0) {
+			writeInvalidParam(w, r, a.name, a.name+" must be a positive number")
+			return p, false
+		}
+		*a.dst = f
+	}
+	if p.SIP == 0 && p.Lumpsum == 0 {
+		writeInvalidParam(w, r, "sip", "sip or lumpsum is required")
+		return p, false
+	}
+
+	if v := strings.TrimSpace(r.URL.Query().Get("months")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > analytics.MaxProjectionMonths {
+			writeInvalidParam(
+				w,
+				r,
+				"months",
+				fmt.Sprintf("months must be between 1 and %d", analytics.MaxProjectionMonths),
+			)
+			return p, false
+		}
+		p.Months = n
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("simulations")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 100 || n > analytics.MaxProjectionSimulations {
+			writeInvalidParam(
+				w,
+				r,
+				"simulations",
+				fmt.Sprintf("simulations must be between 100 and %d", analytics.MaxProjectionSimulations),
+			)
+			return p, false
+		}
+		p.Simulations = n
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("seed")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeInvalidParam(w, r, "seed", "seed must be an integer")
+			return p, false
+		}
+		p.Seed = n
+	}
+	return p, true
+}
+
+func (s *Server) writeProjectionError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, analytics.ErrInsufficientHistory) {
+		writeError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			CodeInsufficientHistory,
+			"not enough nav history to resample monthly returns",
+		)
+		return
+	}
+	s.writeInternalError(w, r, err)
+}
+
+func projectionOut(p analytics.ProjectionParams, res analytics.ProjectionResult) Projection {
+	out := Projection{
+		Lumpsum:     p.Lumpsum,
+		SIP:         p.SIP,
+		Months:      p.Months,
+		Simulations: p.Simulations,
+		Seed:        p.Seed,
+		Samples:     res.Samples,
+		SampleFrom:  res.SampleStart.Format(dateLayout),
+		SampleTo:    res.SampleEnd.Format(dateLayout),
+		Invested:    round(res.Invested, 2),
+		ProbTarget:  roundPtr(res.ProbTarget, 4),
+		Path:        make([]ProjectionPoint, len(res.Path)),
+	}
+	if p.Target > 0 {
+		out.Target = &p.Target
+	}
+	for i, pt := range res.Path {
+		out.Path[i] = ProjectionPoint{
+			Month:    pt.Month,
+			Invested: round(pt.Invested, 2),
+			P10:      round(pt.P10, 2),
+			P50:      round(pt.P50, 2),
+			P90:      round(pt.P90, 2),
+		}
+	}
+	out.Final = out.Path[len(out.Path)-1]
+	return out
+}
@@ -2,136 +2,305 @@ package api
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"mf-analytics-service/internal/analytics"
 	"mf-analytics-service/internal/db"
 )
 
-func (s *Server) handleFundsRank() http.HandlerFunc {
-	type fund struct {
-		Rank         int      `json:"rank"`
-		FundCode     string   `json:"fund_code"`
-		FundName     string   `json:"fund_name"`
-		AMC          string   `json:"amc"`
-		MedianReturn *float64 `json:"median_return,omitempty"`
-		MaxDrawdown  *float64 `json:"max_drawdown,omitempty"`
-		CurrentNAV   *float64 `json:"current_nav,omitempty"`
-		LastUpdated  string   `json:"last_updated,omitempty"`
-	}
+type RankContribution struct {
+	Metric       string   `json:"metric"`
+	Value        *float64 `json:"value"`
+	Weight       float64  `json:"weight"`
+	Score        *float64 `json:"score"`
+	Contribution *float64 `json:"contribution"`
+}
 
-	type resp struct {
-		Category   string `json:"category"`
-		Window     string `json:"window"`
-		SortedBy   string `json:"sorted_by"`
-		TotalFunds int64  `json:"total_funds"`
-		Showing    int    `json:"showing"`
-		Funds      []fund `json:"funds"`
-	}
+type RankWindowResult struct {
+	SortValue          *float64           `json:"sort_value,omitempty"`
+	Score              *float64           `json:"score,omitempty"`
+	Contributions      []RankContribution `json:"contributions,omitempty"`
+	CategoryPercentile *float64           `json:"category_percentile,omitempty"`
+	Quartile           int                `json:"quartile,omitempty"`
+}
 
+type RankedFund struct {
+	Rank         int    `json:"rank"`
+	PreviousRank *int   `json:"previous_rank,omitempty"`
+	RankChange   *int   `json:"rank_change,omitempty"` // positive = moved up
+	FundCode     string `json:"fund_code"`
+	FundName     string `json:"fund_name"`
+	AMC          string `json:"amc"`
+	Category     string `json:"category"`
+
+	// Metric fields describe the first requested window the fund has analytics for.
+	MedianReturn *float64 `json:"median_return,omitempty"`
+	MaxDrawdown  *float64 `json:"max_drawdown,omitempty"`
+	Volatility   *float64 `json:"volatility,omitempty"`
+	Sharpe       *float64 `json:"sharpe,omitempty"`
+	Consistency  *float64 `json:"consistency,omitempty"`
+
+	RankWindowResult
+
+	// Windows and the mean category percentile across them are set for multi-window ranks.
+	MeanCategoryPercentile *float64                    `json:"mean_category_percentile,omitempty"`
+	Windows                map[string]RankWindowResult `json:"windows,omitempty"`
+
+	CurrentNAV  *float64 `json:"current_nav,omitempty"`
+	LastUpdated string   `json:"last_updated,omitempty"`
+}
+
+// FundsRankResponse is the body of GET /funds/rank.
+type FundsRankResponse struct {
+	Category    string                `json:"category,omitempty"`
+	Categories  []string              `json:"categories,omitempty"`
+	AMCs        []string              `json:"amcs,omitempty"`
+	AsOf        string                `json:"as_of,omitempty"`
+	Window      string                `json:"window"`
+	Windows     []string              `json:"windows,omitempty"`
+	MaxQuartile int                   `json:"max_quartile,omitempty"`
+	SortedBy    string                `json:"sorted_by"`
+	Order       string                `json:"order"`
+	Scoring     analytics.ScoreMethod `json:"scoring,omitempty"`
+	Weights     map[string]float64    `json:"weights,omitempty"`
+	TotalFunds  int64                 `json:"total_funds"`
+	Showing     int                   `json:"showing"`
+	Funds       []RankedFund          `json:"funds"`
+}
+
+func (s *Server) handleFundsRank() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		category := strings.TrimSpace(r.URL.Query().Get("category"))
-		window := strings.TrimSpace(r.URL.Query().Get("window"))
-		sortBy := strings.TrimSpace(r.URL.Query().Get("sort_by"))
+		// category and amc may be repeated; both are optional.
+		categories := queryList(r, "category")
+		amcs := queryList(r, "amc")
+		windows := splitParam(r.URL.Query().Get("window"))
 		limitStr := strings.TrimSpace(r.URL.Query().Get("limit"))
 
-		if category == "" {
-			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "category is required"})
+		if len(windows) == 0 {
+			writeInvalidParam(w, r, "window", "window must be one or more of "+s.windowChoices())
 			return
 		}
-		if !isValidWindow(window) {
-			writeJSON(
-				w,
-				http.StatusBadRequest,
-				map[string]any{"error": "window must be one of 1Y|3Y|5Y|10Y"},
-			)
-			return
+		for _, window := range windows {
+			if !s.isPrecomputedWindow(window) {
+				writeInvalidParam(w, r, "window", "window must be one or more of "+s.windowChoices())
+				return
+			}
+		}
+
+		maxQuartile := 0
+		if v := strings.TrimSpace(r.URL.Query().Get("max_quartile")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 4 {
+				writeInvalidParam(w, r, "max_quartile", "max_quartile must be 1..4")
+				return
+			}
+			maxQuartile = n
 		}
-		if sortBy == "" {
-			sortBy = "median_return"
+
+		spec, err := parseRankSpec(r)
+		if err != nil {
+			writeInvalid(w, r, CodeInvalidParameter, err)
+			return
 		}
-		if sortBy != "median_return" && sortBy != "max_drawdown" {
-			writeJSON(
-				w,
-				http.StatusBadRequest,
-				map[string]any{"error": "sort_by must be one of median_return|max_drawdown"},
-			)
+		asOf, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("as_of")))
+		if err != nil {
+			writeInvalidParam(w, r, "as_of", "as_of must be YYYY-MM-DD")
 			return
 		}
 
 		limit, err := parseLimit(limitStr, 5)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			writeInvalidParam(w, r, "limit", err.Error())
 			return
 		}
 
 		q := db.New(s.pool)
-		total, err := q.CountFundsByCategory(r.Context(), category)
+		// Rankings depend on every fund's analytics in the windows, so they are versioned by the
+		// newest computation among them; checked first to skip ranking when nothing changed.
+		version, err := q.GetFundAnalyticsVersion(r.Context(), windows)
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			s.writeInternalError(w, r, err)
 			return
 		}
-
-		out := resp{
-			Category:   category,
-			Window:     window,
-			SortedBy:   sortBy,
-			TotalFunds: total,
+		if s.notModified(w, r, newValidators(
+			version.ComputedAt.Time,
+			timestampPart(version.ComputedAt.Time),
+			version.DataEndDate.Time.Format(dateLayout),
+			strconv.FormatInt(version.AnalyticsRows, 10),
+		)) {
+			return
 		}
 
-		if sortBy == "max_drawdown" {
-			rows, err := q.RankFundsByMaxDrawdown(r.Context(), db.RankFundsByMaxDrawdownParams{
-				Category: category,
-				Window:   window,
-				Limit:    limit,
+		total, err := q.CountFundsFiltered(r.Context(), db.CountFundsFilteredParams{
+			Categories: categories,
+			Amcs:       amcs,
+		})
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		// as_of ranks each fund's latest snapshot on or before that date; rank movement is only
+		// reported for the current computation.
+		var rows []db.ListRankCandidatesRow
+		var prevRows []db.ListPreviousRankCandidatesRow
+		if asOf.Valid {
+			snapshots, err := q.ListRankCandidatesAsOf(r.Context(), db.ListRankCandidatesAsOfParams{
+				Windows:    windows,
+				AsOf:       asOf,
+				Categories: categories,
+				Amcs:       amcs,
 			})
 			if err != nil {
-				writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+				s.writeInternalError(w, r, err)
 				return
 			}
-			out.Funds = make([]fund, 0, len(rows))
-			for i, row := range rows {
-				f := fund{
-					Rank:         i + 1,
-					FundCode:     row.SchemeCode,
-					FundName:     row.SchemeName,
-					AMC:          row.Amc,
-					MedianReturn: numericPtr(row.RollingMedian),
-					MaxDrawdown:  numericPtr(row.MaxDrawdown),
-					CurrentNAV:   decimalPtr(row.CurrentNav),
-				}
-				if row.LastUpdated.Valid {
-					f.LastUpdated = row.LastUpdated.Time.UTC().Format("2006-01-02")
-				}
-				out.Funds = append(out.Funds, f)
+			rows = make([]db.ListRankCandidatesRow, 0, len(snapshots))
+			for _, row := range snapshots {
+				rows = append(rows, db.ListRankCandidatesRow(row))
 			}
 		} else {
-			rows, err := q.RankFundsByMedianReturn(r.Context(), db.RankFundsByMedianReturnParams{
-				Category: category,
-				Window:   window,
-				Limit:    limit,
+			rows, err = q.ListRankCandidates(r.Context(), db.ListRankCandidatesParams{
+				Windows:    windows,
+				Categories: categories,
+				Amcs:       amcs,
 			})
 			if err != nil {
-				writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+				s.writeInternalError(w, r, err)
 				return
 			}
-			out.Funds = make([]fund, 0, len(rows))
-			for i, row := range rows {
-				f := fund{
-					Rank:         i + 1,
-					FundCode:     row.SchemeCode,
-					FundName:     row.SchemeName,
-					AMC:          row.Amc,
-					MedianReturn: numericPtr(row.RollingMedian),
-					MaxDrawdown:  numericPtr(row.MaxDrawdown),
-					CurrentNAV:   decimalPtr(row.CurrentNav),
+			prevRows, err = q.ListPreviousRankCandidates(r.Context(), db.ListPreviousRankCandidatesParams{
+				Windows:    windows,
+				Categories: categories,
+				Amcs:       amcs,
+			})
+			if err != nil {
+				s.writeInternalError(w, r, err)
+				return
+			}
+		}
+
+		cands := make([]rankCandidate, 0, len(rows))
+		byKey := make(map[string]db.ListRankCandidatesRow, len(rows))
+		for _, row := range rows {
+			cands = append(cands, currentCandidate(row))
+			byKey[row.SchemeCode+"|"+row.Window] = row
+		}
+		prevCands := make([]rankCandidate, 0, len(prevRows))
+		for _, row := range prevRows {
+			prevCands = append(prevCands, previousCandidate(row))
+		}
+
+		ranked := spec.rank(cands, windows, maxQuartile)
+		previousRank := map[string]int{}
+		if len(prevCands) > 0 {
+			for i, f := range spec.rank(prevCands, windows, maxQuartile) {
+				previousRank[f.code] = i + 1
+			}
+		}
+		if int(limit) < len(ranked) {
+			ranked = ranked[:limit]
+		}
+
+		out := FundsRankResponse{
+			Window:      strings.Join(windows, ","),
+			MaxQuartile: maxQuartile,
+			SortedBy:    spec.sortBy,
+			Order:       spec.order,
+			Scoring:     spec.method,
+			TotalFunds:  total,
+			Funds:       make([]RankedFund, 0, len(ranked)),
+		}
+		if asOf.Valid {
+			out.AsOf = asOf.Time.Format(dateLayout)
+		}
+		if len(categories) == 1 {
+			out.Category = categories[0]
+		} else {
+			out.Categories = categories
+		}
+		out.AMCs = amcs
+		if len(windows) > 1 {
+			out.Windows = windows
+		}
+		if len(spec.weights) > 0 {
+			out.Weights = make(map[string]float64, len(spec.weights))
+			for _, wt := range spec.weights {
+				out.Weights[wt.Metric] = round(wt.Weight, 4)
+			}
+		}
+
+		describe := func(rf rankedFund, window string) RankWindowResult {
+			wr := rf.perWindow[window]
+			res := RankWindowResult{
+				CategoryPercentile: roundPtr(wr.percentile, 1),
+				Quartile:           wr.quartile,
+			}
+			if len(spec.weights) == 0 {
+				res.SortValue = roundPtr(wr.key, 2)
+				return res
+			}
+			res.Score = roundPtr(wr.key, 4)
+			res.Contributions = make([]RankContribution, 0, len(spec.weights))
+			row := byKey[rf.code+"|"+window]
+			values := currentCandidate(row).values
+			for m, wt := range spec.weights {
+				c := wr.contributions[m]
+				res.Contributions = append(res.Contributions, RankContribution{
+					Metric:       wt.Metric,
+					Value:        roundPtr(values[wt.Metric], 2),
+					Weight:       round(wt.Weight, 4),
+					Score:        roundPtr(c/wt.Weight, 4),
+					Contribution: roundPtr(c, 4),
+				})
+			}
+			return res
+		}
+
+		for i, rf := range ranked {
+			var row db.ListRankCandidatesRow
+			for _, window := range windows {
+				if v, ok := byKey[rf.code+"|"+window]; ok {
+					row = v
+					break
 				}
-				if row.LastUpdated.Valid {
-					f.LastUpdated = row.LastUpdated.Time.UTC().Format("2006-01-02")
+			}
+			f := RankedFund{
+				Rank:         i + 1,
+				FundCode:     row.SchemeCode,
+				FundName:     row.SchemeName,
+				AMC:          row.Amc,
+				Category:     row.Category,
+				MedianReturn: numericPtr(row.RollingMedian),
+				MaxDrawdown:  numericPtr(row.MaxDrawdown),
+				Volatility:   numericPtr(row.Volatility),
+				Sharpe:       numericPtr(row.Sharpe),
+				Consistency:  numericPtr(row.Consistency),
+				CurrentNAV:   numericPtr(row.CurrentNav),
+			}
+			if prev, ok := previousRank[rf.code]; ok {
+				change := prev - f.Rank
+				f.PreviousRank = &prev
+				f.RankChange = &change
+			}
+			if len(windows) == 1 {
+				f.RankWindowResult = describe(rf, windows[0])
+			} else {
+				f.MeanCategoryPercentile = roundPtr(rf.key, 1)
+				f.Windows = make(map[string]RankWindowResult, len(windows))
+				for _, window := range windows {
+					if _, ok := rf.perWindow[window]; ok {
+						f.Windows[window] = describe(rf, window)
+					}
 				}
-				out.Funds = append(out.Funds, f)
 			}
+			if row.LastUpdated.Valid {
+				f.LastUpdated = row.LastUpdated.Time.UTC().Format("2006-01-02")
+			}
+			out.Funds = append(out.Funds, f)
 		}
 
 		out.Showing = len(out.Funds)
@@ -139,14 +308,53 @@ func (s *Server) handleFundsRank() http.HandlerFunc {
 	}
 }
 
-func decimalPtr(d any) *float64 {
-	switch v := d.(type) {
-	case interface{ InexactFloat64() float64 }:
-		f := v.InexactFloat64()
-		return &f
+// queryList returns the non-empty, trimmed values of a repeatable query parameter, or nil.
+func queryList(r *http.Request, name string) []string {
+	var out []string
+	for _, v := range r.URL.Query()[name] {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// splitParam splits a comma-separated parameter such as window=1Y,3Y,5Y, dropping duplicates.
+func splitParam(v string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" && !seen[part] {
+			seen[part] = true
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// defaultRankOrder keeps the historical defaults: most negative drawdown first, lowest volatility
+// first, everything else highest first.
+func defaultRankOrder(metric string) string {
+	switch metric {
+	case "max_drawdown", "volatility":
+		return "asc"
 	default:
-		return nil
+		return "desc"
+	}
+}
+
+func rankMetricChoices() string {
+	return strings.Join(rankMetricNames(), "|")
+}
+
+// rankMetricNames lists analytics.RankMetrics in a stable order.
+func rankMetricNames() []string {
+	names := make([]string, 0, len(analytics.RankMetrics))
+	for name := range analytics.RankMetrics {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
 
 func numericPtr(n pgtype.Numeric) *float64 {
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mf-analytics-service/internal/db"
+)
+
+type RankHistoryPoint struct {
+	Date               string   `json:"date"`
+	Rank               int      `json:"rank"`
+	OutOf              int      `json:"out_of"`
+	SortValue          *float64 `json:"sort_value,omitempty"`
+	Score              *float64 `json:"score,omitempty"`
+	CategoryPercentile *float64 `json:"category_percentile"`
+	Quartile           int      `json:"quartile,omitempty"`
+}
+
+// RankHistoryResponse is the body of GET /funds/{code}/rank-history.
+type RankHistoryResponse struct {
+	FundCode string             `json:"fund_code"`
+	FundName string             `json:"fund_name"`
+	Category string             `json:"category"`
+	Window   string             `json:"window"`
+	SortedBy string             `json:"sorted_by"`
+	Order    string             `json:"order"`
+	From     string             `json:"from,omitempty"`
+	To       string             `json:"to,omitempty"`
+	Step     string             `json:"step"`
+	Points   int                `json:"points"`
+	History  []RankHistoryPoint `json:"history"`
+}
+
+func (s *Server) handleFundRankHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		window := strings.TrimSpace(r.URL.Query().Get("window"))
+		if !s.isPrecomputedWindow(window) {
+			writeInvalidParam(w, r, "window", "window must be one of "+s.windowChoices())
+			return
+		}
+		spec, err := parseRankSpec(r)
+		if err != nil {
+			writeInvalid(w, r, CodeInvalidParameter, err)
+			return
+		}
+		from, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("from")))
+		if err != nil {
+			writeInvalidParam(w, r, "from", "from must be YYYY-MM-DD")
+			return
+		}
+		to, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("to")))
+		if err != nil {
+			writeInvalidParam(w, r, "to", "to must be YYYY-MM-DD")
+			return
+		}
+		step, err := parseFreq(strings.TrimSpace(r.URL.Query().Get("step")), freqMonthly)
+		if err != nil {
+			writeInvalidParam(w, r, "step", "step "+err.Error())
+			return
+		}
+
+		f, ok := s.lookupFund(w, r, code)
+		if !ok {
+			return
+		}
+
+		q := db.New(s.pool)
+
+		snapshotDates, err := q.ListFundAnalyticsHistoryDates(r.Context(), db.ListFundAnalyticsHistoryDatesParams{
+			SchemeCode: code,
+			Window:     window,
+			FromDate:   from,
+			ToDate:     to,
+		})
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		// Rank at the last snapshot of each step period; the fund's category peers are taken at
+		// their own latest snapshot on or before that date.
+		dates := make([]time.Time, len(snapshotDates))
+		for i, d := range snapshotDates {
+			dates[i] = d.Time
+		}
+		asOf := []pgtype.Date{}
+		for _, i := range periodEnds(dates, step) {
+			asOf = append(asOf, snapshotDates[i])
+		}
+
+		out := RankHistoryResponse{
+			FundCode: code,
+			FundName: f.SchemeName,
+			Category: f.Category,
+			Window:   window,
+			SortedBy: spec.sortBy,
+			Order:    spec.order,
+			Step:     step,
+			History:  []RankHistoryPoint{},
+		}
+		if from.Valid {
+			out.From = from.Time.Format(dateLayout)
+		}
+		if to.Valid {
+			out.To = to.Time.Format(dateLayout)
+		}
+
+		if len(asOf) > 0 {
+			rows, err := q.ListCategoryHistorySnapshots(r.Context(), db.ListCategoryHistorySnapshotsParams{
+				AsOfDates:  asOf,
+				SchemeCode: code,
+				Window:     window,
+			})
+			if err != nil {
+				s.writeInternalError(w, r, err)
+				return
+			}
+
+			byDate := map[time.Time][]rankCandidate{}
+			for _, row := range rows {
+				byDate[row.SnapshotDate.Time] = append(byDate[row.SnapshotDate.Time], historyCandidate(row))
+			}
+			for _, d := range asOf {
+				ranked := spec.rank(byDate[d.Time], []string{window}, 0)
+				for i, rf := range ranked {
+					if rf.code != code {
+						continue
+					}
+					wr := rf.perWindow[window]
+					p := RankHistoryPoint{
+						Date:               d.Time.UTC().Format(dateLayout),
+						Rank:               i + 1,
+						OutOf:              len(ranked),
+						CategoryPercentile: roundPtr(wr.percentile, 1),
+						Quartile:           wr.quartile,
+					}
+					if len(spec.weights) > 0 {
+						p.Score = roundPtr(wr.key, 4)
+					} else {
+						p.SortValue = roundPtr(wr.key, 2)
+					}
+					out.History = append(out.History, p)
+					break
+				}
+			}
+		}
+		out.Points = len(out.History)
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+func historyCandidate(row db.ListCategoryHistorySnapshotsRow) rankCandidate {
+	return rankCandidate{
+		code:     row.SchemeCode,
+		category: row.Category,
+		window:   row.Window,
+		values:   candidateValues(metricColumns(row)),
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+type TrailingReturn struct {
+	Period           string   `json:"period"`
+	StartDate        string   `json:"start_date,omitempty"`
+	EndDate          string   `json:"end_date,omitempty"`
+	AbsoluteReturn   *float64 `json:"absolute_return,omitempty"`
+	AnnualizedReturn *float64 `json:"annualized_return,omitempty"`
+}
+
+type CalendarYearReturn struct {
+	Year      int32   `json:"year"`
+	StartDate string  `json:"start_date"`
+	EndDate   string  `json:"end_date"`
+	Return    float64 `json:"return"`
+	Complete  bool    `json:"complete"`
+}
+
+// FundReturnsResponse is the body of GET /funds/{code}/returns.
+type FundReturnsResponse struct {
+	FundCode      string               `json:"fund_code"`
+	FundName      string               `json:"fund_name"`
+	AsOf          string               `json:"as_of,omitempty"`
+	Trailing      []TrailingReturn     `json:"trailing"`
+	CalendarYears []CalendarYearReturn `json:"calendar_years"`
+	ComputedAt    string               `json:"computed_at,omitempty"`
+}
+
+func (s *Server) handleFundReturns() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		if code == "" {
+			writeInvalidParam(w, r, "code", "missing fund code")
+			return
+		}
+
+		f, ok := s.lookupFund(w, r, code)
+		if !ok {
+			return
+		}
+
+		q := db.New(s.pool)
+
+		trailingRows, err := q.ListFundTrailingReturns(r.Context(), code)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		if len(trailingRows) == 0 {
+			writeError(w, r, http.StatusNotFound, CodeAnalyticsNotReady, "returns not computed yet")
+			return
+		}
+		yearRows, err := q.ListFundCalendarReturns(r.Context(), code)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		out := FundReturnsResponse{
+			FundCode:      code,
+			FundName:      f.SchemeName,
+			Trailing:      make([]TrailingReturn, 0, len(trailingRows)),
+			CalendarYears: make([]CalendarYearReturn, 0, len(yearRows)),
+		}
+
+		byPeriod := make(map[string]db.FundTrailingReturn, len(trailingRows))
+		for _, row := range trailingRows {
+			byPeriod[row.Period] = row
+		}
+		// Present periods in their natural order rather than the table's.
+		for _, spec := range analytics.TrailingPeriods {
+			row, ok := byPeriod[spec.Label]
+			if !ok {
+				continue
+			}
+			t := TrailingReturn{
+				Period:           row.Period,
+				AbsoluteReturn:   numericPtr(row.AbsoluteReturn),
+				AnnualizedReturn: numericPtr(row.AnnualizedReturn),
+			}
+			if row.StartDate.Valid {
+				t.StartDate = row.StartDate.Time.UTC().Format(dateLayout)
+			}
+			if row.EndDate.Valid {
+				t.EndDate = row.EndDate.Time.UTC().Format(dateLayout)
+				out.AsOf = t.EndDate
+			}
+			if row.ComputedAt.Valid {
+				out.ComputedAt = row.ComputedAt.Time.UTC().Format(timeRFC3339)
+			}
+			out.Trailing = append(out.Trailing, t)
+		}
+
+		for _, row := range yearRows {
+			out.CalendarYears = append(out.CalendarYears, CalendarYearReturn{
+				Year:      row.Year,
+				StartDate: row.StartDate.Time.UTC().Format(dateLayout),
+				EndDate:   row.EndDate.Time.UTC().Format(dateLayout),
+				Return:    row.ReturnPct.InexactFloat64(),
+				Complete:  row.Complete,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mf-analytics-service/internal/db"
+)
+
+type RollingReturnPoint struct {
+	EndDate   string   `json:"end_date"`
+	StartDate string   `json:"start_date"`
+	Return    float64  `json:"return"`
+	CAGR      *float64 `json:"cagr,omitempty"`
+}
+
+// RollingReturnsResponse is the body of GET /funds/{code}/rolling-returns.
+type RollingReturnsResponse struct {
+	FundCode string               `json:"fund_code"`
+	Window   string               `json:"window"`
+	Step     string               `json:"step"`
+	From     string               `json:"from,omitempty"`
+	To       string               `json:"to,omitempty"`
+	Points   int                  `json:"points"`
+	Series   []RollingReturnPoint `json:"series"`
+}
+
+func (s *Server) handleFundRollingReturns() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		window := strings.TrimSpace(r.URL.Query().Get("window"))
+		if code == "" {
+			writeInvalidParam(w, r, "code", "missing fund code")
+			return
+		}
+		if !s.isPrecomputedWindow(window) {
+			writeInvalidParam(w, r, "window", "window must be one of "+s.windowChoices())
+			return
+		}
+		from, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("from")))
+		if err != nil {
+			writeInvalidParam(w, r, "from", "from must be YYYY-MM-DD")
+			return
+		}
+		to, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("to")))
+		if err != nil {
+			writeInvalidParam(w, r, "to", "to must be YYYY-MM-DD")
+			return
+		}
+		step, err := parseFreq(strings.TrimSpace(r.URL.Query().Get("step")), freqDaily)
+		if err != nil {
+			writeInvalidParam(w, r, "step", "step "+err.Error())
+			return
+		}
+
+		if _, ok := s.lookupFund(w, r, code); !ok {
+			return
+		}
+
+		q := db.New(s.pool)
+
+		rows, err := q.ListFundRollingReturns(r.Context(), db.ListFundRollingReturnsParams{
+			SchemeCode: code,
+			Window:     window,
+			FromDate:   from,
+			ToDate:     to,
+		})
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		dates := make([]time.Time, len(rows))
+		for i, row := range rows {
+			dates[i] = row.EndDate.Time
+		}
+
+		out := RollingReturnsResponse{
+			FundCode: code,
+			Window:   window,
+			Step:     step,
+			Series:   []RollingReturnPoint{},
+		}
+		if from.Valid {
+			out.From = from.Time.Format(dateLayout)
+		}
+		if to.Valid {
+			out.To = to.Time.Format(dateLayout)
+		}
+
+		for _, i := range periodEnds(dates, step) {
+			row := rows[i]
+			out.Series = append(out.Series, RollingReturnPoint{
+				EndDate:   row.EndDate.Time.UTC().Format(dateLayout),
+				StartDate: row.StartDate.Time.UTC().Format(dateLayout),
+				Return:    round(row.ReturnPct, 2),
+				CAGR:      float8Ptr(row.CagrPct, 2),
+			})
+		}
+		out.Points = len(out.Series)
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
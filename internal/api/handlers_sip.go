@@ -0,0 +1,243 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+type SIPInstallment struct {
+	ScheduledDate string  `json:"scheduled_date"`
+	NavDate       string  `json:"nav_date"`
+	NAV           float64 `json:"nav"`
+	Amount        float64 `json:"amount"`
+	Units         float64 `json:"units"`
+}
+
+// SIPResponse is the body of GET /funds/{code}/sip.
+type SIPResponse struct {
+	FundCode          string           `json:"fund_code"`
+	FundName          string           `json:"fund_name"`
+	Amount            float64          `json:"amount"`
+	Day               int              `json:"day"`
+	From              string           `json:"from"`
+	To                string           `json:"to"`
+	InstallmentCount  int              `json:"installment_count"`
+	UnitsBought       float64          `json:"units_bought"`
+	InvestedAmount    float64          `json:"invested_amount"`
+	ValuationDate     string           `json:"valuation_date"`
+	ValuationNAV      float64          `json:"valuation_nav"`
+	CurrentValue      float64          `json:"current_value"`
+	AbsoluteGain      float64          `json:"absolute_gain"`
+	AbsoluteReturnPct float64          `json:"absolute_return"`
+	XIRR              *float64         `json:"xirr,omitempty"`
+	Installments      []SIPInstallment `json:"installments"`
+}
+
+func (s *Server) handleFundSIP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		if code == "" {
+			writeInvalidParam(w, r, "code", "missing fund code")
+			return
+		}
+		amount, day, ok := parseSIPParams(w, r)
+		if !ok {
+			return
+		}
+		from, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("from")))
+		if err != nil {
+			writeInvalidParam(w, r, "from", "from must be YYYY-MM-DD")
+			return
+		}
+		to, err := parseDateParam(strings.TrimSpace(r.URL.Query().Get("to")))
+		if err != nil {
+			writeInvalidParam(w, r, "to", "to must be YYYY-MM-DD")
+			return
+		}
+
+		f, ok := s.lookupFund(w, r, code)
+		if !ok {
+			return
+		}
+
+		res, err := analytics.SimulateSIP(r.Context(), s.pool, code, analytics.SIPParams{
+			Amount: amount,
+			Day:    day,
+			From:   from.Time,
+			To:     to.Time,
+		})
+		if err != nil {
+			if errors.Is(err, analytics.ErrInsufficientHistory) {
+				writeError(
+					w,
+					r,
+					http.StatusUnprocessableEntity,
+					CodeInsufficientHistory,
+					"not enough nav history for the requested period",
+				)
+				return
+			}
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		out := SIPResponse{
+			FundCode:          code,
+			FundName:          f.SchemeName,
+			Amount:            amount,
+			Day:               day,
+			From:              res.Installments[0].ScheduledDate.Format(dateLayout),
+			To:                res.ValuationDate.Format(dateLayout),
+			InstallmentCount:  len(res.Installments),
+			UnitsBought:       round(res.Units, 4),
+			InvestedAmount:    round(res.Invested, 2),
+			ValuationDate:     res.ValuationDate.Format(dateLayout),
+			ValuationNAV:      res.ValuationNAV,
+			CurrentValue:      round(res.CurrentValue, 2),
+			AbsoluteGain:      round(res.AbsoluteGain, 2),
+			AbsoluteReturnPct: round(res.AbsoluteReturnPct, 2),
+			XIRR:              roundPtr(res.XIRRPct, 2),
+			Installments:      make([]SIPInstallment, 0, len(res.Installments)),
+		}
+		for _, in := range res.Installments {
+			out.Installments = append(out.Installments, SIPInstallment{
+				ScheduledDate: in.ScheduledDate.Format(dateLayout),
+				NavDate:       in.NavDate.Format(dateLayout),
+				NAV:           in.NAV,
+				Amount:        in.Amount,
+				Units:         round(in.Units, 4),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// RollingSIPResponse is the body of GET /funds/{code}/sip/rolling.
+type RollingSIPResponse struct {
+	FundCode string `json:"fund_code"`
+	FundName string `json:"fund_name"`
+	Window   string `json:"window"`
+	Day      int    `json:"day"`
+	Periods  int    `json:"periods_analyzed"`
+	XIRR     struct {
+		Min    float64 `json:"min"`
+		P25    float64 `json:"p25"`
+		Median float64 `json:"median"`
+		P75    float64 `json:"p75"`
+		Max    float64 `json:"max"`
+		Mean   float64 `json:"mean"`
+	} `json:"xirr"`
+	ProbNegative float64 `json:"prob_negative"`
+}
+
+func (s *Server) handleFundRollingSIP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		if code == "" {
+			writeInvalidParam(w, r, "code", "missing fund code")
+			return
+		}
+		window := strings.TrimSpace(r.URL.Query().Get("window"))
+		if window == "" {
+			window = "3Y"
+		}
+		months := 0
+		switch window {
+		case "3Y":
+			months = 36
+		case "5Y":
+			months = 60
+		default:
+			writeInvalidParam(w, r, "window", "window must be one of 3Y|5Y")
+			return
+		}
+		_, day, ok := parseSIPParams(w, r)
+		if !ok {
+			return
+		}
+
+		f, ok := s.lookupFund(w, r, code)
+		if !ok {
+			return
+		}
+
+		res, err := analytics.RollingSIP(r.Context(), s.pool, code, months, day)
+		if err != nil {
+			if errors.Is(err, analytics.ErrInsufficientHistory) {
+				writeError(
+					w,
+					r,
+					http.StatusUnprocessableEntity,
+					CodeInsufficientHistory,
+					"not enough nav history for a "+window+" SIP",
+				)
+				return
+			}
+			s.writeInternalError(w, r, err)
+			return
+		}
+
+		out := RollingSIPResponse{
+			FundCode:     code,
+			FundName:     f.SchemeName,
+			Window:       window,
+			Day:          day,
+			Periods:      res.Periods,
+			ProbNegative: round(res.ProbNegative, 4),
+		}
+		out.XIRR.Min = round(res.Min, 2)
+		out.XIRR.P25 = round(res.P25, 2)
+		out.XIRR.Median = round(res.Median, 2)
+		out.XIRR.P75 = round(res.P75, 2)
+		out.XIRR.Max = round(res.Max, 2)
+		out.XIRR.Mean = round(res.Mean, 2)
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// parseSIPParams reads amount (default 5000) and day (default 1); it writes the 400 itself.
+func parseSIPParams(w http.ResponseWriter, r *http.Request) (amount float64, day int, ok bool) {
+	amount, day = 5000, 1
+	if v := strings.TrimSpace(r.URL.Query().Get("amount")); v != "" {
+		a, err := strconv.ParseFloat(v, 64)
+		if err != nil || a <= 0 || math.IsInf(a, 0) {
+			writeInvalidParam(w, r, "amount", "amount must be a positive number")
+			return 0, 0, false
+		}
+		amount = a
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("day")); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 || d > 31 {
+			writeInvalidParam(w, r, "day", "day must be between 1 and 31")
+			return 0, 0, false
+		}
+		day = d
+	}
+	return amount, day, true
+}
+
+// lookupFund loads fund metadata, writing 404/500 responses itself when it can't.
+func (s *Server) lookupFund(w http.ResponseWriter, r *http.Request, code string) (db.Fund, bool) {
+	f, err := db.New(s.pool).GetFund(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeError(w, r, http.StatusNotFound, CodeFundNotFound, "fund not found")
+			return db.Fund{}, false
+		}
+		s.writeInternalError(w, r, err)
+		return db.Fund{}, false
+	}
+	return f, true
+}
@@ -13,33 +13,36 @@ import (
 	"mf-analytics-service/internal/db"
 )
 
-func (s *Server) handleSyncTrigger() http.HandlerFunc {
-	type resp struct {
-		RunID string `json:"run_id"`
-	}
-	type errResp struct {
-		Error string `json:"error"`
-		RunID string `json:"run_id,omitempty"`
-	}
+// SyncTriggerResponse is the body of POST /sync/trigger.
+type SyncTriggerResponse struct {
+	RunID string `json:"run_id"`
+}
 
+// SyncErrorResponse is the 409 of POST /sync/trigger, naming the run already in progress.
+type SyncErrorResponse struct {
+	ErrorResponse
+	RunID string `json:"run_id,omitempty"`
+}
+
+func (s *Server) handleSyncTrigger() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		runID, status, err := s.enqueueManualRun(ctx)
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, errResp{Error: err.Error()})
+			s.writeInternalError(w, r, err)
 			return
 		}
 
 		if status == http.StatusConflict {
-			writeJSON(w, http.StatusConflict, errResp{
-				Error: "a sync run is already running",
-				RunID: runID,
+			writeJSON(w, http.StatusConflict, SyncErrorResponse{
+				ErrorResponse: newErrorResponse(r, CodeSyncAlreadyRunning, "a sync run is already running"),
+				RunID:         runID,
 			})
 			return
 		}
 
-		writeJSON(w, http.StatusAccepted, resp{RunID: runID})
+		writeJSON(w, http.StatusAccepted, SyncTriggerResponse{RunID: runID})
 	}
 }
 
@@ -6,37 +6,38 @@ import (
 	"mf-analytics-service/internal/db"
 )
 
-func (s *Server) handleSyncStatus() http.HandlerFunc {
-	type run struct {
-		RunID      string `json:"run_id,omitempty"`
-		RunType    string `json:"run_type,omitempty"`
-		Status     string `json:"status,omitempty"`
-		StartedAt  string `json:"started_at,omitempty"`
-		FinishedAt string `json:"finished_at,omitempty"`
-		Error      string `json:"error_summary,omitempty"`
-	}
+type SyncRun struct {
+	RunID      string `json:"run_id,omitempty"`
+	RunType    string `json:"run_type,omitempty"`
+	Status     string `json:"status,omitempty"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	Error      string `json:"error_summary,omitempty"`
+}
 
-	type scheme struct {
-		SchemeCode     string `json:"scheme_code"`
-		Status         string `json:"status"`
-		LastSyncedDate string `json:"last_synced_date,omitempty"`
-		RetryCount     int32  `json:"retry_count"`
-		LastError      string `json:"last_error,omitempty"`
-		LastAttemptAt  string `json:"last_attempt_at,omitempty"`
-	}
+type SyncScheme struct {
+	SchemeCode     string `json:"scheme_code"`
+	Status         string `json:"status"`
+	LastSyncedDate string `json:"last_synced_date,omitempty"`
+	RetryCount     int32  `json:"retry_count"`
+	LastError      string `json:"last_error,omitempty"`
+	LastAttemptAt  string `json:"last_attempt_at,omitempty"`
+}
 
-	type resp struct {
-		LatestRun run              `json:"latest_run"`
-		Counts    map[string]int64 `json:"counts"`
-		Schemes   []scheme         `json:"schemes"`
-	}
+// SyncStatusResponse is the body of GET /sync/status.
+type SyncStatusResponse struct {
+	LatestRun SyncRun          `json:"latest_run"`
+	Counts    map[string]int64 `json:"counts"`
+	Schemes   []SyncScheme     `json:"schemes"`
+}
 
+func (s *Server) handleSyncStatus() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		q := db.New(s.pool)
 
-		out := resp{
+		out := SyncStatusResponse{
 			Counts:  map[string]int64{},
-			Schemes: []scheme{},
+			Schemes: []SyncScheme{},
 		}
 
 		if latest, err := q.GetLatestSyncRun(r.Context()); err == nil {
@@ -64,7 +65,7 @@ func (s *Server) handleSyncStatus() http.HandlerFunc {
 
 		if states, err := q.ListSyncState(r.Context()); err == nil {
 			for _, st := range states {
-				si := scheme{
+				si := SyncScheme{
 					SchemeCode: st.SchemeCode,
 					Status:     st.Status,
 					RetryCount: st.RetryCount,
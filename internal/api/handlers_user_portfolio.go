@@ -0,0 +1,261 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mf-analytics-service/internal/db"
+	"mf-analytics-service/internal/investor"
+)
+
+// maxImportBytes caps a CSV import body.
+const maxImportBytes = 5 << 20
+
+// UserPosition is the body of GET /users/{id}/portfolio/{code}.
+type UserPosition struct {
+	FundCode       string    `json:"fund_code"`
+	FundName       string    `json:"fund_name"`
+	Units          float64   `json:"units"`
+	Invested       float64   `json:"invested"`
+	Withdrawn      float64   `json:"withdrawn"`
+	CostBasis      float64   `json:"cost_basis"`
+	ValuationDate  *string   `json:"valuation_date"`
+	NAV            *float64  `json:"nav"`
+	CurrentValue   float64   `json:"current_value"`
+	RealizedGain   float64   `json:"realized_gain"`
+	UnrealizedGain float64   `json:"unrealized_gain"`
+	XIRR           *float64  `json:"xirr"`
+	FirstInvested  string    `json:"first_invested"`
+	HoldingDays    *float64  `json:"holding_days"`
+	OpenLots       []OpenLot `json:"open_lots,omitempty"`
+}
+
+type OpenLot struct {
+	Date        string  `json:"date"`
+	Units       float64 `json:"units"`
+	Cost        float64 `json:"cost"`
+	HoldingDays int     `json:"holding_days"`
+}
+
+type UserTransaction struct {
+	FundCode string  `json:"fund_code"`
+	Type     string  `json:"type"`
+	Date     string  `json:"date"`
+	Amount   float64 `json:"amount"`
+	Units    float64 `json:"units"`
+	Source   string  `json:"source"`
+}
+
+// UserTransactionsResponse is the body of GET /users/{id}/transactions.
+type UserTransactionsResponse struct {
+	UserID       string            `json:"user_id"`
+	Transactions []UserTransaction `json:"transactions"`
+}
+
+func (s *Server) handleUserTransactions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(w, r)
+		if !ok {
+			return
+		}
+		rows, err := db.New(s.pool).ListUserTransactions(r.Context(), userID)
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		out := UserTransactionsResponse{UserID: userID, Transactions: make([]UserTransaction, len(rows))}
+		for i, row := range rows {
+			out.Transactions[i] = UserTransaction{
+				FundCode: row.SchemeCode,
+				Type:     row.TxnType,
+				Date:     row.TxnDate.Time.UTC().Format(dateLayout),
+				Amount:   row.Amount.InexactFloat64(),
+				Units:    row.Units.InexactFloat64(),
+				Source:   row.Source,
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// TransactionImportResponse is the body of POST /users/{id}/transactions/import.
+type TransactionImportResponse struct {
+	UserID     string `json:"user_id"`
+	Parsed     int    `json:"parsed"`
+	Imported   int64  `json:"imported"`
+	Duplicates int64  `json:"duplicates"`
+}
+
+// handleUserTransactionsImport stores the transactions in a CSV body. CAS text exports are
+// imported from local files with cmd/import.
+func (s *Server) handleUserTransactionsImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(w, r)
+		if !ok {
+			return
+		}
+		txns, err := investor.ParseCSV(http.MaxBytesReader(w, r.Body, maxImportBytes))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidBody, "invalid csv: "+err.Error())
+			return
+		}
+
+		n, err := investor.Import(r.Context(), s.pool, userID, investor.SourceCSV, txns)
+		var unknown *investor.UnknownFundsError
+		switch {
+		case errors.As(err, &unknown):
+			writeError(w, r, http.StatusUnprocessableEntity, CodeUnknownFund, err.Error())
+			return
+		case err != nil:
+			s.writeInternalError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, TransactionImportResponse{
+			UserID:     userID,
+			Parsed:     len(txns),
+			Imported:   n,
+			Duplicates: int64(len(txns)) - n,
+		})
+	}
+}
+
+// UserPortfolioResponse is the body of GET /users/{id}/portfolio.
+type UserPortfolioResponse struct {
+	UserID         string         `json:"user_id"`
+	Invested       float64        `json:"invested"`
+	Withdrawn      float64        `json:"withdrawn"`
+	CostBasis      float64        `json:"cost_basis"`
+	CurrentValue   float64        `json:"current_value"`
+	RealizedGain   float64        `json:"realized_gain"`
+	UnrealizedGain float64        `json:"unrealized_gain"`
+	XIRR           *float64       `json:"xirr"`
+	Funds          []UserPosition `json:"funds"`
+}
+
+func (s *Server) handleUserPortfolio() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(w, r)
+		if !ok {
+			return
+		}
+		sum, names, ok := s.valueUserPortfolio(w, r, userID)
+		if !ok {
+			return
+		}
+		out := UserPortfolioResponse{
+			UserID:         userID,
+			Invested:       round(sum.Invested, 2),
+			Withdrawn:      round(sum.Withdrawn, 2),
+			CostBasis:      round(sum.CostBasis, 2),
+			CurrentValue:   round(sum.CurrentValue, 2),
+			RealizedGain:   round(sum.RealizedGain, 2),
+			UnrealizedGain: round(sum.UnrealizedGain, 2),
+			XIRR:           roundPtr(sum.XIRRPct, 2),
+			Funds:          make([]UserPosition, len(sum.Positions)),
+		}
+		for i, p := range sum.Positions {
+			out.Funds[i] = userPosition(p, names[p.SchemeCode], false)
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// handleUserPortfolioFund is one fund of the portfolio with its open FIFO lots.
+func (s *Server) handleUserPortfolioFund() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(w, r)
+		if !ok {
+			return
+		}
+		code := chi.URLParam(r, "code")
+		sum, names, ok := s.valueUserPortfolio(w, r, userID)
+		if !ok {
+			return
+		}
+		for _, p := range sum.Positions {
+			if p.SchemeCode == code {
+				writeJSON(w, http.StatusOK, userPosition(p, names[code], true))
+				return
+			}
+		}
+		writeError(w, r, http.StatusNotFound, CodeNoTransactions, "no transactions in this fund")
+	}
+}
+
+// valueUserPortfolio values a user's transactions and loads fund names, writing 404/422/500 itself.
+func (s *Server) valueUserPortfolio(w http.ResponseWriter, r *http.Request, userID string) (investor.Summary, map[string]string, bool) {
+	sum, err := investor.Portfolio(r.Context(), s.pool, userID)
+	var oversold *investor.OversoldError
+	var missing *investor.MissingQuoteError
+	switch {
+	case errors.As(err, &oversold):
+		writeError(w, r, http.StatusUnprocessableEntity, CodeInvalidTransactions, err.Error())
+		return investor.Summary{}, nil, false
+	case errors.As(err, &missing):
+		writeError(w, r, http.StatusUnprocessableEntity, CodeNAVUnavailable, err.Error())
+		return investor.Summary{}, nil, false
+	case err != nil:
+		s.writeInternalError(w, r, err)
+		return investor.Summary{}, nil, false
+	case len(sum.Positions) == 0:
+		writeError(w, r, http.StatusNotFound, CodeNoTransactions, "no transactions for this user")
+		return investor.Summary{}, nil, false
+	}
+
+	funds, err := db.New(s.pool).ListFunds(r.Context(), db.ListFundsParams{})
+	if err != nil {
+		s.writeInternalError(w, r, err)
+		return investor.Summary{}, nil, false
+	}
+	names := make(map[string]string, len(funds))
+	for _, f := range funds {
+		names[f.SchemeCode] = f.SchemeName
+	}
+	return sum, names, true
+}
+
+func userPosition(p investor.Position, name string, withLots bool) UserPosition {
+	out := UserPosition{
+		FundCode:       p.SchemeCode,
+		FundName:       name,
+		Units:          round(p.Units, 4),
+		Invested:       round(p.Invested, 2),
+		Withdrawn:      round(p.Withdrawn, 2),
+		CostBasis:      round(p.CostBasis, 2),
+		CurrentValue:   round(p.CurrentValue, 2),
+		RealizedGain:   round(p.RealizedGain, 2),
+		UnrealizedGain: round(p.UnrealizedGain, 2),
+		XIRR:           roundPtr(p.XIRRPct, 2),
+		FirstInvested:  p.FirstInvested.Format(dateLayout),
+	}
+	if !p.ValuationDate.IsZero() {
+		d := p.ValuationDate.Format(dateLayout)
+		out.ValuationDate = &d
+		out.NAV = roundPtr(p.NAV, 4)
+		out.HoldingDays = roundPtr(p.HoldingDays, 1)
+	}
+	if withLots {
+		out.OpenLots = make([]OpenLot, len(p.Lots))
+		for i, l := range p.Lots {
+			out.OpenLots[i] = OpenLot{
+				Date:        l.Date.Format(dateLayout),
+				Units:       round(l.Units, 4),
+				Cost:        round(l.Cost, 2),
+				HoldingDays: int(p.ValuationDate.Sub(l.Date) / (24 * time.Hour)),
+			}
+		}
+	}
+	return out
+}
+
+func userIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	if !investor.ValidUserID(id) {
+		writeInvalidParam(w, r, "id", investor.ErrInvalidUserID.Error())
+		return "", false
+	}
+	return id, true
+}
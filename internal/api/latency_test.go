@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// latencyBudget is the PRD's response time target for read endpoints.
+	latencyBudget = 200 * time.Millisecond
+	benchFunds    = 2000
+	benchYears    = 10
+)
+
+// BenchmarkReadLatency measures the read endpoints against benchFunds funds with benchYears of
+// weekday NAVs each, in a scratch schema of the database at TEST_DATABASE_URL. Each endpoint
+// reports its p99 and fails when it exceeds latencyBudget. The response cache is off, so every
+// request reaches Postgres.
+//
+//	TEST_DATABASE_URL=postgres://... go test ./internal/api -run '^$' -bench ReadLatency -benchtime 200x
+func BenchmarkReadLatency(b *testing.B) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_URL not set; skipping integration benchmark")
+	}
+	pool := seedBenchSchema(b, dsn)
+	s := NewServer(pool, slog.New(slog.NewTextHandler(io.Discard, nil)), WithResponseCache(0, 0, ""))
+	b.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	code := func(i int) string { return fmt.Sprint(100000 + 1 + i%benchFunds) }
+	endpoints := []struct {
+		name   string
+		target func(i int) string
+	}{
+		{"rank", func(int) string { return "/funds/rank?window=3Y&limit=10" }},
+		{"rank_category_composite", func(i int) string {
+			return fmt.Sprintf("/funds/rank?window=3Y,5Y&category=Category%%20%d&sort_by=composite"+
+				"&weights=rolling_median:0.5,max_drawdown:0.3,sharpe:0.2&limit=10", i%12)
+		}},
+		{"fund_details", func(i int) string { return "/funds/" + code(i) }},
+		{"fund_analytics", func(i int) string { return "/funds/" + code(i) + "/analytics?window=3Y" }},
+		{"funds_by_nav", func(int) string { return "/funds?sort=nav&order=desc&limit=20&include=latest_nav" }},
+	}
+	for _, ep := range endpoints {
+		b.Run(ep.name, func(b *testing.B) {
+			durations := make([]time.Duration, 0, b.N)
+			for i := 0; i < b.N; i++ {
+				target := ep.target(i)
+				start := time.Now()
+				rec := httptest.NewRecorder()
+				s.r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+				durations = append(durations, time.Since(start))
+				if rec.Code != http.StatusOK {
+					b.Fatalf("GET %s = %d: %s", target, rec.Code, rec.Body)
+				}
+			}
+			p99 := percentileDuration(durations, 0.99)
+			b.ReportMetric(float64(p99.Microseconds())/1000, "p99-ms")
+			if p99 > latencyBudget {
+				b.Errorf("p99 %s exceeds %s", p99, latencyBudget)
+			}
+		})
+	}
+}
+
+func percentileDuration(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+}
+
+// seedBenchSchema applies every migration to a new schema, dropped after the benchmark, and
+// fills it with synthetic funds, NAVs and analytics. It returns a pool whose search_path is
+// that schema.
+func seedBenchSchema(b *testing.B, dsn string) *pgxpool.Pool {
+	b.Helper()
+	ctx := context.Background()
+	schema := fmt.Sprintf("mf_bench_%d", time.Now().UnixNano())
+
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		b.Fatalf("pool: %v", err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		b.Fatalf("create schema: %v", err)
+	}
+	b.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		b.Fatalf("parse dsn: %v", err)
+	}
+	// public stays on the path for extensions such as pg_trgm.
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		b.Fatalf("pool: %v", err)
+	}
+	b.Cleanup(pool.Close)
+
+	migrations, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		b.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, path := range migrations {
+		ddl, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, string(ddl)); err != nil {
+			b.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+	}
+
+	started := time.Now()
+	seed := []string{
+		fmt.Sprintf(`
+			INSERT INTO funds (scheme_code, scheme_name, amc, category, inception_date)
+			SELECT (100000 + i)::text, 'Bench Fund ' || i || ' Direct Growth', 'AMC ' || (i %% 40),
+			       'Category ' || (i %% 12), CURRENT_DATE - INTERVAL '%d years'
+			FROM generate_series(1, %d) AS i`, benchYears, benchFunds),
+		fmt.Sprintf(`
+			INSERT INTO nav_history (scheme_code, nav_date, nav_value)
+			SELECT f.scheme_code, d::date, round((10 + random() * 90)::numeric, 4)
+			FROM funds f
+			CROSS JOIN generate_series(CURRENT_DATE - INTERVAL '%d years', CURRENT_DATE, INTERVAL '1 day') AS d
+			WHERE extract(isodow FROM d) < 6`, benchYears),
+		`INSERT INTO fund_latest_nav (scheme_code, nav_date, nav_value)
+			SELECT DISTINCT ON (scheme_code) scheme_code, nav_date, nav_value
+			FROM nav_history
+			ORDER BY scheme_code, nav_date DESC`,
+		`INSERT INTO fund_analytics (scheme_code, "window", rolling_min, rolling_max, rolling_median,
+			rolling_p25, rolling_p75, max_drawdown, cagr_min, cagr_max, cagr_median, volatility, sharpe,
+			consistency, data_start_date, data_end_date, nav_points, rolling_periods)
+			SELECT f.scheme_code, w, -20 + random() * 10, 40 + random() * 40, 5 + random() * 15,
+			       random() * 8, 15 + random() * 10, -(10 + random() * 40), -5 + random() * 5,
+			       20 + random() * 10, 8 + random() * 8, 10 + random() * 15, random() * 2,
+			       random() * 100, n.start_date, n.end_date, n.points, n.points - 250
+			FROM funds f
+			CROSS JOIN unnest(ARRAY['1Y', '3Y', '5Y', '10Y']) AS w
+			JOIN (
+			  SELECT scheme_code, MIN(nav_date) AS start_date, MAX(nav_date) AS end_date, COUNT(*)::int AS points
+			  FROM nav_history GROUP BY scheme_code
+			) n ON n.scheme_code = f.scheme_code`,
+		`INSERT INTO fund_analytics_previous (scheme_code, "window", rolling_min, rolling_max,
+			rolling_median, rolling_p25, rolling_p75, max_drawdown, cagr_min, cagr_max, cagr_median,
+			volatility, sharpe, consistency, data_end_date, computed_at)
+			SELECT scheme_code, "window", rolling_min, rolling_max, rolling_median - 1, rolling_p25,
+			       rolling_p75, max_drawdown, cagr_min, cagr_max, cagr_median, volatility, sharpe,
+			       consistency, data_end_date - 1, computed_at - INTERVAL '1 day'
+			FROM fund_analytics`,
+		"ANALYZE funds, nav_history, fund_latest_nav, fund_analytics, fund_analytics_previous",
+	}
+	for _, stmt := range seed {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			b.Fatalf("seed: %v\n%s", err, stmt)
+		}
+	}
+	b.Logf("seeded %d funds x %d years in %s", benchFunds, benchYears, time.Since(started).Round(time.Second))
+	return pool
+}
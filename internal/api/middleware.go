@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -12,21 +13,32 @@ func (s *Server) requestLogger() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			// authenticate fills in the key once it is known.
+			fields := &logFields{}
 
-			next.ServeHTTP(ww, r)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), logFieldsKey{}, fields)))
 
 			if s.log == nil {
 				return
 			}
 			s.log.Info("http",
+				"request_id", middleware.GetReqID(r.Context()),
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", ww.Status(),
 				"bytes", ww.BytesWritten(),
 				"duration_ms", time.Since(start).Milliseconds(),
 				"remote", r.RemoteAddr,
+				"key_id", fields.keyID,
 			)
 		})
 	}
 }
 
+// logFields are request log attributes that are only known further down the chain.
+type logFields struct {
+	keyID string
+}
+
+type logFieldsKey struct{}
+
@@ -0,0 +1,563 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mf-analytics-service/internal/apikey"
+)
+
+// apiParam is a query or path parameter of an apiOperation.
+type apiParam struct {
+	name        string
+	in          string // query|path
+	typ         string // string|integer|number|boolean
+	description string
+	required    bool
+	enum        []string
+	// repeated parameters may be given more than once or as a comma-separated list.
+	repeated bool
+}
+
+func queryParam(name, typ, description string, enum ...string) apiParam {
+	return apiParam{name: name, in: "query", typ: typ, description: description, enum: enum}
+}
+
+func requiredQueryParam(name, typ, description string) apiParam {
+	p := queryParam(name, typ, description)
+	p.required = true
+	return p
+}
+
+func repeatedQueryParam(name, description string) apiParam {
+	p := queryParam(name, "string", description)
+	p.repeated = true
+	return p
+}
+
+// apiOperation documents one route. Every route registered in routes.go has exactly one, which
+// TestOpenAPICoversRoutes enforces; the schemas are reflected from the response types themselves
+// so the document can't describe a shape the handlers don't write.
+type apiOperation struct {
+	method  string
+	path    string
+	summary string
+	params  []apiParam
+	// request is the JSON request body, or requestCSV for a text/csv body.
+	request    any
+	requestCSV bool
+	status     int
+	response   any // nil for an empty body
+	// csv marks routes that also answer Accept: text/csv.
+	csv    bool
+	errors []int
+	// errorBodies replaces ErrorResponse as the body of some error statuses.
+	errorBodies map[int]any
+	// conditional marks routes that send ETag and Last-Modified and answer 304 to
+	// If-None-Match or If-Modified-Since.
+	conditional bool
+	// scope is the API key scope the route requires, read unless set; public routes need no key.
+	scope  string
+	public bool
+}
+
+func (s *Server) apiOperations() []apiOperation {
+	precomputed := "Precomputed window: " + s.windowChoices() + "."
+	adhocWindow := "Window as <n>M or <n>Y."
+	// precomputedWindow is the window of routes that only read precomputed rows.
+	precomputedWindow := func() apiParam {
+		p := requiredQueryParam("window", "string", precomputed)
+		for _, w := range s.windows {
+			p.enum = append(p.enum, w.Label)
+		}
+		return p
+	}
+	from := queryParam("from", "string", "First date, YYYY-MM-DD.")
+	to := queryParam("to", "string", "Last date, YYYY-MM-DD.")
+	step := func(def string) apiParam {
+		return queryParam("step", "string", "Sampling step; defaults to "+def+".", freqDaily, freqWeekly, freqMonthly)
+	}
+	portfolioID := apiParam{name: "id", in: "path", typ: "integer", required: true, description: "Model portfolio id."}
+	projection := []apiParam{
+		queryParam("sip", "number", "Monthly SIP amount; sip and/or lumpsum is required."),
+		queryParam("lumpsum", "number", "Amount invested at the start."),
+		queryParam("months", "integer", "Horizon in months; defaults to 120."),
+		queryParam("target", "number", "Target corpus for prob_target."),
+		queryParam("simulations", "integer", "Bootstrap simulations, 100 to 10000."),
+		queryParam("seed", "integer", "Random seed; defaults to 1."),
+	}
+	sip := []apiParam{
+		queryParam("amount", "number", "Monthly instalment; defaults to 5000."),
+		queryParam("day", "integer", "Day of the month the instalment is invested, 1 to 31."),
+	}
+
+	ops := []apiOperation{
+		{
+			method: http.MethodGet, path: "/analytics/correlation", summary: "Correlation matrix of fund returns",
+			params: []apiParam{
+				requiredQueryParam("window", "string", adhocWindow),
+				queryParam("frequency", "string", "Return frequency; defaults to daily.", "daily", "weekly"),
+				queryParam("category", "string", "Category to correlate."),
+			},
+			status: http.StatusOK, response: CorrelationResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/categories/{category}/analytics", summary: "Category-wide analytics",
+			params: []apiParam{precomputedWindow()},
+			status: http.StatusOK, response: CategoryAnalyticsResponse{}, errors: []int{400, 404, 500},
+			conditional: true,
+		},
+		{
+			method: http.MethodGet, path: "/debug/vars", summary: "Runtime and cache metrics (expvar)",
+			status: http.StatusOK, response: map[string]any{}, scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodGet, path: "/funds", summary: "List, search and page through funds",
+			params: []apiParam{
+				queryParam("category", "string", "Category substring."),
+				queryParam("amc", "string", "AMC substring."),
+				queryParam("q", "string", "Fuzzy match on the scheme name."),
+				queryParam("sort", "string", "Sort key; defaults to name.", "name", "amc", "nav", "inception"),
+				queryParam("order", "string", "Sort order; defaults to asc.", "asc", "desc"),
+				queryParam("limit", "integer", "Page size, 1 to 200; defaults to 50."),
+				queryParam("cursor", "string", "next_cursor of the previous page."),
+				queryParam("include", "string", "Comma-separated extras: latest_nav, metrics."),
+				queryParam("window", "string", "Window of include=metrics. "+precomputed),
+			},
+			status: http.StatusOK, response: FundsListResponse{}, errors: []int{400, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/compare", summary: "Compare funds side by side",
+			params: []apiParam{
+				requiredQueryParam("codes", "string", "2 to 5 comma-separated scheme codes."),
+				queryParam("windows", "string", "Comma-separated precomputed windows."),
+			},
+			status: http.StatusOK, response: FundsCompareResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/rank", summary: "Rank funds by a metric or composite score",
+			params: []apiParam{
+				repeatedQueryParam("category", "Category to rank within; repeatable."),
+				repeatedQueryParam("amc", "AMC to rank within; repeatable."),
+				queryParam("window", "string", "Comma-separated windows. "+precomputed),
+				queryParam("sort_by", "string", "composite, median_return or a metric column; defaults to median_return."),
+				queryParam("order", "string", "Sort order; defaults by metric.", "asc", "desc"),
+				queryParam("weights", "string", "Composite weights as metric:weight pairs."),
+				queryParam("score", "string", "Composite scoring method.", "zscore", "percentile"),
+				queryParam("limit", "integer", "Funds to return; defaults to 5."),
+				queryParam("max_quartile", "integer", "Only funds in this category quartile or better, 1 to 4."),
+				queryParam("as_of", "string", "Rank the snapshot on or before this date, YYYY-MM-DD."),
+			},
+			status: http.StatusOK, response: FundsRankResponse{}, errors: []int{400, 500},
+			conditional: true,
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}", summary: "Fund metadata and latest NAV",
+			status: http.StatusOK, response: FundDetailsResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/analytics", summary: "Rolling-return analytics of a fund",
+			params: []apiParam{
+				requiredQueryParam("window", "string", precomputed+" Other <n>M|<n>Y windows are computed on demand."),
+				queryParam("as_of", "string", "Use NAVs up to this date, YYYY-MM-DD."),
+				queryParam("detail", "string", "full adds the return distribution.", "summary", "full"),
+				queryParam("bucket_width", "number", "Histogram bucket width in percentage points (detail=full)."),
+				queryParam("beat", "number", "Return threshold in % for prob_beat (detail=full)."),
+			},
+			status: http.StatusOK, response: FundAnalyticsResponse{}, errors: []int{400, 404, 500},
+			conditional: true,
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/nav", summary: "NAV history of a fund",
+			params: []apiParam{
+				from, to,
+				queryParam("freq", "string", "Sampling frequency; defaults to daily.", freqDaily, freqWeekly, freqMonthly),
+				queryParam("fill", "string", "Gap filling; defaults to none.", "none", "ffill"),
+			},
+			status: http.StatusOK, response: FundNAVResponse{}, csv: true, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/projection", summary: "Bootstrap projection of an investment in a fund",
+			params: projection,
+			status: http.StatusOK, response: FundProjectionResponse{}, errors: []int{400, 404, 422, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/rank-history", summary: "A fund's category rank over time",
+			params: []apiParam{precomputedWindow(), from, to, step(freqMonthly)},
+			status: http.StatusOK, response: RankHistoryResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/rolling-returns", summary: "Rolling-return series of a fund",
+			params: []apiParam{precomputedWindow(), from, to, step(freqDaily)},
+			status: http.StatusOK, response: RollingReturnsResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/returns", summary: "Trailing and calendar-year returns",
+			status: http.StatusOK, response: FundReturnsResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/sip", summary: "Backtest of a monthly SIP",
+			params: append([]apiParam{from, to}, sip...),
+			status: http.StatusOK, response: SIPResponse{}, errors: []int{400, 404, 422, 500},
+		},
+		{
+			method: http.MethodGet, path: "/funds/{code}/sip/rolling", summary: "XIRR distribution of rolling SIPs",
+			params: append([]apiParam{queryParam("window", "string", "SIP length; defaults to 3Y.", "3Y", "5Y")}, sip...),
+			status: http.StatusOK, response: RollingSIPResponse{}, errors: []int{400, 404, 422, 500},
+		},
+		{
+			method: http.MethodGet, path: "/openapi.json", summary: "This document",
+			status: http.StatusOK, response: map[string]any{}, public: true,
+		},
+		{
+			method: http.MethodGet, path: "/portfolios", summary: "List model portfolios",
+			status: http.StatusOK, response: PortfoliosListResponse{}, errors: []int{500},
+		},
+		{
+			method: http.MethodPost, path: "/portfolios", summary: "Create a model portfolio",
+			request: Portfolio{}, status: http.StatusCreated, response: Portfolio{}, errors: []int{400, 500},
+			scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodGet, path: "/portfolios/{id}", summary: "Get a model portfolio",
+			params: []apiParam{portfolioID},
+			status: http.StatusOK, response: Portfolio{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodPut, path: "/portfolios/{id}", summary: "Replace a model portfolio",
+			params:  []apiParam{portfolioID},
+			request: Portfolio{}, status: http.StatusOK, response: Portfolio{}, errors: []int{400, 404, 500},
+			scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodDelete, path: "/portfolios/{id}", summary: "Delete a model portfolio",
+			params: []apiParam{portfolioID},
+			status: http.StatusNoContent, errors: []int{400, 404, 500}, scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodGet, path: "/portfolios/{id}/analytics", summary: "Analytics of a model portfolio's NAV",
+			params: []apiParam{portfolioID, queryParam("window", "string", adhocWindow)},
+			status: http.StatusOK, response: PortfolioAnalyticsResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/portfolios/{id}/nav", summary: "Synthesized NAV of a model portfolio",
+			params: []apiParam{portfolioID, from, to, step(freqDaily)},
+			status: http.StatusOK, response: PortfolioNAVResponse{}, errors: []int{400, 404, 500},
+		},
+		{
+			method: http.MethodGet, path: "/portfolios/{id}/projection", summary: "Bootstrap projection of a model portfolio",
+			params: append([]apiParam{portfolioID}, projection...),
+			status: http.StatusOK, response: PortfolioProjectionResponse{}, errors: []int{400, 404, 422, 500},
+		},
+		{
+			method: http.MethodPost, path: "/sync/trigger", summary: "Start a manual sync run",
+			status: http.StatusAccepted, response: SyncTriggerResponse{}, errors: []int{409, 500},
+			errorBodies: map[int]any{http.StatusConflict: SyncErrorResponse{}},
+			scope:       apikey.ScopeSyncTrigger,
+		},
+		{
+			method: http.MethodGet, path: "/sync/status", summary: "Sync pipeline state and progress",
+			status: http.StatusOK, response: SyncStatusResponse{},
+		},
+		{
+			method: http.MethodGet, path: "/users/{id}/capital-gains", summary: "Capital gains and tax per financial year",
+			params: []apiParam{queryParam("fy", "string", "Financial year, e.g. 2024-25; also lists its lots.")},
+			status: http.StatusOK, response: CapitalGainsResponse{}, errors: []int{400, 422, 500},
+			scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodGet, path: "/users/{id}/portfolio", summary: "Valuation of an investor's holdings",
+			status: http.StatusOK, response: UserPortfolioResponse{}, errors: []int{400, 404, 422, 500},
+			scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodGet, path: "/users/{id}/portfolio/{code}", summary: "One holding with its open lots",
+			status: http.StatusOK, response: UserPosition{}, errors: []int{400, 404, 422, 500},
+			scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodGet, path: "/users/{id}/transactions", summary: "An investor's transactions",
+			status: http.StatusOK, response: UserTransactionsResponse{}, errors: []int{400, 500},
+			scope: apikey.ScopeAdmin,
+		},
+		{
+			method: http.MethodPost, path: "/users/{id}/transactions/import", summary: "Import transactions from CSV",
+			requestCSV: true, status: http.StatusOK, response: TransactionImportResponse{}, errors: []int{400, 422, 500},
+			scope: apikey.ScopeAdmin,
+		},
+	}
+	for i := range ops {
+		if ops[i].scope == "" && !ops[i].public {
+			ops[i].scope = apikey.ScopeRead
+		}
+	}
+	return ops
+}
+
+func (s *Server) handleOpenAPI() http.HandlerFunc {
+	doc, err := json.Marshal(s.openAPIDocument())
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			s.writeInternalError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	}
+}
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// openAPIDocument renders apiOperations as an OpenAPI 3.0 document.
+func (s *Server) openAPIDocument() map[string]any {
+	schemas := schemaSet{}
+	paths := map[string]any{}
+	for _, op := range s.apiOperations() {
+		params := make([]any, 0, len(op.params))
+		declared := map[string]bool{}
+		for _, p := range op.params {
+			declared[p.in+":"+p.name] = true
+			params = append(params, p.openAPI())
+		}
+		// Path parameters default to required strings.
+		for _, m := range pathParamPattern.FindAllStringSubmatch(op.path, -1) {
+			if !declared["path:"+m[1]] {
+				params = append(params, apiParam{name: m[1], in: "path", typ: "string", required: true}.openAPI())
+			}
+		}
+
+		responses := map[string]any{}
+		ok := map[string]any{"description": http.StatusText(op.status)}
+		if op.response != nil {
+			content := map[string]any{"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(op.response))}}
+			if op.csv {
+				content["text/csv"] = map[string]any{"schema": map[string]any{"type": "string"}}
+			}
+			ok["content"] = content
+		}
+		if op.conditional {
+			for _, h := range []apiParam{
+				{name: "If-None-Match", in: "header", typ: "string", description: "ETag of a cached copy."},
+				{name: "If-Modified-Since", in: "header", typ: "string", description: "Last-Modified of a cached copy."},
+			} {
+				params = append(params, h.openAPI())
+			}
+			ok["headers"] = map[string]any{
+				"ETag":          map[string]any{"schema": map[string]any{"type": "string"}},
+				"Last-Modified": map[string]any{"schema": map[string]any{"type": "string"}},
+				"Cache-Control": map[string]any{
+					"description": "private, max-age until the next scheduled incremental sync.",
+					"schema":      map[string]any{"type": "string"},
+				},
+				"Vary": map[string]any{
+					"description": "Authorization, X-API-Key.",
+					"schema":      map[string]any{"type": "string"},
+				},
+			}
+			responses[strconv.Itoa(http.StatusNotModified)] = map[string]any{
+				"description": http.StatusText(http.StatusNotModified),
+			}
+		}
+		responses[strconv.Itoa(op.status)] = ok
+		errs := op.errors
+		if !op.public {
+			errs = append([]int{http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests}, errs...)
+		}
+		for _, code := range errs {
+			errBody := op.errorBodies[code]
+			if errBody == nil {
+				errBody = ErrorResponse{}
+			}
+			responses[strconv.Itoa(code)] = map[string]any{
+				"description": http.StatusText(code),
+				"content":     map[string]any{"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(errBody))}},
+			}
+		}
+
+		o := map[string]any{
+			"operationId": operationID(op.method, op.path),
+			"summary":     op.summary,
+			"parameters":  params,
+			"responses":   responses,
+		}
+		if op.public {
+			o["security"] = []any{}
+		} else {
+			o["x-required-scope"] = op.scope
+		}
+		switch {
+		case op.requestCSV:
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"text/csv": map[string]any{"schema": map[string]any{"type": "string"}}},
+			}
+		case op.request != nil:
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(op.request))}},
+			}
+		}
+
+		item, _ := paths[op.path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = o
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Mutual Fund Analytics API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		// Scopes aren't OAuth scopes, so they are listed per operation as x-required-scope.
+		"security": []any{map[string]any{"apiKey": []any{}}, map[string]any{"bearer": []any{}}},
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func (p apiParam) openAPI() map[string]any {
+	schema := map[string]any{"type": p.typ}
+	if len(p.enum) > 0 {
+		schema["enum"] = p.enum
+	}
+	if p.repeated {
+		schema = map[string]any{"type": "array", "items": schema}
+	}
+	out := map[string]any{"name": p.name, "in": p.in, "schema": schema}
+	if p.required {
+		out["required"] = true
+	}
+	if p.description != "" {
+		out["description"] = p.description
+	}
+	return out
+}
+
+// operationID derives a stable id such as getFundsCodeAnalytics from a route.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// schemaSet collects the named response types as components, keyed by Go type name.
+type schemaSet map[string]any
+
+// of returns the schema of t, registering named structs as components and referring to them.
+// Properties follow encoding/json: the json tag names them, omitempty fields are optional,
+// embedded structs are flattened, and pointers without omitempty may be null.
+func (set schemaSet) of(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return set.of(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": set.elem(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": set.elem(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return set.object(t)
+		}
+		if _, ok := set[t.Name()]; !ok {
+			set[t.Name()] = nil // reserve the name before recursing
+			set[t.Name()] = set.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+// elem is the schema of a slice or map element, which encodes as null when it is a nil pointer.
+func (set schemaSet) elem(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return nullable(set.of(t))
+	}
+	return set.of(t)
+}
+
+func (set schemaSet) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			omitempty := strings.Contains(","+opts+",", ",omitempty,")
+			schema := set.of(f.Type)
+			if f.Type.Kind() == reflect.Pointer && !omitempty {
+				schema = nullable(schema)
+			}
+			props[name] = schema
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+
+	out := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+// nullable allows null besides schema; a $ref can't carry siblings in OpenAPI 3.0, so it is
+// wrapped in allOf.
+func nullable(schema map[string]any) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]any{"allOf": []any{schema}, "nullable": true}
+	}
+	out := make(map[string]any, len(schema)+1)
+	for k, v := range schema {
+		out[k] = v
+	}
+	out["nullable"] = true
+	return out
+}
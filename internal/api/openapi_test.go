@@ -0,0 +1,422 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newTestServer(t *testing.T, pool *pgxpool.Pool) *Server {
+	t.Helper()
+	return NewServer(pool, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// fetchSpec reads the document the way a client does, so the tests see exactly what is served.
+func fetchSpec(t *testing.T, s *Server) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", rec.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	doc := fetchSpec(t, s)
+	paths := doc["paths"].(map[string]any)
+
+	routed := map[string]bool{}
+	err := chi.Walk(s.r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+		routed[key] = true
+		item, _ := paths[route].(map[string]any)
+		if item == nil || item[strings.ToLower(method)] == nil {
+			t.Errorf("%s is routed but not in the spec", key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, item := range paths {
+		for method, op := range item.(map[string]any) {
+			key := strings.ToUpper(method) + " " + path
+			if !routed[key] {
+				t.Errorf("%s is in the spec but not routed", key)
+			}
+			declared := map[string]bool{}
+			for _, p := range op.(map[string]any)["parameters"].([]any) {
+				p := p.(map[string]any)
+				if p["in"] == "path" {
+					declared[p["name"].(string)] = true
+				}
+			}
+			for _, m := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				if !declared[m[1]] {
+					t.Errorf("%s doesn't declare path parameter %s", key, m[1])
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAPIRefsResolve(t *testing.T) {
+	doc := fetchSpec(t, newTestServer(t, nil))
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if schemas[name] == nil {
+					t.Errorf("unresolved $ref %s", ref)
+				}
+			}
+			for _, e := range v {
+				walk(e)
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(doc)
+}
+
+// TestHandlerErrorsMatchSpec checks real handler output for requests rejected before any query
+// runs, so it needs no database.
+func TestHandlerErrorsMatchSpec(t *testing.T) {
+	s := newTestServer(t, nil)
+	doc := fetchSpec(t, s)
+	tests := []struct {
+		method, target, body string
+		want                 int
+		code                 string
+		field                string
+	}{
+		{method: "GET", target: "/funds?sort=bogus", want: 400, code: CodeInvalidParameter, field: "sort"},
+		{method: "GET", target: "/funds?limit=500", want: 400, code: CodeInvalidParameter, field: "limit"},
+		{method: "GET", target: "/funds?cursor=not-a-cursor", want: 400, code: CodeInvalidParameter, field: "cursor"},
+		{method: "GET", target: "/funds?include=everything", want: 400, code: CodeInvalidParameter, field: "include"},
+		{method: "GET", target: "/funds/rank?window=2Y", want: 400, code: CodeInvalidWindow, field: "window"},
+		{method: "GET", target: "/funds/rank?window=3Y&sort_by=bogus", want: 400, code: CodeInvalidParameter, field: "sort_by"},
+		{method: "GET", target: "/funds/compare?codes=119598", want: 400, code: CodeInvalidParameter, field: "codes"},
+		{method: "GET", target: "/funds/119598/analytics?window=3Y&detail=bogus", want: 400, code: CodeInvalidParameter, field: "detail"},
+		{method: "GET", target: "/funds/119598/nav?from=yesterday", want: 400, code: CodeInvalidParameter, field: "from"},
+		{method: "GET", target: "/funds/119598/projection", want: 400, code: CodeInvalidParameter, field: "sip"},
+		{method: "GET", target: "/funds/119598/sip?day=40", want: 400, code: CodeInvalidParameter, field: "day"},
+		{method: "GET", target: "/analytics/correlation?window=3Y&frequency=hourly", want: 400, code: CodeInvalidParameter, field: "frequency"},
+		{method: "GET", target: "/portfolios/abc", want: 400, code: CodeInvalidParameter, field: "id"},
+		{method: "POST", target: "/portfolios", body: "{", want: 400, code: CodeInvalidBody},
+		{method: "POST", target: "/portfolios", body: `{"name":"x","holdings":[]}`, want: 400, code: CodeInvalidBody, field: "holdings"},
+		{method: "GET", target: "/users/not%20valid/portfolio", want: 400, code: CodeInvalidParameter, field: "id"},
+		{method: "GET", target: "/users/investor-1/capital-gains?fy=2024", want: 400, code: CodeInvalidParameter, field: "fy"},
+		{method: "POST", target: "/users/investor-1/transactions/import", body: "date,amount\n", want: 400, code: CodeInvalidBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := serve(s, tt.method, tt.target, tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if err := validateResponse(doc, s, tt.method, tt.target, rec); err != nil {
+				t.Fatal(err)
+			}
+			var got ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Error.Code != tt.code {
+				t.Errorf("code %s, want %s", got.Error.Code, tt.code)
+			}
+			if got.Error.RequestID == "" {
+				t.Error("missing request_id")
+			}
+			if tt.field != "" && (len(got.Error.Details) != 1 || got.Error.Details[0].Field != tt.field) {
+				t.Errorf("details %+v, want field %s", got.Error.Details, tt.field)
+			}
+		})
+	}
+}
+
+// TestPRDExamplesMatchSpec keeps the example responses in prd.md in step with the handlers.
+func TestPRDExamplesMatchSpec(t *testing.T) {
+	doc := fetchSpec(t, newTestServer(t, nil))
+	prd, err := os.ReadFile("../../prd.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples := map[string]string{
+		"Example Ranking Response":   "FundsRankResponse",
+		"Example Analytics Response": "FundAnalyticsResponse",
+	}
+	for heading, schema := range examples {
+		body, err := exampleAfter(string(prd), heading)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v any
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			t.Fatalf("%s: %v", heading, err)
+		}
+		ref := map[string]any{"$ref": "#/components/schemas/" + schema}
+		if err := validateSchema(doc, ref, v, "$"); err != nil {
+			t.Errorf("%s: %v", heading, err)
+		}
+	}
+}
+
+// TestHandlersMatchSpec validates successful responses against the spec. It reads whatever the
+// database at TEST_DATABASE_URL holds, so it needs synced funds and computed analytics.
+func TestHandlersMatchSpec(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping integration test")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	var codes []string
+	var category string
+	rows, err := pool.Query(ctx, `
+		SELECT f.scheme_code, f.category FROM funds f
+		WHERE EXISTS (SELECT 1 FROM fund_analytics a WHERE a.scheme_code = f.scheme_code)
+		ORDER BY f.scheme_code LIMIT 2`)
+	if err != nil {
+		t.Fatalf("pick funds: %v", err)
+	}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code, &category); err != nil {
+			t.Fatal(err)
+		}
+		codes = append(codes, code)
+	}
+	rows.Close()
+	if len(codes) < 2 {
+		t.Skip("database has fewer than two funds with analytics")
+	}
+
+	s := newTestServer(t, pool)
+	doc := fetchSpec(t, s)
+	window := s.windows[0].Label
+	code := codes[0]
+	targets := []string{
+		"/funds",
+		"/funds?include=latest_nav,metrics&limit=5",
+		"/funds/" + code,
+		"/funds/" + code + "/analytics?window=" + window + "&detail=full&beat=10",
+		"/funds/" + code + "/nav?freq=monthly",
+		"/funds/" + code + "/returns",
+		"/funds/" + code + "/rolling-returns?window=1Y&step=monthly",
+		"/funds/" + code + "/rank-history?window=" + window,
+		"/funds/" + code + "/sip",
+		"/funds/" + code + "/sip/rolling",
+		"/funds/" + code + "/projection?sip=5000&months=60&target=500000",
+		"/funds/rank?window=" + window + "&category=" + url.QueryEscape(category),
+		"/funds/rank?window=" + window + "&sort_by=composite&limit=3",
+		"/funds/compare?codes=" + strings.Join(codes, ","),
+		"/categories/" + url.PathEscape(category) + "/analytics?window=" + window,
+		"/analytics/correlation?window=1Y&category=" + url.QueryEscape(category),
+		"/portfolios",
+		"/sync/status",
+	}
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			rec := serve(s, http.MethodGet, target, "")
+			if err := validateResponse(doc, s, http.MethodGet, target, rec); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// A range past the last NAV must not be forward-filled into a point beyond the data.
+	target := "/funds/" + code + "/nav?from=2999-01-01&fill=ffill"
+	rec := serve(s, http.MethodGet, target, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s = %d: %s", target, rec.Code, rec.Body)
+	}
+	if err := validateResponse(doc, s, http.MethodGet, target, rec); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serve(s *Server, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.r.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+// validateResponse checks rec against the operation the router matches for the request: the
+// status must be documented and a JSON body must satisfy its schema.
+func validateResponse(doc map[string]any, s *Server, method, target string, rec *httptest.ResponseRecorder) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+	rctx := chi.NewRouteContext()
+	if !s.r.Match(rctx, method, u.Path) {
+		return fmt.Errorf("no route for %s %s", method, u.Path)
+	}
+	pattern := rctx.RoutePattern()
+	op, _ := doc["paths"].(map[string]any)[pattern].(map[string]any)[strings.ToLower(method)].(map[string]any)
+	if op == nil {
+		return fmt.Errorf("%s %s isn't documented", method, pattern)
+	}
+	resp, _ := op["responses"].(map[string]any)[strconv.Itoa(rec.Code)].(map[string]any)
+	if resp == nil {
+		return fmt.Errorf("%s %s: status %d isn't documented: %s", method, pattern, rec.Code, rec.Body)
+	}
+	content, _ := resp["content"].(map[string]any)
+	if content == nil {
+		if rec.Body.Len() > 0 {
+			return fmt.Errorf("%s %s: %d should have no body", method, pattern, rec.Code)
+		}
+		return nil
+	}
+	media, _ := content["application/json"].(map[string]any)
+	if media == nil {
+		return fmt.Errorf("%s %s: %d has no JSON schema", method, pattern, rec.Code)
+	}
+	var v any
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		return fmt.Errorf("%s %s: decode body: %v", method, pattern, err)
+	}
+	if err := validateSchema(doc, media["schema"], v, "$"); err != nil {
+		return fmt.Errorf("%s %s %d: %v", method, pattern, rec.Code, err)
+	}
+	return nil
+}
+
+// validateSchema checks v against the subset of OpenAPI 3.0 schemas openAPIDocument emits.
+func validateSchema(doc map[string]any, schema any, v any, at string) error {
+	sch, _ := schema.(map[string]any)
+	if ref, ok := sch["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		return validateSchema(doc, doc["components"].(map[string]any)["schemas"].(map[string]any)[name], v, at)
+	}
+	if v == nil {
+		if sch["nullable"] == true || len(sch) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%s: null isn't allowed", at)
+	}
+	if all, ok := sch["allOf"].([]any); ok {
+		for _, s := range all {
+			if err := validateSchema(doc, s, v, at); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch sch["type"] {
+	case nil:
+		return nil
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: want a string, got %v", at, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: want a number, got %v", at, v)
+		}
+	case "integer":
+		if f, ok := v.(float64); !ok || f != math.Trunc(f) {
+			return fmt.Errorf("%s: want an integer, got %v", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: want a boolean, got %v", at, v)
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: want an array, got %v", at, v)
+		}
+		for i, e := range arr {
+			if err := validateSchema(doc, sch["items"], e, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: want an object, got %v", at, v)
+		}
+		props, _ := sch["properties"].(map[string]any)
+		required, _ := sch["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required %s", at, name)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, ok := props[k]
+			if !ok {
+				if sch["additionalProperties"] == false {
+					return fmt.Errorf("%s: unexpected property %s", at, k)
+				}
+				prop = sch["additionalProperties"]
+			}
+			if err := validateSchema(doc, prop, obj[k], at+"."+k); err != nil {
+				return err
+			}
+		}
+	}
+	if enum, ok := sch["enum"].([]any); ok {
+		for _, e := range enum {
+			if e == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v isn't one of %v", at, v, enum)
+	}
+	return nil
+}
+
+// exampleAfter returns the first ```json block following heading in a markdown document.
+func exampleAfter(md, heading string) (string, error) {
+	i := strings.Index(md, heading)
+	if i < 0 {
+		return "", fmt.Errorf("heading %q not found", heading)
+	}
+	m := regexp.MustCompile("(?s)```json\n(.*?)```").FindStringSubmatch(md[i:])
+	if m == nil {
+		return "", fmt.Errorf("no json example after %q", heading)
+	}
+	return m[1], nil
+}
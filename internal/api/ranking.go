@@ -0,0 +1,298 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+// rankCandidate is one fund_analytics row (current or previous) reduced to what ranking needs.
+type rankCandidate struct {
+	code     string
+	category string
+	window   string
+	values   map[string]float64 // analytics.RankMetrics columns; NaN when NULL
+}
+
+// rankSpec is how /funds/rank orders funds within one window: by a metric column, or by a
+// composite score over weighted metrics.
+type rankSpec struct {
+	sortBy  string // as requested
+	metric  string
+	weights []analytics.MetricWeight // composite when non-empty
+	method  analytics.ScoreMethod
+	order   string // asc|desc
+}
+
+// parseRankSpec reads sort_by (composite, median_return or any analytics.RankMetrics column),
+// order (asc|desc), and for composite the weights and score method.
+func parseRankSpec(r *http.Request) (rankSpec, error) {
+	sortBy := strings.TrimSpace(r.URL.Query().Get("sort_by"))
+	order := strings.TrimSpace(r.URL.Query().Get("order"))
+	if sortBy == "" {
+		sortBy = "median_return"
+	}
+	spec := rankSpec{sortBy: sortBy, metric: analytics.CanonicalMetric(sortBy)}
+	if _, ok := analytics.RankMetrics[spec.metric]; !ok && sortBy != "composite" {
+		return spec, FieldError{
+			Field:   "sort_by",
+			Message: "sort_by must be composite, median_return or a metric column (" + rankMetricChoices() + ")",
+		}
+	}
+	if order == "" {
+		order = defaultRankOrder(spec.metric)
+	}
+	if order != "asc" && order != "desc" {
+		return spec, FieldError{Field: "order", Message: "order must be asc|desc"}
+	}
+	spec.order = order
+
+	if sortBy != "composite" {
+		if r.URL.Query().Has("weights") || r.URL.Query().Has("score") {
+			return spec, FieldError{Field: "sort_by", Message: "weights and score require sort_by=composite"}
+		}
+		return spec, nil
+	}
+
+	var err error
+	spec.metric = ""
+	spec.weights, err = analytics.ParseWeights(r.URL.Query().Get("weights"))
+	if err != nil {
+		return spec, FieldError{Field: "weights", Message: "weights: " + err.Error()}
+	}
+	spec.method = analytics.ScoreMethod(strings.TrimSpace(r.URL.Query().Get("score")))
+	if spec.method == "" {
+		spec.method = analytics.ScoreZ
+	}
+	if spec.method != analytics.ScoreZ && spec.method != analytics.ScorePercentile {
+		return spec, FieldError{Field: "score", Message: "score must be zscore|percentile"}
+	}
+	return spec, nil
+}
+
+type windowRank struct {
+	key           float64   // metric value or composite score; NaN when missing
+	contributions []float64 // composite only, aligned with rankSpec.weights
+	percentile    float64   // within the fund's category, 100 = best whatever the sort order
+	quartile      int       // 1..4, 0 when unranked
+}
+
+type rankedFund struct {
+	code      string
+	key       float64 // sort key across windows
+	perWindow map[string]windowRank
+}
+
+// rank orders funds for the given windows. With one window funds are sorted by that window's key.
+// With several, funds are sorted by their mean category percentile across the windows (funds
+// missing a window sort last). maxQuartile > 0 keeps only funds in that quartile or better in
+// every window, e.g. 1 for funds that are top-quartile in all of them.
+func (sp rankSpec) rank(cands []rankCandidate, windows []string, maxQuartile int) []rankedFund {
+	byWindow := make(map[string][]rankCandidate, len(windows))
+	for _, c := range cands {
+		byWindow[c.window] = append(byWindow[c.window], c)
+	}
+
+	funds := map[string]*rankedFund{}
+	var order []string
+	for _, window := range windows {
+		group := byWindow[window]
+		keys, contributions := sp.keys(group)
+		percentiles := categoryPercentiles(group, keys, sp.higherIsBetter())
+		for i, c := range group {
+			f, ok := funds[c.code]
+			if !ok {
+				f = &rankedFund{code: c.code, perWindow: map[string]windowRank{}}
+				funds[c.code] = f
+				order = append(order, c.code)
+			}
+			wr := windowRank{key: keys[i], percentile: percentiles[i], quartile: quartileOf(percentiles[i])}
+			if contributions != nil {
+				wr.contributions = contributions[i]
+			}
+			f.perWindow[window] = wr
+		}
+	}
+
+	out := make([]rankedFund, 0, len(order))
+	for _, code := range order {
+		f := funds[code]
+		if len(windows) == 1 {
+			f.key = f.perWindow[windows[0]].key
+		} else {
+			f.key = meanPercentile(f.perWindow, windows)
+		}
+		if maxQuartile > 0 && !withinQuartile(f.perWindow, windows, maxQuartile) {
+			continue
+		}
+		out = append(out, *f)
+	}
+
+	desc := sp.order == "desc" || len(windows) > 1
+	sort.SliceStable(out, func(a, b int) bool {
+		ka, kb := out[a].key, out[b].key
+		switch {
+		case math.IsNaN(ka) || math.IsNaN(kb):
+			return !math.IsNaN(ka) && math.IsNaN(kb) // missing values last
+		case desc:
+			return ka > kb
+		default:
+			return ka < kb
+		}
+	})
+	return out
+}
+
+// keys computes each candidate's sort key (and composite contributions) within one window.
+func (sp rankSpec) keys(group []rankCandidate) ([]float64, [][]float64) {
+	if len(sp.weights) > 0 {
+		values := make([][]float64, len(sp.weights))
+		for m, wt := range sp.weights {
+			values[m] = make([]float64, len(group))
+			for i, c := range group {
+				values[m][i] = c.values[wt.Metric]
+			}
+		}
+		return analytics.CompositeScores(values, sp.weights, sp.method)
+	}
+	keys := make([]float64, len(group))
+	for i, c := range group {
+		keys[i] = c.values[sp.metric]
+	}
+	return keys, nil
+}
+
+// higherIsBetter reports the direction of the sort key: composite scores are oriented so higher
+// is better, metrics follow analytics.RankMetrics.
+func (sp rankSpec) higherIsBetter() bool {
+	if len(sp.weights) > 0 {
+		return true
+	}
+	return analytics.RankMetrics[sp.metric]
+}
+
+// categoryPercentiles percent-ranks keys within each category, oriented so 100 is the best, as
+// in fund_category_ranks. The requested sort order only changes how funds are listed.
+func categoryPercentiles(group []rankCandidate, keys []float64, higherIsBetter bool) []float64 {
+	idxByCategory := map[string][]int{}
+	for i, c := range group {
+		idxByCategory[c.category] = append(idxByCategory[c.category], i)
+	}
+	out := make([]float64, len(group))
+	for _, idx := range idxByCategory {
+		xs := make([]float64, len(idx))
+		for k, i := range idx {
+			xs[k] = keys[i]
+		}
+		ps := analytics.PercentRanks(xs)
+		for k, i := range idx {
+			if !higherIsBetter && !math.IsNaN(ps[k]) {
+				ps[k] = 100 - ps[k]
+			}
+			out[i] = ps[k]
+		}
+	}
+	return out
+}
+
+func quartileOf(percentile float64) int {
+	switch {
+	case math.IsNaN(percentile):
+		return 0
+	case percentile >= 75:
+		return 1
+	case percentile >= 50:
+		return 2
+	case percentile >= 25:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func meanPercentile(perWindow map[string]windowRank, windows []string) float64 {
+	sum := 0.0
+	for _, w := range windows {
+		wr, ok := perWindow[w]
+		if !ok || math.IsNaN(wr.percentile) {
+			return math.NaN()
+		}
+		sum += wr.percentile
+	}
+	return sum / float64(len(windows))
+}
+
+func withinQuartile(perWindow map[string]windowRank, windows []string, maxQuartile int) bool {
+	for _, w := range windows {
+		wr, ok := perWindow[w]
+		if !ok || wr.quartile == 0 || wr.quartile > maxQuartile {
+			return false
+		}
+	}
+	return true
+}
+
+// metricFields caches, per sqlc row type, the field index of each analytics.RankMetrics column.
+var metricFields sync.Map // reflect.Type -> map[string][]int
+
+// metricColumns returns the analytics.RankMetrics columns of a sqlc row struct. sqlc tags each
+// field with its column name, so every query selecting these columns shares this one mapping.
+func metricColumns(row any) map[string]pgtype.Numeric {
+	v := reflect.ValueOf(row)
+	fields, ok := metricFields.Load(v.Type())
+	if !ok {
+		idx := make(map[string][]int, len(analytics.RankMetrics))
+		for _, f := range reflect.VisibleFields(v.Type()) {
+			name := f.Tag.Get("json")
+			if _, ok := analytics.RankMetrics[name]; ok && f.Type == numericType {
+				idx[name] = f.Index
+			}
+		}
+		fields, _ = metricFields.LoadOrStore(v.Type(), idx)
+	}
+	out := make(map[string]pgtype.Numeric, len(analytics.RankMetrics))
+	for name, index := range fields.(map[string][]int) {
+		out[name] = v.FieldByIndex(index).Interface().(pgtype.Numeric)
+	}
+	return out
+}
+
+var numericType = reflect.TypeOf(pgtype.Numeric{})
+
+func candidateValues(cols map[string]pgtype.Numeric) map[string]float64 {
+	out := make(map[string]float64, len(cols))
+	for name, n := range cols {
+		if v := numericPtr(n); v != nil {
+			out[name] = *v
+		} else {
+			out[name] = math.NaN()
+		}
+	}
+	return out
+}
+
+func currentCandidate(row db.ListRankCandidatesRow) rankCandidate {
+	return rankCandidate{
+		code:     row.SchemeCode,
+		category: row.Category,
+		window:   row.Window,
+		values:   candidateValues(metricColumns(row)),
+	}
+}
+
+func previousCandidate(row db.ListPreviousRankCandidatesRow) rankCandidate {
+	return rankCandidate{
+		code:     row.SchemeCode,
+		category: row.Category,
+		window:   row.Window,
+		values:   candidateValues(metricColumns(row)),
+	}
+}
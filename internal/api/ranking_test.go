@@ -0,0 +1,101 @@
+package api
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/db"
+)
+
+// TestParseRankSpecRequestExample parses the composite query from the ranking request verbatim.
+func TestParseRankSpecRequestExample(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet,
+		"/funds/rank?window=3Y&sort_by=composite&weights=median_return:0.5,max_drawdown:0.3,sharpe:0.2", nil)
+	spec, err := parseRankSpec(r)
+	if err != nil {
+		t.Fatalf("parseRankSpec: %v", err)
+	}
+	want := []string{"rolling_median", "max_drawdown", "sharpe"}
+	if len(spec.weights) != len(want) {
+		t.Fatalf("weights %+v", spec.weights)
+	}
+	for i, w := range spec.weights {
+		if w.Metric != want[i] {
+			t.Errorf("weight %d is %s, want %s", i, w.Metric, want[i])
+		}
+	}
+
+	spec, err = parseRankSpec(httptest.NewRequest(http.MethodGet, "/funds/rank?window=3Y&sort_by=median_return", nil))
+	if err != nil || spec.metric != "rolling_median" || spec.sortBy != "median_return" {
+		t.Fatalf("sort_by=median_return: %+v, %v", spec, err)
+	}
+}
+
+// TestMaxQuartileKeepsBestFunds ranks by metrics whose default order is ascending: the top
+// quartile must still hold the best funds, not the first ones listed.
+func TestMaxQuartileKeepsBestFunds(t *testing.T) {
+	cands := func(metric string, values ...float64) []rankCandidate {
+		out := make([]rankCandidate, len(values))
+		for i, v := range values {
+			out[i] = rankCandidate{
+				code:     string(rune('a' + i)),
+				category: "Equity: Large Cap",
+				window:   "3Y",
+				values:   map[string]float64{metric: v},
+			}
+		}
+		return out
+	}
+	cases := []struct {
+		target string
+		metric string
+		values []float64
+		want   string
+	}{
+		// Drawdowns are negative: -5 is the shallowest.
+		{"/funds/rank?window=3Y&sort_by=max_drawdown&max_quartile=1", "max_drawdown", []float64{-30, -5, -20, -10}, "b"},
+		{"/funds/rank?window=3Y&sort_by=max_drawdown&order=desc&max_quartile=1", "max_drawdown", []float64{-30, -5, -20, -10}, "b"},
+		{"/funds/rank?window=3Y&sort_by=volatility&max_quartile=1", "volatility", []float64{22, 18, 9, 30}, "c"},
+		{"/funds/rank?window=3Y&sort_by=median_return&order=asc&max_quartile=1", "rolling_median", []float64{8, 14, 11, 3}, "b"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.target, func(t *testing.T) {
+			spec, err := parseRankSpec(httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ranked := spec.rank(cands(tc.metric, tc.values...), []string{"3Y"}, 1)
+			if len(ranked) != 1 || ranked[0].code != tc.want {
+				t.Fatalf("top quartile %+v, want only %s", ranked, tc.want)
+			}
+			if p := ranked[0].perWindow["3Y"].percentile; p != 100 {
+				t.Fatalf("best fund has percentile %v, want 100", p)
+			}
+		})
+	}
+}
+
+func TestMetricColumnsCoverRankMetrics(t *testing.T) {
+	want := pgtype.Numeric{Int: big.NewInt(125), Exp: -1, Valid: true}
+	for _, row := range []any{
+		db.FundAnalytic{MaxDrawdown: want},
+		db.ListRankCandidatesRow{MaxDrawdown: want},
+		db.ListPreviousRankCandidatesRow{MaxDrawdown: want},
+		db.ListCategoryHistorySnapshotsRow{MaxDrawdown: want},
+	} {
+		cols := metricColumns(row)
+		for name := range analytics.RankMetrics {
+			if _, ok := cols[name]; !ok {
+				t.Errorf("%T lacks %s", row, name)
+			}
+		}
+		if len(cols) != len(analytics.RankMetrics) || cols["max_drawdown"] != want {
+			t.Errorf("%T: unexpected columns %v", row, cols)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	freqDaily   = "daily"
+	freqWeekly  = "weekly"
+	freqMonthly = "monthly"
+)
+
+func parseFreq(s, def string) (string, error) {
+	if s == "" {
+		return def, nil
+	}
+	switch s {
+	case freqDaily, freqWeekly, freqMonthly:
+		return s, nil
+	default:
+		return "", fmt.Errorf("must be one of %s|%s|%s", freqDaily, freqWeekly, freqMonthly)
+	}
+}
+
+// periodEnds returns the indexes of the last observation in each period for an ascending
+// date series. Daily keeps every observation; weekly buckets by ISO week and monthly by
+// calendar month, so each bucket is represented by its period-end value.
+func periodEnds(dates []time.Time, freq string) []int {
+	out := make([]int, 0, len(dates))
+	for i := range dates {
+		if freq == freqDaily || i == len(dates)-1 || periodKey(dates[i], freq) != periodKey(dates[i+1], freq) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func periodKey(t time.Time, freq string) int {
+	switch freq {
+	case freqWeekly:
+		y, w := t.ISOWeek()
+		return y*100 + w
+	case freqMonthly:
+		return t.Year()*100 + int(t.Month())
+	default:
+		return t.Year()*1000 + t.YearDay()
+	}
+}
+
+// navObs is one NAV observation; filled marks values carried forward over a gap.
+type navObs struct {
+	date   time.Time
+	nav    float64
+	filled bool
+}
+
+// resampleNav buckets ascending observations to freq. With ffill, calendar days (daily) or
+// whole periods (weekly/monthly) with no NAV up to end are filled with the last known value.
+func resampleNav(obs []navObs, freq string, ffill bool, end time.Time) []navObs {
+	if len(obs) == 0 {
+		return obs
+	}
+
+	if ffill && freq == freqDaily {
+		out := make([]navObs, 0, len(obs))
+		for k, o := range obs {
+			out = append(out, o)
+			next := end.AddDate(0, 0, 1)
+			if k+1 < len(obs) {
+				next = obs[k+1].date
+			}
+			for d := o.date.AddDate(0, 0, 1); d.Before(next); d = d.AddDate(0, 0, 1) {
+				out = append(out, navObs{date: d, nav: o.nav, filled: true})
+			}
+		}
+		return out
+	}
+
+	dates := make([]time.Time, len(obs))
+	for i, o := range obs {
+		dates[i] = o.date
+	}
+	picked := make([]navObs, 0, len(obs))
+	for _, i := range periodEnds(dates, freq) {
+		picked = append(picked, obs[i])
+	}
+	if !ffill || freq == freqDaily {
+		return picked
+	}
+
+	out := make([]navObs, 0, len(picked))
+	for k, o := range picked {
+		out = append(out, o)
+		last := k+1 == len(picked)
+		for d := periodEndDate(o.date, freq).AddDate(0, 0, 1); !d.After(end); {
+			pe := periodEndDate(d, freq)
+			if !last && !pe.Before(picked[k+1].date) {
+				break // the next observation falls in this period
+			}
+			if pe.After(end) {
+				pe = end
+			}
+			out = append(out, navObs{date: pe, nav: o.nav, filled: true})
+			d = pe.AddDate(0, 0, 1)
+		}
+	}
+	return out
+}
+
+// periodEndDate returns the last calendar day of the period containing t.
+func periodEndDate(t time.Time, freq string) time.Time {
+	switch freq {
+	case freqWeekly:
+		wd := int(t.Weekday())
+		if wd == 0 {
+			wd = 7
+		}
+		return t.AddDate(0, 0, 7-wd)
+	case freqMonthly:
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()).AddDate(0, 0, -1)
+	default:
+		return t
+	}
+}
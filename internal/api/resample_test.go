@@ -0,0 +1,114 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPeriodEndDate(t *testing.T) {
+	cases := []struct {
+		date, freq, want string
+	}{
+		{"2024-01-01", freqWeekly, "2024-01-07"}, // Monday
+		{"2024-01-05", freqWeekly, "2024-01-07"},
+		{"2024-01-07", freqWeekly, "2024-01-07"}, // Sunday ends its own week
+		{"2024-12-30", freqWeekly, "2025-01-05"},
+		{"2024-02-10", freqMonthly, "2024-02-29"},
+		{"2023-02-28", freqMonthly, "2023-02-28"},
+		{"2023-12-05", freqMonthly, "2023-12-31"},
+		{"2024-03-15", freqDaily, "2024-03-15"},
+	}
+	for _, tc := range cases {
+		if got := periodEndDate(day(t, tc.date), tc.freq).Format(dateLayout); got != tc.want {
+			t.Errorf("periodEndDate(%s, %s) = %s, want %s", tc.date, tc.freq, got, tc.want)
+		}
+	}
+}
+
+func TestResampleNav(t *testing.T) {
+	cases := []struct {
+		name  string
+		obs   []navObs
+		freq  string
+		ffill bool
+		end   string
+		want  []navObs
+	}{
+		{
+			name: "weekly keeps each week's last NAV, the final week partial",
+			obs:  navs(t, "2024-01-01", 10, "2024-01-03", 11, "2024-01-05", 12, "2024-01-09", 13, "2024-01-10", 14),
+			freq: freqWeekly, end: "2024-01-10",
+			want: navs(t, "2024-01-05", 12, "2024-01-10", 14),
+		},
+		{
+			name: "monthly keeps each month's last NAV",
+			obs:  navs(t, "2024-01-15", 10, "2024-01-31", 11, "2024-02-10", 12, "2024-02-28", 13, "2024-03-04", 14),
+			freq: freqMonthly, end: "2024-03-04",
+			want: navs(t, "2024-01-31", 11, "2024-02-28", 13, "2024-03-04", 14),
+		},
+		{
+			name: "daily ffill covers the weekend and runs to end",
+			obs:  navs(t, "2024-01-05", 10, "2024-01-08", 11),
+			freq: freqDaily, ffill: true, end: "2024-01-09",
+			want: []navObs{
+				navAt(t, "2024-01-05", 10, false), navAt(t, "2024-01-06", 10, true), navAt(t, "2024-01-07", 10, true),
+				navAt(t, "2024-01-08", 11, false), navAt(t, "2024-01-09", 11, true),
+			},
+		},
+		{
+			name: "weekly ffill fills empty weeks and ends the partial week at end",
+			obs:  navs(t, "2024-01-05", 10, "2024-01-24", 12),
+			freq: freqWeekly, ffill: true, end: "2024-02-02",
+			want: []navObs{
+				navAt(t, "2024-01-05", 10, false), navAt(t, "2024-01-14", 10, true), navAt(t, "2024-01-21", 10, true),
+				navAt(t, "2024-01-24", 12, false), navAt(t, "2024-02-02", 12, true),
+			},
+		},
+		{
+			name: "monthly ffill fills empty months and ends the partial month at end",
+			obs:  navs(t, "2024-01-31", 10, "2024-04-15", 12),
+			freq: freqMonthly, ffill: true, end: "2024-05-20",
+			want: []navObs{
+				navAt(t, "2024-01-31", 10, false), navAt(t, "2024-02-29", 10, true), navAt(t, "2024-03-31", 10, true),
+				navAt(t, "2024-04-15", 12, false), navAt(t, "2024-05-20", 12, true),
+			},
+		},
+		{
+			name: "no observations",
+			freq: freqMonthly, ffill: true, end: "2024-05-20",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resampleNav(tc.obs, tc.freq, tc.ffill, day(t, tc.end))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got  %v\nwant %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func day(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func navAt(t *testing.T, date string, nav float64, filled bool) navObs {
+	t.Helper()
+	return navObs{date: day(t, date), nav: nav, filled: filled}
+}
+
+// navs builds unfilled observations from date, nav pairs.
+func navs(t *testing.T, pairs ...any) []navObs {
+	t.Helper()
+	var out []navObs
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, navAt(t, pairs[i].(string), float64(pairs[i+1].(int)), false))
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// cachedResponse is a 200 response body kept by s.cached.
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	validators  validators
+}
+
+// cached serves repeated GETs of h from s.responses. Only 200s are stored, keyed by path and
+// the normalized query, so errors and empty results are always recomputed. Every stored
+// response derives from synced data only; the cache is purged when a sync run finishes.
+func (s *Server) cached(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.responses == nil {
+			h(w, r)
+			return
+		}
+		key := r.URL.Path + "?" + r.URL.Query().Encode()
+		if v, ok := s.responses.Get(key); ok {
+			resp := v.(cachedResponse)
+			w.Header().Set("X-Cache", "HIT")
+			if !resp.validators.isZero() && s.notModified(w, r, resp.validators) {
+				return
+			}
+			w.Header().Set("Content-Type", resp.contentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(resp.body)
+			return
+		}
+
+		gen := s.responses.Generation()
+		w.Header().Set("X-Cache", "MISS")
+		cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		h(cw, r)
+		if cw.status == http.StatusOK {
+			s.responses.SetIfGeneration(key, cachedResponse{
+				contentType: w.Header().Get("Content-Type"),
+				body:        cw.body.Bytes(),
+				validators:  validatorsFromHeader(w.Header()),
+			}, gen)
+		}
+	}
+}
+
+// captureWriter passes a response through while keeping a copy of its status and body.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// purgeCaches drops everything derived from synced data.
+func (s *Server) purgeCaches() {
+	if s.responses != nil {
+		s.responses.Purge()
+	}
+	s.onDemand.Purge()
+}
+
+// invalidateOnSync LISTENs on s.syncChannel and purges the caches on every
+// notification, so all replicas drop stale responses when any worker finishes a run. It
+// reconnects with backoff until ctx is cancelled; each (re)connect purges as well, since
+// notifications sent while it wasn't listening are lost.
+func (s *Server) invalidateOnSync(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := s.listenForSync(ctx, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return
+		}
+		if s.log != nil {
+			s.log.Warn("cache invalidation listener failed", "error", err, "retry_in", backoff.String())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (s *Server) listenForSync(ctx context.Context, connected func()) error {
+	pc, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection stays in LISTEN mode, so it must never go back to the pool.
+	conn := pc.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{s.syncChannel}.Sanitize()); err != nil {
+		return err
+	}
+	connected()
+	s.purgeCaches()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		s.purgeCaches()
+		if s.log != nil {
+			s.log.Info("caches purged", "reason", "sync completed", "run_id", n.Payload)
+		}
+	}
+}
@@ -1,10 +1,51 @@
 package api
 
+import (
+	"expvar"
+
+	"mf-analytics-service/internal/apikey"
+)
+
+// routes registers every route with the scope its API key needs; apiOperations documents the
+// same scopes.
 func (s *Server) routes() {
-	s.r.Get("/funds", s.handleFundsList())
-	s.r.Get("/funds/rank", s.handleFundsRank())
-	s.r.Get("/funds/{code}", s.handleFundDetails())
-	s.r.Get("/funds/{code}/analytics", s.handleFundAnalytics())
-	s.r.Post("/sync/trigger", s.handleSyncTrigger())
-	s.r.Get("/sync/status", s.handleSyncStatus())
+	s.r.Get("/openapi.json", s.handleOpenAPI())
+
+	read := s.r.With(s.requireScope(apikey.ScopeRead))
+	read.Get("/analytics/correlation", s.handleCorrelationMatrix())
+	read.Get("/categories/{category}/analytics", s.handleCategoryAnalytics())
+	read.Get("/funds", s.handleFundsList())
+	read.Get("/funds/compare", s.handleFundsCompare())
+	read.Get("/funds/rank", s.cached(s.handleFundsRank()))
+	read.Get("/funds/{code}", s.cached(s.handleFundDetails()))
+	read.Get("/funds/{code}/analytics", s.cached(s.handleFundAnalytics()))
+	read.Get("/funds/{code}/nav", s.handleFundNav())
+	read.Get("/funds/{code}/projection", s.handleFundProjection())
+	read.Get("/funds/{code}/rank-history", s.handleFundRankHistory())
+	read.Get("/funds/{code}/rolling-returns", s.handleFundRollingReturns())
+	read.Get("/funds/{code}/returns", s.handleFundReturns())
+	read.Get("/funds/{code}/sip", s.handleFundSIP())
+	read.Get("/funds/{code}/sip/rolling", s.handleFundRollingSIP())
+	read.Get("/portfolios", s.handlePortfoliosList())
+	read.Get("/portfolios/{id}", s.handlePortfolioGet())
+	read.Get("/portfolios/{id}/analytics", s.handlePortfolioAnalytics())
+	read.Get("/portfolios/{id}/nav", s.handlePortfolioNav())
+	read.Get("/portfolios/{id}/projection", s.handlePortfolioProjection())
+	read.Get("/sync/status", s.handleSyncStatus())
+
+	sync := s.r.With(s.requireScope(apikey.ScopeSyncTrigger))
+	sync.Post("/sync/trigger", s.handleSyncTrigger())
+
+	admin := s.r.With(s.requireScope(apikey.ScopeAdmin))
+	admin.Get("/debug/vars", expvar.Handler().ServeHTTP)
+	admin.Post("/portfolios", s.handlePortfolioCreate())
+	admin.Put("/portfolios/{id}", s.handlePortfolioUpdate())
+	admin.Delete("/portfolios/{id}", s.handlePortfolioDelete())
+	// Investor data is keyed by guessable user ids, and keys aren't bound to a user, so only
+	// admin keys may read it.
+	admin.Get("/users/{id}/capital-gains", s.handleUserCapitalGains())
+	admin.Get("/users/{id}/portfolio", s.handleUserPortfolio())
+	admin.Get("/users/{id}/portfolio/{code}", s.handleUserPortfolioFund())
+	admin.Get("/users/{id}/transactions", s.handleUserTransactions())
+	admin.Post("/users/{id}/transactions/import", s.handleUserTransactionsImport())
 }
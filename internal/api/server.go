@@ -9,6 +9,10 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robfig/cron/v3"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/config"
 )
 
 type Server struct {
@@ -16,20 +20,97 @@ type Server struct {
 	r    *chi.Mux
 	srv  *http.Server
 	log  *slog.Logger
+
+	windows []analytics.WindowSpec
+	// riskFreePct is the rate on-demand and portfolio analytics measure Sharpe and consistency
+	// against.
+	riskFreePct float64
+	// onDemand caches analytics computed for windows outside the precomputed set.
+	onDemand *lruCache
+	// responses caches fund details, analytics and ranking responses until the next sync run
+	// completes; nil disables it.
+	responses *lruCache
+	// syncChannel is the NOTIFY channel finished sync runs are announced on; both caches are
+	// purged on each notification. Empty leaves entries to expire by TTL.
+	syncChannel string
+	// syncSchedule is when cmd/cron enqueues incremental syncs; analytics responses may be cached
+	// by clients until its next run. nil makes clients revalidate every time.
+	syncSchedule cron.Schedule
+	// stopListening ends the cache invalidation listener.
+	stopListening context.CancelFunc
+	// keys authenticates requests; nil serves every route without a key.
+	keys       KeyVerifier
+	keyLimiter KeyLimiter
+}
+
+type Option func(*Server)
+
+// WithWindows sets the precomputed analytics windows the API accepts for ranking and reads.
+func WithWindows(windows []analytics.WindowSpec) Option {
+	return func(s *Server) { s.windows = windows }
 }
 
-func NewServer(pool *pgxpool.Pool, logger *slog.Logger) *Server {
+// WithRiskFreeRate sets the annual risk-free rate (%) of analytics computed on request. It should
+// match the worker's, so on-demand windows agree with precomputed ones.
+func WithRiskFreeRate(pct float64) Option {
+	return func(s *Server) { s.riskFreePct = pct }
+}
+
+// WithResponseCache sets how long cached responses live and how many are kept, and the NOTIFY
+// channel (events.SyncCompleted) that purges them when a sync run finishes. A ttl <= 0 disables
+// the response cache; the channel still purges on-demand analytics.
+func WithResponseCache(ttl time.Duration, maxEntries int, syncChannel string) Option {
+	return func(s *Server) {
+		s.syncChannel = syncChannel
+		if ttl <= 0 || maxEntries <= 0 {
+			s.responses = nil
+			return
+		}
+		s.responses = newLRUCache("responses", ttl, maxEntries)
+	}
+}
+
+// WithSyncSchedule aligns the Cache-Control max-age of analytics responses with the next
+// scheduled incremental sync.
+func WithSyncSchedule(schedule cron.Schedule) Option {
+	return func(s *Server) { s.syncSchedule = schedule }
+}
+
+// WithAPIKeys requires an API key with the route's scope on every route but /openapi.json, and
+// meters each key with limiter when it is non-nil.
+func WithAPIKeys(keys KeyVerifier, limiter KeyLimiter) Option {
+	return func(s *Server) {
+		s.keys = keys
+		s.keyLimiter = limiter
+	}
+}
+
+func NewServer(pool *pgxpool.Pool, logger *slog.Logger, opts ...Option) *Server {
 	s := &Server{
-		pool: pool,
-		r:    chi.NewRouter(),
-		log:  logger,
+		pool:        pool,
+		r:           chi.NewRouter(),
+		log:         logger,
+		windows:     analytics.DefaultWindows,
+		riskFreePct: analytics.DefaultRiskFreeRatePct,
+		onDemand:    newLRUCache("on_demand_analytics", 15*time.Minute, 1024),
+		responses:   newLRUCache("responses", config.DefaultResponseCacheTTL, config.DefaultResponseCacheEntries),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
-	s.routes()
 
-	// Standard middleware set for observability and safety.
+	// Standard middleware set for observability and safety. chi requires it before any route.
 	s.r.Use(middleware.RequestID)
 	s.r.Use(middleware.Recoverer)
 	s.r.Use(s.requestLogger())
+	s.r.Use(s.authenticate)
+	s.routes()
+
+	if pool != nil && s.syncChannel != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopListening = cancel
+		go s.invalidateOnSync(ctx)
+	}
 
 	s.srv = &http.Server{
 		Handler:      s.r,
@@ -46,5 +127,8 @@ func (s *Server) ListenAndServe(addr string) error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.stopListening != nil {
+		s.stopListening()
+	}
 	return s.srv.Shutdown(ctx)
 }
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"math"
 	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const timeRFC3339 = "2006-01-02T15:04:05Z07:00"
@@ -9,3 +13,39 @@ const timeRFC3339 = "2006-01-02T15:04:05Z07:00"
 func strconvParseFloat(s string) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
+
+const dateLayout = "2006-01-02"
+
+// parseDateParam parses an optional YYYY-MM-DD query parameter.
+func parseDateParam(s string) (pgtype.Date, error) {
+	if s == "" {
+		return pgtype.Date{}, nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return pgtype.Date{}, err
+	}
+	return pgtype.Date{Time: t, Valid: true}, nil
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+// roundPtr rounds v, mapping NaN/Inf (undefined metrics) to nil.
+func roundPtr(v float64, places int) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	r := round(v, places)
+	return &r
+}
+
+// float8Ptr rounds a nullable double, mapping NULL to nil.
+func float8Ptr(f pgtype.Float8, places int) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return roundPtr(f.Float64, places)
+}
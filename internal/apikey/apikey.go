@@ -0,0 +1,207 @@
+// Package apikey issues and verifies the API keys clients send to cmd/api. Keys are stored in
+// `api_keys` as a SHA-256 of their secret, so a key is only ever shown when it is created.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// Scopes a key can be granted. admin implies the others.
+const (
+	ScopeRead        = "read"
+	ScopeSyncTrigger = "sync:trigger"
+	ScopeAdmin       = "admin"
+)
+
+// Scopes lists every scope, for validation and help text.
+var Scopes = []string{ScopeRead, ScopeSyncTrigger, ScopeAdmin}
+
+// prefix marks the plaintext as one of our keys, so leaked keys are easy to grep for.
+const prefix = "mfa_"
+
+var (
+	// ErrInvalidKey is a key that is malformed, unknown, revoked or has the wrong secret. Callers
+	// can't tell which, on purpose.
+	ErrInvalidKey = errors.New("invalid api key")
+	ErrNotFound   = errors.New("api key not found")
+)
+
+// Key is a stored key without its secret.
+type Key struct {
+	ID        string
+	Name      string
+	Scopes    []string
+	CreatedAt time.Time
+	// RevokedAt is zero for active keys.
+	RevokedAt time.Time
+}
+
+// Allows reports whether k grants scope.
+func (k Key) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScopes rejects an empty list and scopes other than Scopes.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required (%s)", strings.Join(Scopes, ", "))
+	}
+	for _, s := range scopes {
+		if !validScope(s) {
+			return fmt.Errorf("unknown scope %q, use %s", s, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Store reads and writes `api_keys`.
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+// Create stores a new key and returns it with its plaintext, which can't be recovered later.
+func (s *Store) Create(ctx context.Context, name string, scopes []string) (Key, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return Key{}, "", errors.New("a key needs a name")
+	}
+	if err := ValidateScopes(scopes); err != nil {
+		return Key{}, "", err
+	}
+	id, secret, err := generate()
+	if err != nil {
+		return Key{}, "", err
+	}
+	if err := db.New(s.pool).CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		KeyID:      id,
+		Name:       name,
+		SecretHash: hashSecret(secret),
+		Scopes:     scopes,
+	}); err != nil {
+		return Key{}, "", err
+	}
+	return Key{ID: id, Name: name, Scopes: scopes, CreatedAt: time.Now().UTC()}, format(id, secret), nil
+}
+
+// Revoke disables key id immediately. Revoking an already revoked key is ErrNotFound.
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	n, err := db.New(s.pool).RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns every key, revoked ones included, oldest first.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	rows, err := db.New(s.pool).ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(rows))
+	for _, r := range rows {
+		keys = append(keys, fromRow(r))
+	}
+	return keys, nil
+}
+
+// Verify returns the active key whose plaintext is token, or ErrInvalidKey.
+func (s *Store) Verify(ctx context.Context, token string) (Key, error) {
+	id, secret, ok := parse(token)
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+	row, err := db.New(s.pool).GetAPIKey(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Key{}, ErrInvalidKey
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	if subtle.ConstantTimeCompare(row.SecretHash, hashSecret(secret)) != 1 || row.RevokedAt.Valid {
+		return Key{}, ErrInvalidKey
+	}
+	return fromRow(row), nil
+}
+
+func fromRow(r db.ApiKey) Key {
+	k := Key{ID: r.KeyID, Name: r.Name, Scopes: r.Scopes, CreatedAt: r.CreatedAt.Time}
+	if r.RevokedAt.Valid {
+		k.RevokedAt = r.RevokedAt.Time
+	}
+	return k
+}
+
+// generate returns a random 16-hex-digit id and a 256-bit secret.
+func generate() (id, secret string, err error) {
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(idBytes), base64.RawURLEncoding.EncodeToString(secretBytes), nil
+}
+
+// format is the plaintext clients send: mfa_<id>_<secret>.
+func format(id, secret string) string {
+	return prefix + id + "_" + secret
+}
+
+// parse splits a plaintext key. The id is hex, so the first '_' after the prefix ends it even
+// though the secret may contain more.
+func parse(token string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(token, prefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || len(id) != 16 || secret == "" {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
@@ -0,0 +1,64 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		id, secret, err := generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := format(id, secret)
+		if !strings.HasPrefix(token, prefix) {
+			t.Fatalf("missing prefix: %s", token)
+		}
+		gotID, gotSecret, ok := parse(token)
+		if !ok || gotID != id || gotSecret != secret {
+			t.Fatalf("parse(%s) = %s, %s, %v", token, gotID, gotSecret, ok)
+		}
+	}
+}
+
+func TestParseRejectsMalformedKeys(t *testing.T) {
+	for _, token := range []string{
+		"",
+		"0123456789abcdef_secret",
+		"mfa_0123456789abcdef",
+		"mfa_0123456789abcdef_",
+		"mfa_0123456789abcde_secret",
+		"mfa_0123456789abcdeg_secret",
+		"Bearer mfa_0123456789abcdef_secret",
+	} {
+		if _, _, ok := parse(token); ok {
+			t.Errorf("parse(%q) accepted", token)
+		}
+	}
+}
+
+func TestKeyAllows(t *testing.T) {
+	read := Key{Scopes: []string{ScopeRead}}
+	if !read.Allows(ScopeRead) || read.Allows(ScopeSyncTrigger) || read.Allows(ScopeAdmin) {
+		t.Fatalf("read key scopes wrong")
+	}
+	admin := Key{Scopes: []string{ScopeAdmin}}
+	for _, s := range Scopes {
+		if !admin.Allows(s) {
+			t.Errorf("admin should allow %s", s)
+		}
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeRead, ScopeSyncTrigger}); err != nil {
+		t.Fatal(err)
+	}
+	if ValidateScopes(nil) == nil {
+		t.Error("empty scopes accepted")
+	}
+	if ValidateScopes([]string{"write"}) == nil {
+		t.Error("unknown scope accepted")
+	}
+}
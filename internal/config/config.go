@@ -2,17 +2,73 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"mf-analytics-service/internal/ratelimiter"
+	"github.com/robfig/cron/v3"
 	"gopkg.in/yaml.v3"
+
+	"mf-analytics-service/internal/analytics"
+	"mf-analytics-service/internal/ratelimiter"
 )
 
 type Config struct {
 	HTTPAddr    string          `yaml:"http_addr"`
 	DatabaseURL string          `yaml:"database_url"`
 	RateLimiter RateLimiterYAML `yaml:"rate_limiter"`
+	Analytics   AnalyticsYAML   `yaml:"analytics"`
+	Cache       CacheYAML       `yaml:"cache"`
+	Cron        CronYAML        `yaml:"cron"`
+	Auth        AuthYAML        `yaml:"auth"`
+}
+
+type AuthYAML struct {
+	// Disabled serves every route without an API key, for local development only.
+	Disabled bool `yaml:"disabled"`
+	// RateLimiter are the fixed windows each API key gets to itself; unset uses
+	// ratelimiter.DefaultKeyConfig.
+	RateLimiter RateLimiterYAML `yaml:"rate_limiter"`
+}
+
+type CronYAML struct {
+	// Incremental is the standard cron spec on which cmd/cron enqueues the incremental sync, in
+	// the TZ location. The API aligns analytics Cache-Control with it.
+	Incremental string `yaml:"incremental"`
+}
+
+type CacheYAML struct {
+	// TTL bounds how long an API response is served from memory, e.g. "10m"; "0" disables the
+	// response cache. Entries are also dropped whenever a sync run completes.
+	TTL string `yaml:"ttl"`
+	// MaxEntries caps the cached responses; the least recently used are evicted first.
+	MaxEntries int `yaml:"max_entries"`
+}
+
+type AnalyticsYAML struct {
+	// Windows are precomputed for every fund, e.g. ["6M", "1Y", "18M", "3Y", "5Y", "7Y", "10Y"].
+	Windows []string `yaml:"windows"`
+	// Incremental updates analytics after a daily sync from the stored rolling series and the
+	// new NAVs only, instead of recomputing from the full history.
+	Incremental bool `yaml:"incremental"`
+	// HistoryRetentionDays prunes fund_analytics_history snapshots older than this; 0 keeps all.
+	HistoryRetentionDays int `yaml:"history_retention_days"`
+	// RiskFreeRatePct is the annual risk-free rate (%) Sharpe ratios and consistency are measured
+	// against; unset uses analytics.DefaultRiskFreeRatePct.
+	RiskFreeRatePct *float64 `yaml:"risk_free_rate_pct"`
+	// Correlation configures the scheduled pairwise correlation job.
+	Correlation CorrelationYAML `yaml:"correlation"`
+}
+
+type CorrelationYAML struct {
+	// Windows are trailing windows ending at the latest NAV, e.g. ["1Y", "3Y"].
+	Windows []string `yaml:"windows"`
+	// Frequencies of the correlated returns: daily and/or weekly.
+	Frequencies []string `yaml:"frequencies"`
+	// MinObservations is the fewest common returns a pair needs to get a correlation.
+	MinObservations int `yaml:"min_observations"`
 }
 
 type RateLimiterYAML struct {
@@ -50,6 +106,66 @@ func Load() (Config, error) {
 	if cfg.HTTPAddr == "" {
 		cfg.HTTPAddr = ":8080"
 	}
+	if v := os.Getenv("ANALYTICS_WINDOWS"); v != "" {
+		cfg.Analytics.Windows = splitList(v)
+	}
+	if v := os.Getenv("ANALYTICS_INCREMENTAL"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse ANALYTICS_INCREMENTAL: %w", err)
+		}
+		cfg.Analytics.Incremental = b
+	}
+	if v := os.Getenv("ANALYTICS_HISTORY_RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse ANALYTICS_HISTORY_RETENTION_DAYS: %w", err)
+		}
+		cfg.Analytics.HistoryRetentionDays = n
+	}
+	if v := os.Getenv("ANALYTICS_RISK_FREE_RATE_PCT"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse ANALYTICS_RISK_FREE_RATE_PCT: %w", err)
+		}
+		cfg.Analytics.RiskFreeRatePct = &f
+	}
+	if v := os.Getenv("ANALYTICS_CORRELATION_WINDOWS"); v != "" {
+		cfg.Analytics.Correlation.Windows = splitList(v)
+	}
+	if v := os.Getenv("ANALYTICS_CORRELATION_FREQUENCIES"); v != "" {
+		cfg.Analytics.Correlation.Frequencies = splitList(v)
+	}
+	if v := os.Getenv("ANALYTICS_CORRELATION_MIN_OBSERVATIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse ANALYTICS_CORRELATION_MIN_OBSERVATIONS: %w", err)
+		}
+		cfg.Analytics.Correlation.MinObservations = n
+	}
+	if v := os.Getenv("INCREMENTAL_CRON"); v != "" {
+		cfg.Cron.Incremental = v
+	}
+	if cfg.Cron.Incremental == "" {
+		cfg.Cron.Incremental = DefaultIncrementalCron
+	}
+	if v := os.Getenv("API_CACHE_TTL"); v != "" {
+		cfg.Cache.TTL = v
+	}
+	if v := os.Getenv("API_CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse API_CACHE_MAX_ENTRIES: %w", err)
+		}
+		cfg.Cache.MaxEntries = n
+	}
+	if v := os.Getenv("API_AUTH_DISABLED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse API_AUTH_DISABLED: %w", err)
+		}
+		cfg.Auth.Disabled = b
+	}
 
 	return cfg, nil
 }
@@ -58,31 +174,204 @@ func (c Config) Validate() error {
 	if c.DatabaseURL == "" {
 		return fmt.Errorf("database_url/DATABASE_URL is required")
 	}
-	for _, w := range c.RateLimiter.Windows {
+	if err := validateWindows("rate_limiter", c.RateLimiter.Windows); err != nil {
+		return err
+	}
+	if err := validateWindows("auth.rate_limiter", c.Auth.RateLimiter.Windows); err != nil {
+		return err
+	}
+	if c.Analytics.HistoryRetentionDays < 0 {
+		return fmt.Errorf("analytics.history_retention_days must be >= 0")
+	}
+	if _, err := c.AnalyticsWindows(); err != nil {
+		return err
+	}
+	if _, err := c.RiskFreeRatePct(); err != nil {
+		return err
+	}
+	if _, err := c.CorrelationConfig(); err != nil {
+		return err
+	}
+	if _, _, err := c.ResponseCache(); err != nil {
+		return err
+	}
+	if _, err := c.IncrementalSchedule(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateWindows(field string, windows []RateLimiterWindowYAML) error {
+	for _, w := range windows {
 		if w.Type == "" {
-			return fmt.Errorf("rate_limiter.windows[].type is required")
+			return fmt.Errorf("%s.windows[].type is required", field)
 		}
 		if w.Limit <= 0 {
-			return fmt.Errorf("rate_limiter.windows[%s].limit must be > 0", w.Type)
+			return fmt.Errorf("%s.windows[%s].limit must be > 0", field, w.Type)
 		}
 		d, err := time.ParseDuration(w.Duration)
 		if err != nil || d <= 0 {
 			return fmt.Errorf(
-				"rate_limiter.windows[%s].duration must be valid duration (e.g. 1s, 1m, 1h)",
-				w.Type,
+				"%s.windows[%s].duration must be valid duration (e.g. 1s, 1m, 1h)",
+				field, w.Type,
 			)
 		}
 	}
 	return nil
 }
 
+// AnalyticsWindows returns the configured precomputed windows, or analytics.DefaultWindows.
+func (c Config) AnalyticsWindows() ([]analytics.WindowSpec, error) {
+	if len(c.Analytics.Windows) == 0 {
+		return analytics.DefaultWindows, nil
+	}
+	return parseWindows("analytics.windows", c.Analytics.Windows)
+}
+
+// RiskFreeRatePct returns the configured risk-free rate, or analytics.DefaultRiskFreeRatePct.
+func (c Config) RiskFreeRatePct() (float64, error) {
+	if c.Analytics.RiskFreeRatePct == nil {
+		return analytics.DefaultRiskFreeRatePct, nil
+	}
+	pct := *c.Analytics.RiskFreeRatePct
+	if math.IsNaN(pct) || pct < 0 || pct >= 100 {
+		return 0, fmt.Errorf("analytics.risk_free_rate_pct must be in [0, 100), got %v", pct)
+	}
+	return pct, nil
+}
+
+// CorrelationConfig returns the correlation job settings, defaulting each unset field from
+// analytics.DefaultCorrelationConfig.
+func (c Config) CorrelationConfig() (analytics.CorrelationConfig, error) {
+	cc := c.Analytics.Correlation
+	out := analytics.DefaultCorrelationConfig
+	if len(cc.Windows) > 0 {
+		windows, err := parseWindows("analytics.correlation.windows", cc.Windows)
+		if err != nil {
+			return analytics.CorrelationConfig{}, err
+		}
+		out.Windows = windows
+	}
+	if len(cc.Frequencies) > 0 {
+		for _, f := range cc.Frequencies {
+			if f != analytics.FrequencyDaily && f != analytics.FrequencyWeekly {
+				return analytics.CorrelationConfig{}, fmt.Errorf("analytics.correlation.frequencies: %q must be daily or weekly", f)
+			}
+		}
+		out.Frequencies = cc.Frequencies
+	}
+	if cc.MinObservations < 0 {
+		return analytics.CorrelationConfig{}, fmt.Errorf("analytics.correlation.min_observations must be >= 0")
+	}
+	if cc.MinObservations > 0 {
+		out.MinObservations = cc.MinObservations
+	}
+	return out, nil
+}
+
+// DefaultIncrementalCron enqueues the incremental sync daily at 02:00.
+const DefaultIncrementalCron = "0 2 * * *"
+
+// IncrementalSchedule parses cron.incremental, evaluated in the TZ location (UTC when unset or
+// unknown) unless the spec sets its own CRON_TZ.
+func (c Config) IncrementalSchedule() (cron.Schedule, error) {
+	spec := c.Cron.Incremental
+	if spec == "" {
+		spec = DefaultIncrementalCron
+	}
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("cron.incremental: %w", err)
+	}
+	if ss, ok := sched.(*cron.SpecSchedule); ok && ss.Location == time.Local {
+		ss.Location = Location()
+	}
+	return sched, nil
+}
+
+// Location is the TZ location cron schedules run in, defaulting to UTC.
+func Location() *time.Location {
+	if tz := os.Getenv("TZ"); tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			return l
+		}
+	}
+	return time.UTC
+}
+
+// Response cache defaults. Invalidation on sync completion keeps entries fresh; the TTL only
+// bounds staleness if a notification is missed.
+const (
+	DefaultResponseCacheTTL     = 10 * time.Minute
+	DefaultResponseCacheEntries = 4096
+)
+
+// ResponseCache returns the API response cache TTL and size, defaulting unset fields from
+// DefaultResponseCacheTTL and DefaultResponseCacheEntries. A zero TTL disables the cache.
+func (c Config) ResponseCache() (time.Duration, int, error) {
+	ttl, maxEntries := DefaultResponseCacheTTL, DefaultResponseCacheEntries
+	if c.Cache.TTL != "" {
+		d, err := time.ParseDuration(c.Cache.TTL)
+		if err != nil || d < 0 {
+			return 0, 0, fmt.Errorf("cache.ttl must be a valid duration >= 0 (e.g. 10m), got %q", c.Cache.TTL)
+		}
+		ttl = d
+	}
+	if c.Cache.MaxEntries < 0 {
+		return 0, 0, fmt.Errorf("cache.max_entries must be >= 0")
+	}
+	if c.Cache.MaxEntries > 0 {
+		maxEntries = c.Cache.MaxEntries
+	}
+	return ttl, maxEntries, nil
+}
+
+func parseWindows(field string, labels []string) ([]analytics.WindowSpec, error) {
+	seen := make(map[string]bool, len(labels))
+	windows := make([]analytics.WindowSpec, 0, len(labels))
+	for _, label := range labels {
+		w, err := analytics.ParseWindow(label)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+		if len(w.Label) > analytics.MaxWindowLabelLen {
+			return nil, fmt.Errorf("%s: %q is longer than %d characters", field, label, analytics.MaxWindowLabelLen)
+		}
+		if seen[w.Label] {
+			return nil, fmt.Errorf("%s: duplicate window %q", field, label)
+		}
+		seen[w.Label] = true
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func (c Config) RateLimiterConfig() (ratelimiter.Config, error) {
-	if len(c.RateLimiter.Windows) == 0 {
-		return ratelimiter.DefaultConfig(), nil
+	return limiterConfig(c.RateLimiter, ratelimiter.DefaultConfig())
+}
+
+// KeyRateLimiterConfig returns the windows of each API key, or ratelimiter.DefaultKeyConfig.
+func (c Config) KeyRateLimiterConfig() (ratelimiter.Config, error) {
+	return limiterConfig(c.Auth.RateLimiter, ratelimiter.DefaultKeyConfig())
+}
+
+func limiterConfig(y RateLimiterYAML, def ratelimiter.Config) (ratelimiter.Config, error) {
+	if len(y.Windows) == 0 {
+		return def, nil
 	}
 
-	windows := make([]ratelimiter.WindowConfig, 0, len(c.RateLimiter.Windows))
-	for _, w := range c.RateLimiter.Windows {
+	windows := make([]ratelimiter.WindowConfig, 0, len(y.Windows))
+	for _, w := range y.Windows {
 		d, err := time.ParseDuration(w.Duration)
 		if err != nil || d <= 0 {
 			return ratelimiter.Config{}, fmt.Errorf("invalid rate limiter duration for %q: %q", w.Type, w.Duration)
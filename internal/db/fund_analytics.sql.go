@@ -9,11 +9,10 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/shopspring/decimal"
 )
 
 const getFundAnalytics = `-- name: GetFundAnalytics :one
-SELECT scheme_code, "window", rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75, max_drawdown, cagr_min, cagr_max, cagr_median, data_start_date, data_end_date, nav_points, rolling_periods, computed_at
+SELECT scheme_code, "window", rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75, max_drawdown, cagr_min, cagr_max, cagr_median, data_start_date, data_end_date, nav_points, rolling_periods, computed_at, distribution, volatility, sharpe, consistency, risk_free_rate
 FROM fund_analytics
 WHERE scheme_code = $1
   AND "window" = $2
@@ -44,73 +43,193 @@ func (q *Queries) GetFundAnalytics(ctx context.Context, arg GetFundAnalyticsPara
 		&i.NavPoints,
 		&i.RollingPeriods,
 		&i.ComputedAt,
+		&i.Distribution,
+		&i.Volatility,
+		&i.Sharpe,
+		&i.Consistency,
+		&i.RiskFreeRate,
 	)
 	return i, err
 }
 
-const rankFundsByMaxDrawdown = `-- name: RankFundsByMaxDrawdown :many
+const getFundAnalyticsState = `-- name: GetFundAnalyticsState :one
+SELECT scheme_code, "window", last_end_date, worst_drawdown, updated_at
+FROM fund_analytics_state
+WHERE scheme_code = $1
+  AND "window" = $2
+`
+
+type GetFundAnalyticsStateParams struct {
+	SchemeCode string `json:"scheme_code"`
+	Window     string `json:"window"`
+}
+
+func (q *Queries) GetFundAnalyticsState(ctx context.Context, arg GetFundAnalyticsStateParams) (FundAnalyticsState, error) {
+	row := q.db.QueryRow(ctx, getFundAnalyticsState, arg.SchemeCode, arg.Window)
+	var i FundAnalyticsState
+	err := row.Scan(
+		&i.SchemeCode,
+		&i.Window,
+		&i.LastEndDate,
+		&i.WorstDrawdown,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getFundAnalyticsVersion = `-- name: GetFundAnalyticsVersion :one
 SELECT
-  fa.scheme_code,
-  f.scheme_name,
-  f.amc,
+  MAX(computed_at)::timestamp AS computed_at,
+  MAX(data_end_date)::date AS data_end_date,
+  COUNT(*) AS analytics_rows
+FROM fund_analytics
+WHERE "window" = ANY($1::text[])
+`
+
+type GetFundAnalyticsVersionRow struct {
+	ComputedAt    pgtype.Timestamp `json:"computed_at"`
+	DataEndDate   pgtype.Date      `json:"data_end_date"`
+	AnalyticsRows int64            `json:"analytics_rows"`
+}
+
+// The newest computation and NAV date over the windows; with the row count they change whenever
+// any fund's analytics in those windows do.
+func (q *Queries) GetFundAnalyticsVersion(ctx context.Context, windows []string) (GetFundAnalyticsVersionRow, error) {
+	row := q.db.QueryRow(ctx, getFundAnalyticsVersion, windows)
+	var i GetFundAnalyticsVersionRow
+	err := row.Scan(&i.ComputedAt, &i.DataEndDate, &i.AnalyticsRows)
+	return i, err
+}
+
+const listFundAnalyticsForSchemes = `-- name: ListFundAnalyticsForSchemes :many
+SELECT scheme_code, "window", rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75, max_drawdown, cagr_min, cagr_max, cagr_median, data_start_date, data_end_date, nav_points, rolling_periods, computed_at, distribution, volatility, sharpe, consistency, risk_free_rate
+FROM fund_analytics
+WHERE scheme_code = ANY($1::text[])
+  AND "window" = ANY($2::text[])
+ORDER BY scheme_code ASC, "window" ASC
+`
+
+type ListFundAnalyticsForSchemesParams struct {
+	SchemeCodes []string `json:"scheme_codes"`
+	Windows     []string `json:"windows"`
+}
+
+func (q *Queries) ListFundAnalyticsForSchemes(ctx context.Context, arg ListFundAnalyticsForSchemesParams) ([]FundAnalytic, error) {
+	rows, err := q.db.Query(ctx, listFundAnalyticsForSchemes, arg.SchemeCodes, arg.Windows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FundAnalytic{}
+	for rows.Next() {
+		var i FundAnalytic
+		if err := rows.Scan(
+			&i.SchemeCode,
+			&i.Window,
+			&i.RollingMin,
+			&i.RollingMax,
+			&i.RollingMedian,
+			&i.RollingP25,
+			&i.RollingP75,
+			&i.MaxDrawdown,
+			&i.CagrMin,
+			&i.CagrMax,
+			&i.CagrMedian,
+			&i.DataStartDate,
+			&i.DataEndDate,
+			&i.NavPoints,
+			&i.RollingPeriods,
+			&i.ComputedAt,
+			&i.Distribution,
+			&i.Volatility,
+			&i.Sharpe,
+			&i.Consistency,
+			&i.RiskFreeRate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPreviousRankCandidates = `-- name: ListPreviousRankCandidates :many
+SELECT
+  fp.scheme_code,
   f.category,
-  fa."window",
-  fa.rolling_median,
-  fa.max_drawdown,
-  nav.nav_value AS current_nav,
-  nav.nav_date AS last_updated
-FROM fund_analytics fa
-JOIN funds f ON f.scheme_code = fa.scheme_code
-LEFT JOIN LATERAL (
-  SELECT nh.nav_value, nh.nav_date
-  FROM nav_history nh
-  WHERE nh.scheme_code = fa.scheme_code
-  ORDER BY nh.nav_date DESC
-  LIMIT 1
-) nav ON true
-WHERE f.category = $1
-  AND fa."window" = $2
-ORDER BY fa.max_drawdown ASC NULLS LAST
-LIMIT $3
+  fp."window",
+  fp.rolling_min,
+  fp.rolling_max,
+  fp.rolling_median,
+  fp.rolling_p25,
+  fp.rolling_p75,
+  fp.max_drawdown,
+  fp.cagr_min,
+  fp.cagr_max,
+  fp.cagr_median,
+  fp.volatility,
+  fp.sharpe,
+  fp.consistency
+FROM fund_analytics_previous fp
+JOIN funds f ON f.scheme_code = fp.scheme_code
+WHERE fp."window" = ANY($1::text[])
+  AND ($2::text[] IS NULL OR f.category = ANY($2::text[]))
+  AND ($3::text[] IS NULL OR f.amc = ANY($3::text[]))
+ORDER BY fp.scheme_code ASC, fp."window" ASC
 `
 
-type RankFundsByMaxDrawdownParams struct {
-	Category string `json:"category"`
-	Window   string `json:"window"`
-	Limit    int32  `json:"limit"`
+type ListPreviousRankCandidatesParams struct {
+	Windows    []string `json:"windows"`
+	Categories []string `json:"categories"`
+	Amcs       []string `json:"amcs"`
 }
 
-type RankFundsByMaxDrawdownRow struct {
-	SchemeCode    string          `json:"scheme_code"`
-	SchemeName    string          `json:"scheme_name"`
-	Amc           string          `json:"amc"`
-	Category      string          `json:"category"`
-	Window        string          `json:"window"`
-	RollingMedian pgtype.Numeric  `json:"rolling_median"`
-	MaxDrawdown   pgtype.Numeric  `json:"max_drawdown"`
-	CurrentNav    decimal.Decimal `json:"current_nav"`
-	LastUpdated   pgtype.Date     `json:"last_updated"`
+type ListPreviousRankCandidatesRow struct {
+	SchemeCode    string         `json:"scheme_code"`
+	Category      string         `json:"category"`
+	Window        string         `json:"window"`
+	RollingMin    pgtype.Numeric `json:"rolling_min"`
+	RollingMax    pgtype.Numeric `json:"rolling_max"`
+	RollingMedian pgtype.Numeric `json:"rolling_median"`
+	RollingP25    pgtype.Numeric `json:"rolling_p25"`
+	RollingP75    pgtype.Numeric `json:"rolling_p75"`
+	MaxDrawdown   pgtype.Numeric `json:"max_drawdown"`
+	CagrMin       pgtype.Numeric `json:"cagr_min"`
+	CagrMax       pgtype.Numeric `json:"cagr_max"`
+	CagrMedian    pgtype.Numeric `json:"cagr_median"`
+	Volatility    pgtype.Numeric `json:"volatility"`
+	Sharpe        pgtype.Numeric `json:"sharpe"`
+	Consistency   pgtype.Numeric `json:"consistency"`
 }
 
-func (q *Queries) RankFundsByMaxDrawdown(ctx context.Context, arg RankFundsByMaxDrawdownParams) ([]RankFundsByMaxDrawdownRow, error) {
-	rows, err := q.db.Query(ctx, rankFundsByMaxDrawdown, arg.Category, arg.Window, arg.Limit)
+func (q *Queries) ListPreviousRankCandidates(ctx context.Context, arg ListPreviousRankCandidatesParams) ([]ListPreviousRankCandidatesRow, error) {
+	rows, err := q.db.Query(ctx, listPreviousRankCandidates, arg.Windows, arg.Categories, arg.Amcs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []RankFundsByMaxDrawdownRow{}
+	items := []ListPreviousRankCandidatesRow{}
 	for rows.Next() {
-		var i RankFundsByMaxDrawdownRow
+		var i ListPreviousRankCandidatesRow
 		if err := rows.Scan(
 			&i.SchemeCode,
-			&i.SchemeName,
-			&i.Amc,
 			&i.Category,
 			&i.Window,
+			&i.RollingMin,
+			&i.RollingMax,
 			&i.RollingMedian,
+			&i.RollingP25,
+			&i.RollingP75,
 			&i.MaxDrawdown,
-			&i.CurrentNav,
-			&i.LastUpdated,
+			&i.CagrMin,
+			&i.CagrMax,
+			&i.CagrMedian,
+			&i.Volatility,
+			&i.Sharpe,
+			&i.Consistency,
 		); err != nil {
 			return nil, err
 		}
@@ -122,67 +241,91 @@ func (q *Queries) RankFundsByMaxDrawdown(ctx context.Context, arg RankFundsByMax
 	return items, nil
 }
 
-const rankFundsByMedianReturn = `-- name: RankFundsByMedianReturn :many
+const listRankCandidates = `-- name: ListRankCandidates :many
 SELECT
   fa.scheme_code,
   f.scheme_name,
   f.amc,
   f.category,
   fa."window",
+  fa.rolling_min,
+  fa.rolling_max,
   fa.rolling_median,
+  fa.rolling_p25,
+  fa.rolling_p75,
   fa.max_drawdown,
+  fa.cagr_min,
+  fa.cagr_max,
+  fa.cagr_median,
+  fa.volatility,
+  fa.sharpe,
+  fa.consistency,
   nav.nav_value AS current_nav,
   nav.nav_date AS last_updated
 FROM fund_analytics fa
 JOIN funds f ON f.scheme_code = fa.scheme_code
-LEFT JOIN LATERAL (
-  SELECT nh.nav_value, nh.nav_date
-  FROM nav_history nh
-  WHERE nh.scheme_code = fa.scheme_code
-  ORDER BY nh.nav_date DESC
-  LIMIT 1
-) nav ON true
-WHERE f.category = $1
-  AND fa."window" = $2
-ORDER BY fa.rolling_median DESC NULLS LAST
-LIMIT $3
+LEFT JOIN fund_latest_nav nav ON nav.scheme_code = fa.scheme_code
+WHERE fa."window" = ANY($1::text[])
+  AND ($2::text[] IS NULL OR f.category = ANY($2::text[]))
+  AND ($3::text[] IS NULL OR f.amc = ANY($3::text[]))
+ORDER BY fa.scheme_code ASC, fa."window" ASC
 `
 
-type RankFundsByMedianReturnParams struct {
-	Category string `json:"category"`
-	Window   string `json:"window"`
-	Limit    int32  `json:"limit"`
+type ListRankCandidatesParams struct {
+	Windows    []string `json:"windows"`
+	Categories []string `json:"categories"`
+	Amcs       []string `json:"amcs"`
 }
 
-type RankFundsByMedianReturnRow struct {
-	SchemeCode    string          `json:"scheme_code"`
-	SchemeName    string          `json:"scheme_name"`
-	Amc           string          `json:"amc"`
-	Category      string          `json:"category"`
-	Window        string          `json:"window"`
-	RollingMedian pgtype.Numeric  `json:"rolling_median"`
-	MaxDrawdown   pgtype.Numeric  `json:"max_drawdown"`
-	CurrentNav    decimal.Decimal `json:"current_nav"`
-	LastUpdated   pgtype.Date     `json:"last_updated"`
+type ListRankCandidatesRow struct {
+	SchemeCode    string         `json:"scheme_code"`
+	SchemeName    string         `json:"scheme_name"`
+	Amc           string         `json:"amc"`
+	Category      string         `json:"category"`
+	Window        string         `json:"window"`
+	RollingMin    pgtype.Numeric `json:"rolling_min"`
+	RollingMax    pgtype.Numeric `json:"rolling_max"`
+	RollingMedian pgtype.Numeric `json:"rolling_median"`
+	RollingP25    pgtype.Numeric `json:"rolling_p25"`
+	RollingP75    pgtype.Numeric `json:"rolling_p75"`
+	MaxDrawdown   pgtype.Numeric `json:"max_drawdown"`
+	CagrMin       pgtype.Numeric `json:"cagr_min"`
+	CagrMax       pgtype.Numeric `json:"cagr_max"`
+	CagrMedian    pgtype.Numeric `json:"cagr_median"`
+	Volatility    pgtype.Numeric `json:"volatility"`
+	Sharpe        pgtype.Numeric `json:"sharpe"`
+	Consistency   pgtype.Numeric `json:"consistency"`
+	CurrentNav    pgtype.Numeric `json:"current_nav"`
+	LastUpdated   pgtype.Date    `json:"last_updated"`
 }
 
-func (q *Queries) RankFundsByMedianReturn(ctx context.Context, arg RankFundsByMedianReturnParams) ([]RankFundsByMedianReturnRow, error) {
-	rows, err := q.db.Query(ctx, rankFundsByMedianReturn, arg.Category, arg.Window, arg.Limit)
+func (q *Queries) ListRankCandidates(ctx context.Context, arg ListRankCandidatesParams) ([]ListRankCandidatesRow, error) {
+	rows, err := q.db.Query(ctx, listRankCandidates, arg.Windows, arg.Categories, arg.Amcs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []RankFundsByMedianReturnRow{}
+	items := []ListRankCandidatesRow{}
 	for rows.Next() {
-		var i RankFundsByMedianReturnRow
+		var i ListRankCandidatesRow
 		if err := rows.Scan(
 			&i.SchemeCode,
 			&i.SchemeName,
 			&i.Amc,
 			&i.Category,
 			&i.Window,
+			&i.RollingMin,
+			&i.RollingMax,
 			&i.RollingMedian,
+			&i.RollingP25,
+			&i.RollingP75,
 			&i.MaxDrawdown,
+			&i.CagrMin,
+			&i.CagrMax,
+			&i.CagrMedian,
+			&i.Volatility,
+			&i.Sharpe,
+			&i.Consistency,
 			&i.CurrentNav,
 			&i.LastUpdated,
 		); err != nil {
@@ -196,6 +339,56 @@ func (q *Queries) RankFundsByMedianReturn(ctx context.Context, arg RankFundsByMe
 	return items, nil
 }
 
+const snapshotPreviousFundAnalytics = `-- name: SnapshotPreviousFundAnalytics :exec
+INSERT INTO fund_analytics_previous (
+  scheme_code, "window",
+  rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75,
+  max_drawdown,
+  cagr_min, cagr_max, cagr_median,
+  volatility, sharpe, consistency,
+  data_end_date, computed_at
+)
+SELECT
+  scheme_code, "window",
+  rolling_min, rolling_max, rolling_median, rolling_p25, rolling_p75,
+  max_drawdown,
+  cagr_min, cagr_max, cagr_median,
+  volatility, sharpe, consistency,
+  data_end_date, computed_at
+FROM fund_analytics
+WHERE scheme_code = $1
+  AND "window" = $2
+  AND data_end_date < $3::date
+ON CONFLICT (scheme_code, "window") DO UPDATE SET
+  rolling_min = EXCLUDED.rolling_min,
+  rolling_max = EXCLUDED.rolling_max,
+  rolling_median = EXCLUDED.rolling_median,
+  rolling_p25 = EXCLUDED.rolling_p25,
+  rolling_p75 = EXCLUDED.rolling_p75,
+  max_drawdown = EXCLUDED.max_drawdown,
+  cagr_min = EXCLUDED.cagr_min,
+  cagr_max = EXCLUDED.cagr_max,
+  cagr_median = EXCLUDED.cagr_median,
+  volatility = EXCLUDED.volatility,
+  sharpe = EXCLUDED.sharpe,
+  consistency = EXCLUDED.consistency,
+  data_end_date = EXCLUDED.data_end_date,
+  computed_at = EXCLUDED.computed_at
+`
+
+type SnapshotPreviousFundAnalyticsParams struct {
+	SchemeCode     string      `json:"scheme_code"`
+	Window         string      `json:"window"`
+	NewDataEndDate pgtype.Date `json:"new_data_end_date"`
+}
+
+// Keeps the current row as the previous computation, but only when the new data end date moves
+// past it, so recomputing on unchanged data doesn't erase the comparison point.
+func (q *Queries) SnapshotPreviousFundAnalytics(ctx context.Context, arg SnapshotPreviousFundAnalyticsParams) error {
+	_, err := q.db.Exec(ctx, snapshotPreviousFundAnalytics, arg.SchemeCode, arg.Window, arg.NewDataEndDate)
+	return err
+}
+
 const upsertFundAnalytics = `-- name: UpsertFundAnalytics :exec
 INSERT INTO fund_analytics (
   scheme_code, "window",
@@ -203,6 +396,9 @@ INSERT INTO fund_analytics (
   max_drawdown,
   cagr_min, cagr_max, cagr_median,
   data_start_date, data_end_date, nav_points, rolling_periods,
+  distribution,
+  volatility, sharpe, consistency,
+  risk_free_rate,
   computed_at
 )
 VALUES (
@@ -211,6 +407,9 @@ VALUES (
   $8,
   $9, $10, $11,
   $12, $13, $14, $15,
+  $16,
+  $17, $18, $19,
+  $20,
   NOW()
 )
 ON CONFLICT (scheme_code, "window") DO UPDATE SET
@@ -227,6 +426,11 @@ ON CONFLICT (scheme_code, "window") DO UPDATE SET
   data_end_date = EXCLUDED.data_end_date,
   nav_points = EXCLUDED.nav_points,
   rolling_periods = EXCLUDED.rolling_periods,
+  distribution = EXCLUDED.distribution,
+  volatility = EXCLUDED.volatility,
+  sharpe = EXCLUDED.sharpe,
+  consistency = EXCLUDED.consistency,
+  risk_free_rate = EXCLUDED.risk_free_rate,
   computed_at = NOW()
 `
 
@@ -246,6 +450,11 @@ type UpsertFundAnalyticsParams struct {
 	DataEndDate    pgtype.Date    `json:"data_end_date"`
 	NavPoints      pgtype.Int4    `json:"nav_points"`
 	RollingPeriods pgtype.Int4    `json:"rolling_periods"`
+	Distribution   []byte         `json:"distribution"`
+	Volatility     pgtype.Numeric `json:"volatility"`
+	Sharpe         pgtype.Numeric `json:"sharpe"`
+	Consistency    pgtype.Numeric `json:"consistency"`
+	RiskFreeRate   pgtype.Numeric `json:"risk_free_rate"`
 }
 
 func (q *Queries) UpsertFundAnalytics(ctx context.Context, arg UpsertFundAnalyticsParams) error {
@@ -265,6 +474,37 @@ func (q *Queries) UpsertFundAnalytics(ctx context.Context, arg UpsertFundAnalyti
 		arg.DataEndDate,
 		arg.NavPoints,
 		arg.RollingPeriods,
+		arg.Distribution,
+		arg.Volatility,
+		arg.Sharpe,
+		arg.Consistency,
+		arg.RiskFreeRate,
+	)
+	return err
+}
+
+const upsertFundAnalyticsState = `-- name: UpsertFundAnalyticsState :exec
+INSERT INTO fund_analytics_state (scheme_code, "window", last_end_date, worst_drawdown, updated_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (scheme_code, "window") DO UPDATE SET
+  last_end_date = EXCLUDED.last_end_date,
+  worst_drawdown = EXCLUDED.worst_drawdown,
+  updated_at = NOW()
+`
+
+type UpsertFundAnalyticsStateParams struct {
+	SchemeCode    string        `json:"scheme_code"`
+	Window        string        `json:"window"`
+	LastEndDate   pgtype.Date   `json:"last_end_date"`
+	WorstDrawdown pgtype.Float8 `json:"worst_drawdown"`
+}
+
+func (q *Queries) UpsertFundAnalyticsState(ctx context.Context, arg UpsertFundAnalyticsStateParams) error {
+	_, err := q.db.Exec(ctx, upsertFundAnalyticsState,
+		arg.SchemeCode,
+		arg.Window,
+		arg.LastEndDate,
+		arg.WorstDrawdown,
 	)
 	return err
 }
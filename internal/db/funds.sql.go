@@ -24,6 +24,25 @@ func (q *Queries) CountFundsByCategory(ctx context.Context, category string) (in
 	return column_1, err
 }
 
+const countFundsFiltered = `-- name: CountFundsFiltered :one
+SELECT COUNT(*)::bigint
+FROM funds
+WHERE ($1::text[] IS NULL OR category = ANY($1::text[]))
+  AND ($2::text[] IS NULL OR amc = ANY($2::text[]))
+`
+
+type CountFundsFilteredParams struct {
+	Categories []string `json:"categories"`
+	Amcs       []string `json:"amcs"`
+}
+
+func (q *Queries) CountFundsFiltered(ctx context.Context, arg CountFundsFilteredParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFundsFiltered, arg.Categories, arg.Amcs)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const getFund = `-- name: GetFund :one
 SELECT scheme_code, scheme_name, amc, category, inception_date, created_at, updated_at
 FROM funds
@@ -86,6 +105,116 @@ func (q *Queries) ListFunds(ctx context.Context, arg ListFundsParams) ([]Fund, e
 	return items, nil
 }
 
+const searchFunds = `-- name: SearchFunds :many
+WITH keyed AS (
+  SELECT f.scheme_code, f.scheme_name, f.amc, f.category, f.inception_date,
+         n.nav_date AS latest_nav_date, n.nav_value AS latest_nav,
+         (CASE $1::text
+            WHEN 'name' THEN lower(f.scheme_name)
+            WHEN 'amc' THEN lower(f.amc)
+            ELSE ''
+          END)::text AS sort_text,
+         (CASE $1::text
+            WHEN 'nav' THEN COALESCE(n.nav_value::float8, -1)
+            WHEN 'inception' THEN COALESCE((f.inception_date - DATE '1900-01-01')::float8, -1)
+            ELSE 0
+          END)::float8 AS sort_num
+  FROM funds f
+  LEFT JOIN fund_latest_nav n ON n.scheme_code = f.scheme_code
+  WHERE ($2::text IS NULL OR f.category ILIKE '%' || $2::text || '%')
+    AND ($3::text IS NULL OR f.amc ILIKE '%' || $3::text || '%')
+    AND ($4::text IS NULL
+         OR f.scheme_name ILIKE '%' || $4::text || '%'
+         OR $5::text <% f.scheme_name)
+)
+SELECT scheme_code, scheme_name, amc, category, inception_date, latest_nav_date, latest_nav,
+       sort_text, sort_num
+FROM keyed
+WHERE $6::text IS NULL
+   OR (NOT $7::bool
+       AND (sort_text, sort_num, scheme_code) > ($8::text, $9::float8, $6::text))
+   OR ($7::bool
+       AND (sort_text, sort_num, scheme_code) < ($8::text, $9::float8, $6::text))
+ORDER BY
+  CASE WHEN NOT $7::bool THEN sort_text END ASC,
+  CASE WHEN NOT $7::bool THEN sort_num END ASC,
+  CASE WHEN NOT $7::bool THEN scheme_code END ASC,
+  CASE WHEN $7::bool THEN sort_text END DESC,
+  CASE WHEN $7::bool THEN sort_num END DESC,
+  CASE WHEN $7::bool THEN scheme_code END DESC
+LIMIT $10::int
+`
+
+type SearchFundsParams struct {
+	Sort       string      `json:"sort"`
+	Category   pgtype.Text `json:"category"`
+	Amc        pgtype.Text `json:"amc"`
+	QPattern   pgtype.Text `json:"q_pattern"`
+	Q          pgtype.Text `json:"q"`
+	AfterCode  pgtype.Text `json:"after_code"`
+	Descending bool        `json:"descending"`
+	AfterText  string      `json:"after_text"`
+	AfterNum   float64     `json:"after_num"`
+	PageLimit  int32       `json:"page_limit"`
+}
+
+type SearchFundsRow struct {
+	SchemeCode    string         `json:"scheme_code"`
+	SchemeName    string         `json:"scheme_name"`
+	Amc           string         `json:"amc"`
+	Category      string         `json:"category"`
+	InceptionDate pgtype.Date    `json:"inception_date"`
+	LatestNavDate pgtype.Date    `json:"latest_nav_date"`
+	LatestNav     pgtype.Numeric `json:"latest_nav"`
+	SortText      string         `json:"sort_text"`
+	SortNum       float64        `json:"sort_num"`
+}
+
+// One keyset page of funds. sort is name|amc|nav|inception; each row carries its (sort_text,
+// sort_num) key so the last row of a page is the cursor for the next. Patterns are ILIKE patterns
+// with wildcards already escaped; q is the raw search text, matched by trigram word similarity
+// besides its q_pattern.
+func (q *Queries) SearchFunds(ctx context.Context, arg SearchFundsParams) ([]SearchFundsRow, error) {
+	rows, err := q.db.Query(ctx, searchFunds,
+		arg.Sort,
+		arg.Category,
+		arg.Amc,
+		arg.QPattern,
+		arg.Q,
+		arg.AfterCode,
+		arg.Descending,
+		arg.AfterText,
+		arg.AfterNum,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchFundsRow{}
+	for rows.Next() {
+		var i SearchFundsRow
+		if err := rows.Scan(
+			&i.SchemeCode,
+			&i.SchemeName,
+			&i.Amc,
+			&i.Category,
+			&i.InceptionDate,
+			&i.LatestNavDate,
+			&i.LatestNav,
+			&i.SortText,
+			&i.SortNum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const upsertFund = `-- name: UpsertFund :exec
 INSERT INTO funds (scheme_code, scheme_name, amc, category, inception_date, created_at, updated_at)
 VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
@@ -9,6 +9,48 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+type ApiKey struct {
+	KeyID      string           `json:"key_id"`
+	Name       string           `json:"name"`
+	SecretHash []byte           `json:"secret_hash"`
+	Scopes     []string         `json:"scopes"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	RevokedAt  pgtype.Timestamp `json:"revoked_at"`
+}
+
+type ApiKeyRateLimitState struct {
+	KeyID        string           `json:"key_id"`
+	WindowType   string           `json:"window_type"`
+	WindowStart  pgtype.Timestamp `json:"window_start"`
+	RequestCount int32            `json:"request_count"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+}
+
+type CapitalGainsTaxRule struct {
+	Version              string          `json:"version"`
+	AssetClass           string          `json:"asset_class"`
+	EffectiveFrom        pgtype.Date     `json:"effective_from"`
+	LtcgMinHoldingMonths int32           `json:"ltcg_min_holding_months"`
+	StcgRate             decimal.Decimal `json:"stcg_rate"`
+	LtcgRate             decimal.Decimal `json:"ltcg_rate"`
+	LtcgExemption        decimal.Decimal `json:"ltcg_exemption"`
+	CessRate             decimal.Decimal `json:"cess_rate"`
+	GrandfatherDate      pgtype.Date     `json:"grandfather_date"`
+	Description          string          `json:"description"`
+}
+
+type CategoryAnalytic struct {
+	Category   string           `json:"category"`
+	Window     string           `json:"window"`
+	Metric     string           `json:"metric"`
+	FundCount  int32            `json:"fund_count"`
+	Mean       float64          `json:"mean"`
+	P25        float64          `json:"p25"`
+	Median     float64          `json:"median"`
+	P75        float64          `json:"p75"`
+	ComputedAt pgtype.Timestamp `json:"computed_at"`
+}
+
 type Fund struct {
 	SchemeCode    string           `json:"scheme_code"`
 	SchemeName    string           `json:"scheme_name"`
@@ -36,6 +78,116 @@ type FundAnalytic struct {
 	NavPoints      pgtype.Int4      `json:"nav_points"`
 	RollingPeriods pgtype.Int4      `json:"rolling_periods"`
 	ComputedAt     pgtype.Timestamp `json:"computed_at"`
+	Distribution   []byte           `json:"distribution"`
+	Volatility     pgtype.Numeric   `json:"volatility"`
+	Sharpe         pgtype.Numeric   `json:"sharpe"`
+	Consistency    pgtype.Numeric   `json:"consistency"`
+	RiskFreeRate   pgtype.Numeric   `json:"risk_free_rate"`
+}
+
+type FundAnalyticsHistory struct {
+	SchemeCode    string           `json:"scheme_code"`
+	Window        string           `json:"window"`
+	AsOf          pgtype.Date      `json:"as_of"`
+	RollingMin    pgtype.Numeric   `json:"rolling_min"`
+	RollingMax    pgtype.Numeric   `json:"rolling_max"`
+	RollingMedian pgtype.Numeric   `json:"rolling_median"`
+	RollingP25    pgtype.Numeric   `json:"rolling_p25"`
+	RollingP75    pgtype.Numeric   `json:"rolling_p75"`
+	MaxDrawdown   pgtype.Numeric   `json:"max_drawdown"`
+	CagrMin       pgtype.Numeric   `json:"cagr_min"`
+	CagrMax       pgtype.Numeric   `json:"cagr_max"`
+	CagrMedian    pgtype.Numeric   `json:"cagr_median"`
+	Volatility    pgtype.Numeric   `json:"volatility"`
+	Sharpe        pgtype.Numeric   `json:"sharpe"`
+	Consistency   pgtype.Numeric   `json:"consistency"`
+	ComputedAt    pgtype.Timestamp `json:"computed_at"`
+}
+
+type FundAnalyticsPrevious struct {
+	SchemeCode    string           `json:"scheme_code"`
+	Window        string           `json:"window"`
+	RollingMin    pgtype.Numeric   `json:"rolling_min"`
+	RollingMax    pgtype.Numeric   `json:"rolling_max"`
+	RollingMedian pgtype.Numeric   `json:"rolling_median"`
+	RollingP25    pgtype.Numeric   `json:"rolling_p25"`
+	RollingP75    pgtype.Numeric   `json:"rolling_p75"`
+	MaxDrawdown   pgtype.Numeric   `json:"max_drawdown"`
+	CagrMin       pgtype.Numeric   `json:"cagr_min"`
+	CagrMax       pgtype.Numeric   `json:"cagr_max"`
+	CagrMedian    pgtype.Numeric   `json:"cagr_median"`
+	Volatility    pgtype.Numeric   `json:"volatility"`
+	Sharpe        pgtype.Numeric   `json:"sharpe"`
+	Consistency   pgtype.Numeric   `json:"consistency"`
+	DataEndDate   pgtype.Date      `json:"data_end_date"`
+	ComputedAt    pgtype.Timestamp `json:"computed_at"`
+}
+
+type FundAnalyticsState struct {
+	SchemeCode    string           `json:"scheme_code"`
+	Window        string           `json:"window"`
+	LastEndDate   pgtype.Date      `json:"last_end_date"`
+	WorstDrawdown pgtype.Float8    `json:"worst_drawdown"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+type FundCalendarReturn struct {
+	SchemeCode string           `json:"scheme_code"`
+	Year       int32            `json:"year"`
+	StartDate  pgtype.Date      `json:"start_date"`
+	EndDate    pgtype.Date      `json:"end_date"`
+	ReturnPct  decimal.Decimal  `json:"return_pct"`
+	Complete   bool             `json:"complete"`
+	ComputedAt pgtype.Timestamp `json:"computed_at"`
+}
+
+type FundCategoryRank struct {
+	SchemeCode string           `json:"scheme_code"`
+	Window     string           `json:"window"`
+	Metric     string           `json:"metric"`
+	Category   string           `json:"category"`
+	Percentile float64          `json:"percentile"`
+	Quartile   int16            `json:"quartile"`
+	ComputedAt pgtype.Timestamp `json:"computed_at"`
+}
+
+type FundCorrelation struct {
+	Window       string           `json:"window"`
+	Frequency    string           `json:"frequency"`
+	SchemeCodeA  string           `json:"scheme_code_a"`
+	SchemeCodeB  string           `json:"scheme_code_b"`
+	Correlation  pgtype.Numeric   `json:"correlation"`
+	Observations int32            `json:"observations"`
+	Sufficient   bool             `json:"sufficient"`
+	PeriodStart  pgtype.Date      `json:"period_start"`
+	PeriodEnd    pgtype.Date      `json:"period_end"`
+	ComputedAt   pgtype.Timestamp `json:"computed_at"`
+}
+
+type FundLatestNav struct {
+	SchemeCode string           `json:"scheme_code"`
+	NavDate    pgtype.Date      `json:"nav_date"`
+	NavValue   decimal.Decimal  `json:"nav_value"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+}
+
+type FundRollingReturn struct {
+	SchemeCode string        `json:"scheme_code"`
+	Window     string        `json:"window"`
+	EndDate    pgtype.Date   `json:"end_date"`
+	StartDate  pgtype.Date   `json:"start_date"`
+	ReturnPct  float64       `json:"return_pct"`
+	CagrPct    pgtype.Float8 `json:"cagr_pct"`
+}
+
+type FundTrailingReturn struct {
+	SchemeCode       string           `json:"scheme_code"`
+	Period           string           `json:"period"`
+	StartDate        pgtype.Date      `json:"start_date"`
+	EndDate          pgtype.Date      `json:"end_date"`
+	AbsoluteReturn   pgtype.Numeric   `json:"absolute_return"`
+	AnnualizedReturn pgtype.Numeric   `json:"annualized_return"`
+	ComputedAt       pgtype.Timestamp `json:"computed_at"`
 }
 
 type NavHistory struct {
@@ -45,6 +197,20 @@ type NavHistory struct {
 	CreatedAt  pgtype.Timestamp `json:"created_at"`
 }
 
+type Portfolio struct {
+	ID        int64            `json:"id"`
+	Name      string           `json:"name"`
+	Rebalance string           `json:"rebalance"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
+type PortfolioHolding struct {
+	PortfolioID int64           `json:"portfolio_id"`
+	SchemeCode  string          `json:"scheme_code"`
+	Weight      decimal.Decimal `json:"weight"`
+}
+
 type RateLimiterState struct {
 	WindowType   string           `json:"window_type"`
 	WindowStart  pgtype.Timestamp `json:"window_start"`
@@ -70,3 +236,15 @@ type SyncState struct {
 	LastAttemptAt  pgtype.Timestamp `json:"last_attempt_at"`
 	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
 }
+
+type UserTransaction struct {
+	ID         int64            `json:"id"`
+	UserID     string           `json:"user_id"`
+	SchemeCode string           `json:"scheme_code"`
+	TxnType    string           `json:"txn_type"`
+	TxnDate    pgtype.Date      `json:"txn_date"`
+	Amount     decimal.Decimal  `json:"amount"`
+	Units      decimal.Decimal  `json:"units"`
+	Source     string           `json:"source"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
@@ -13,15 +13,63 @@ import (
 )
 
 const getLatestNav = `-- name: GetLatestNav :one
+SELECT scheme_code, nav_date, nav_value, updated_at
+FROM fund_latest_nav
+WHERE scheme_code = $1
+`
+
+func (q *Queries) GetLatestNav(ctx context.Context, schemeCode string) (FundLatestNav, error) {
+	row := q.db.QueryRow(ctx, getLatestNav, schemeCode)
+	var i FundLatestNav
+	err := row.Scan(
+		&i.SchemeCode,
+		&i.NavDate,
+		&i.NavValue,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getNavHistoryBounds = `-- name: GetNavHistoryBounds :one
+SELECT
+  MIN(nav_date)::date AS start_date,
+  MAX(nav_date)::date AS end_date,
+  COUNT(*)::int AS nav_points
+FROM nav_history
+WHERE scheme_code = $1
+  AND nav_value > 0
+`
+
+type GetNavHistoryBoundsRow struct {
+	StartDate pgtype.Date `json:"start_date"`
+	EndDate   pgtype.Date `json:"end_date"`
+	NavPoints int32       `json:"nav_points"`
+}
+
+// Only positive NAVs count, the same rows the analytics compute uses.
+func (q *Queries) GetNavHistoryBounds(ctx context.Context, schemeCode string) (GetNavHistoryBoundsRow, error) {
+	row := q.db.QueryRow(ctx, getNavHistoryBounds, schemeCode)
+	var i GetNavHistoryBoundsRow
+	err := row.Scan(&i.StartDate, &i.EndDate, &i.NavPoints)
+	return i, err
+}
+
+const getNavOnOrBefore = `-- name: GetNavOnOrBefore :one
 SELECT scheme_code, nav_date, nav_value, created_at
 FROM nav_history
 WHERE scheme_code = $1
+  AND nav_date <= $2
 ORDER BY nav_date DESC
 LIMIT 1
 `
 
-func (q *Queries) GetLatestNav(ctx context.Context, schemeCode string) (NavHistory, error) {
-	row := q.db.QueryRow(ctx, getLatestNav, schemeCode)
+type GetNavOnOrBeforeParams struct {
+	SchemeCode string      `json:"scheme_code"`
+	NavDate    pgtype.Date `json:"nav_date"`
+}
+
+func (q *Queries) GetNavOnOrBefore(ctx context.Context, arg GetNavOnOrBeforeParams) (NavHistory, error) {
+	row := q.db.QueryRow(ctx, getNavOnOrBefore, arg.SchemeCode, arg.NavDate)
 	var i NavHistory
 	err := row.Scan(
 		&i.SchemeCode,
@@ -105,10 +153,21 @@ func (q *Queries) ListNavHistoryForScheme(ctx context.Context, schemeCode string
 }
 
 const upsertNavHistory = `-- name: UpsertNavHistory :exec
-INSERT INTO nav_history (scheme_code, nav_date, nav_value, created_at)
-VALUES ($1, $2, $3, NOW())
-ON CONFLICT (scheme_code, nav_date) DO UPDATE SET
-  nav_value = EXCLUDED.nav_value
+WITH nav AS (
+  INSERT INTO nav_history (scheme_code, nav_date, nav_value, created_at)
+  VALUES ($1, $2, $3, NOW())
+  ON CONFLICT (scheme_code, nav_date) DO UPDATE SET
+    nav_value = EXCLUDED.nav_value
+  RETURNING scheme_code, nav_date, nav_value
+)
+INSERT INTO fund_latest_nav (scheme_code, nav_date, nav_value, updated_at)
+SELECT scheme_code, nav_date, nav_value, NOW()
+FROM nav
+ON CONFLICT (scheme_code) DO UPDATE SET
+  nav_date = EXCLUDED.nav_date,
+  nav_value = EXCLUDED.nav_value,
+  updated_at = NOW()
+WHERE EXCLUDED.nav_date >= fund_latest_nav.nav_date
 `
 
 type UpsertNavHistoryParams struct {
@@ -117,6 +176,8 @@ type UpsertNavHistoryParams struct {
 	NavValue   decimal.Decimal `json:"nav_value"`
 }
 
+// Also advances fund_latest_nav in the same statement, so the two can't disagree. A correction
+// of the latest NAV updates it too; older dates leave it alone.
 func (q *Queries) UpsertNavHistory(ctx context.Context, arg UpsertNavHistoryParams) error {
 	_, err := q.db.Exec(ctx, upsertNavHistory, arg.SchemeCode, arg.NavDate, arg.NavValue)
 	return err
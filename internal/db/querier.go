@@ -13,30 +13,94 @@ import (
 type Querier interface {
 	ClaimNextSyncState(ctx context.Context) (SyncState, error)
 	CountFundsByCategory(ctx context.Context, category string) (int64, error)
+	CountFundsFiltered(ctx context.Context, arg CountFundsFilteredParams) (int64, error)
 	CountSyncStateByStatus(ctx context.Context) ([]CountSyncStateByStatusRow, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) error
+	CreatePortfolio(ctx context.Context, arg CreatePortfolioParams) (Portfolio, error)
 	CreateSyncRun(ctx context.Context, arg CreateSyncRunParams) error
+	DeleteCategoryAnalytics(ctx context.Context) error
+	DeleteFundAnalyticsHistoryBefore(ctx context.Context, asOf pgtype.Date) (int64, error)
+	DeleteFundCategoryRanks(ctx context.Context) error
+	DeleteFundCorrelations(ctx context.Context) error
+	DeleteFundRollingReturns(ctx context.Context, arg DeleteFundRollingReturnsParams) error
+	DeletePortfolio(ctx context.Context, id int64) (int64, error)
+	DeletePortfolioHoldings(ctx context.Context, portfolioID int64) error
 	FinishSyncRunFailure(ctx context.Context, arg FinishSyncRunFailureParams) error
 	FinishSyncRunSuccess(ctx context.Context, runID pgtype.UUID) error
+	GetAPIKey(ctx context.Context, keyID string) (ApiKey, error)
+	GetAPIKeyRateLimitStateForUpdate(ctx context.Context, arg GetAPIKeyRateLimitStateForUpdateParams) (ApiKeyRateLimitState, error)
 	GetFund(ctx context.Context, schemeCode string) (Fund, error)
 	GetFundAnalytics(ctx context.Context, arg GetFundAnalyticsParams) (FundAnalytic, error)
-	GetLatestNav(ctx context.Context, schemeCode string) (NavHistory, error)
+	GetFundAnalyticsState(ctx context.Context, arg GetFundAnalyticsStateParams) (FundAnalyticsState, error)
+	// The newest computation and NAV date over the windows; with the row count they change whenever
+	// any fund's analytics in those windows do.
+	GetFundAnalyticsVersion(ctx context.Context, windows []string) (GetFundAnalyticsVersionRow, error)
+	GetLatestNav(ctx context.Context, schemeCode string) (FundLatestNav, error)
 	GetLatestRunningSyncRun(ctx context.Context) (SyncRun, error)
 	GetLatestSyncRun(ctx context.Context) (SyncRun, error)
+	GetNavHistoryBounds(ctx context.Context, schemeCode string) (GetNavHistoryBoundsRow, error)
+	GetNavOnOrBefore(ctx context.Context, arg GetNavOnOrBeforeParams) (NavHistory, error)
+	GetPortfolio(ctx context.Context, id int64) (Portfolio, error)
 	GetRateLimiterStateForUpdate(ctx context.Context, windowType string) (RateLimiterState, error)
 	InitSyncStateIfMissing(ctx context.Context, schemeCode string) error
+	InsertCategoryAnalytics(ctx context.Context) error
+	InsertFundCategoryRanks(ctx context.Context) error
+	InsertFundCorrelations(ctx context.Context, arg InsertFundCorrelationsParams) error
+	InsertFundRollingReturns(ctx context.Context, arg InsertFundRollingReturnsParams) error
+	InsertPortfolioHoldings(ctx context.Context, arg InsertPortfolioHoldingsParams) error
+	// Skips transactions already stored for the user, so re-imports only add new rows.
+	InsertUserTransactions(ctx context.Context, arg InsertUserTransactionsParams) (int64, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	ListCapitalGainsTaxRules(ctx context.Context, assetClass string) ([]CapitalGainsTaxRule, error)
+	ListCategoryAnalytics(ctx context.Context, arg ListCategoryAnalyticsParams) ([]CategoryAnalytic, error)
+	// For each date, the latest snapshot on or before it of every fund in the given fund's category.
+	ListCategoryHistorySnapshots(ctx context.Context, arg ListCategoryHistorySnapshotsParams) ([]ListCategoryHistorySnapshotsRow, error)
+	ListFundAnalyticsForSchemes(ctx context.Context, arg ListFundAnalyticsForSchemesParams) ([]FundAnalytic, error)
+	ListFundAnalyticsHistoryDates(ctx context.Context, arg ListFundAnalyticsHistoryDatesParams) ([]pgtype.Date, error)
+	ListFundCalendarReturns(ctx context.Context, schemeCode string) ([]FundCalendarReturn, error)
+	ListFundCategoryRanks(ctx context.Context, arg ListFundCategoryRanksParams) ([]FundCategoryRank, error)
+	// Pairs within one window and frequency; with a category, only pairs where both funds are in it.
+	ListFundCorrelations(ctx context.Context, arg ListFundCorrelationsParams) ([]ListFundCorrelationsRow, error)
+	ListFundRollingReturns(ctx context.Context, arg ListFundRollingReturnsParams) ([]ListFundRollingReturnsRow, error)
+	ListFundTrailingReturns(ctx context.Context, schemeCode string) ([]FundTrailingReturn, error)
 	ListFunds(ctx context.Context, arg ListFundsParams) ([]Fund, error)
 	ListNavHistoryBetween(ctx context.Context, arg ListNavHistoryBetweenParams) ([]NavHistory, error)
 	ListNavHistoryForScheme(ctx context.Context, schemeCode string) ([]NavHistory, error)
+	ListPortfolioHoldings(ctx context.Context, portfolioIds []int64) ([]PortfolioHolding, error)
+	ListPortfolios(ctx context.Context) ([]Portfolio, error)
+	ListPreviousRankCandidates(ctx context.Context, arg ListPreviousRankCandidatesParams) ([]ListPreviousRankCandidatesRow, error)
+	ListRankCandidates(ctx context.Context, arg ListRankCandidatesParams) ([]ListRankCandidatesRow, error)
+	// Same shape as ListRankCandidates, from each fund's latest snapshot on or before as_of.
+	ListRankCandidatesAsOf(ctx context.Context, arg ListRankCandidatesAsOfParams) ([]ListRankCandidatesAsOfRow, error)
 	ListSyncState(ctx context.Context) ([]SyncState, error)
-	RankFundsByMaxDrawdown(ctx context.Context, arg RankFundsByMaxDrawdownParams) ([]RankFundsByMaxDrawdownRow, error)
-	RankFundsByMedianReturn(ctx context.Context, arg RankFundsByMedianReturnParams) ([]RankFundsByMedianReturnRow, error)
+	ListUserTransactions(ctx context.Context, userID string) ([]UserTransaction, error)
+	// Tells API replicas (LISTEN sync_completed) that synced data changed.
+	NotifySyncCompleted(ctx context.Context, runID string) error
 	RequeueStaleInProgressSyncState(ctx context.Context, lastAttemptAt pgtype.Timestamp) error
 	ResetAllSyncStateToPending(ctx context.Context) error
 	ResetEligibleIncrementalSyncStateToPending(ctx context.Context) error
+	RevokeAPIKey(ctx context.Context, keyID string) (int64, error)
+	// One keyset page of funds. sort is name|amc|nav|inception; each row carries its (sort_text,
+	// sort_num) key so the last row of a page is the cursor for the next. Patterns are ILIKE patterns
+	// with wildcards already escaped; q is the raw search text, matched by trigram word similarity
+	// besides its q_pattern.
+	SearchFunds(ctx context.Context, arg SearchFundsParams) ([]SearchFundsRow, error)
+	// Copies the current fund_analytics row into the history, one snapshot per data end date.
+	SnapshotFundAnalyticsHistory(ctx context.Context, arg SnapshotFundAnalyticsHistoryParams) error
+	// Keeps the current row as the previous computation, but only when the new data end date moves
+	// past it, so recomputing on unchanged data doesn't erase the comparison point.
+	SnapshotPreviousFundAnalytics(ctx context.Context, arg SnapshotPreviousFundAnalyticsParams) error
+	UpdatePortfolio(ctx context.Context, arg UpdatePortfolioParams) (Portfolio, error)
 	UpdateSyncStateAttempt(ctx context.Context, arg UpdateSyncStateAttemptParams) error
 	UpdateSyncStateSuccess(ctx context.Context, arg UpdateSyncStateSuccessParams) error
+	UpsertAPIKeyRateLimitState(ctx context.Context, arg UpsertAPIKeyRateLimitStateParams) error
 	UpsertFund(ctx context.Context, arg UpsertFundParams) error
 	UpsertFundAnalytics(ctx context.Context, arg UpsertFundAnalyticsParams) error
+	UpsertFundAnalyticsState(ctx context.Context, arg UpsertFundAnalyticsStateParams) error
+	UpsertFundCalendarReturn(ctx context.Context, arg UpsertFundCalendarReturnParams) error
+	UpsertFundTrailingReturn(ctx context.Context, arg UpsertFundTrailingReturnParams) error
+	// Also advances fund_latest_nav in the same statement, so the two can't disagree. A correction
+	// of the latest NAV updates it too; older dates leave it alone.
 	UpsertNavHistory(ctx context.Context, arg UpsertNavHistoryParams) error
 	UpsertRateLimiterState(ctx context.Context, arg UpsertRateLimiterStateParams) error
 }
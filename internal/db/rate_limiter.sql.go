@@ -11,6 +11,32 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const getAPIKeyRateLimitStateForUpdate = `-- name: GetAPIKeyRateLimitStateForUpdate :one
+SELECT key_id, window_type, window_start, request_count, updated_at
+FROM api_key_rate_limit_state
+WHERE key_id = $1
+  AND window_type = $2
+FOR UPDATE
+`
+
+type GetAPIKeyRateLimitStateForUpdateParams struct {
+	KeyID      string `json:"key_id"`
+	WindowType string `json:"window_type"`
+}
+
+func (q *Queries) GetAPIKeyRateLimitStateForUpdate(ctx context.Context, arg GetAPIKeyRateLimitStateForUpdateParams) (ApiKeyRateLimitState, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyRateLimitStateForUpdate, arg.KeyID, arg.WindowType)
+	var i ApiKeyRateLimitState
+	err := row.Scan(
+		&i.KeyID,
+		&i.WindowType,
+		&i.WindowStart,
+		&i.RequestCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getRateLimiterStateForUpdate = `-- name: GetRateLimiterStateForUpdate :one
 SELECT window_type, window_start, request_count, updated_at
 FROM rate_limiter_state
@@ -30,6 +56,32 @@ func (q *Queries) GetRateLimiterStateForUpdate(ctx context.Context, windowType s
 	return i, err
 }
 
+const upsertAPIKeyRateLimitState = `-- name: UpsertAPIKeyRateLimitState :exec
+INSERT INTO api_key_rate_limit_state (key_id, window_type, window_start, request_count, updated_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (key_id, window_type) DO UPDATE SET
+  window_start = EXCLUDED.window_start,
+  request_count = EXCLUDED.request_count,
+  updated_at = NOW()
+`
+
+type UpsertAPIKeyRateLimitStateParams struct {
+	KeyID        string           `json:"key_id"`
+	WindowType   string           `json:"window_type"`
+	WindowStart  pgtype.Timestamp `json:"window_start"`
+	RequestCount int32            `json:"request_count"`
+}
+
+func (q *Queries) UpsertAPIKeyRateLimitState(ctx context.Context, arg UpsertAPIKeyRateLimitStateParams) error {
+	_, err := q.db.Exec(ctx, upsertAPIKeyRateLimitState,
+		arg.KeyID,
+		arg.WindowType,
+		arg.WindowStart,
+		arg.RequestCount,
+	)
+	return err
+}
+
 const upsertRateLimiterState = `-- name: UpsertRateLimiterState :exec
 INSERT INTO rate_limiter_state (window_type, window_start, request_count, updated_at)
 VALUES ($1, $2, $3, NOW())
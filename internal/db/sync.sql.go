@@ -209,6 +209,16 @@ func (q *Queries) ListSyncState(ctx context.Context) ([]SyncState, error) {
 	return items, nil
 }
 
+const notifySyncCompleted = `-- name: NotifySyncCompleted :exec
+SELECT pg_notify('sync_completed', $1::text)
+`
+
+// Tells API replicas (LISTEN sync_completed) that synced data changed.
+func (q *Queries) NotifySyncCompleted(ctx context.Context, runID string) error {
+	_, err := q.db.Exec(ctx, notifySyncCompleted, runID)
+	return err
+}
+
 const requeueStaleInProgressSyncState = `-- name: RequeueStaleInProgressSyncState :exec
 UPDATE sync_state
 SET
@@ -0,0 +1,7 @@
+// Package events names the Postgres NOTIFY channels the services announce changes on, so the
+// sender and its listeners share one definition without depending on each other.
+package events
+
+// SyncCompleted is the channel a finished sync run is announced on, with the run ID as payload.
+// API servers LISTEN on it to invalidate their response caches.
+const SyncCompleted = "sync_completed"
@@ -0,0 +1,277 @@
+package investor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// TaxRule is one version of the capital gains rules for an asset class, applying to redemptions
+// on or after EffectiveFrom until the next version.
+type TaxRule struct {
+	Version              string
+	EffectiveFrom        time.Time
+	LTCGMinHoldingMonths int
+	STCGRatePct          float64
+	LTCGRatePct          float64
+	// LTCGExemption is the long-term gain exempt per financial year; the rule in force at the
+	// year end sets it, so a mid-year change applies to the whole year.
+	LTCGExemption float64
+	CessPct       float64
+	// GrandfatherDate is zero when units can't use a past NAV as their cost.
+	GrandfatherDate time.Time
+}
+
+// RealizedLot is the part of a purchase lot one redemption or switch-out sold.
+type RealizedLot struct {
+	SchemeCode string
+	Acquired   time.Time
+	Sold       time.Time
+	Units      float64
+	Cost       float64 // what the units were bought for
+	// AcquisitionCost is the cost the gain is measured from: Cost, or for grandfathered units the
+	// higher of Cost and the lower of their value at the grandfather date and SaleValue.
+	AcquisitionCost float64
+	SaleValue       float64
+	Gain            float64
+	LongTerm        bool
+	Grandfathered   bool
+	RuleVersion     string
+	RatePct         float64
+	CessPct         float64
+}
+
+// TaxBucket is a financial year's gains taxed at one rate.
+type TaxBucket struct {
+	LongTerm bool
+	RatePct  float64
+	CessPct  float64
+	Net      float64 // gains less losses at this rate
+	Taxable  float64 // after loss set-off and the long-term exemption
+	Tax      float64 // before cess
+	Cess     float64
+}
+
+// FYTax is the capital gains summary of one financial year (April to March).
+type FYTax struct {
+	FY             string // e.g. "2024-25"
+	STCG           float64
+	STCL           float64 // short-term losses, as a positive amount
+	LTCG           float64
+	LTCL           float64 // long-term losses, as a positive amount
+	ExemptionLimit float64
+	ExemptionUsed  float64
+	Buckets        []TaxBucket
+	Tax            float64
+	Cess           float64
+	// UnabsorbedSTCL and UnabsorbedLTCL are losses the year's gains couldn't absorb, available to
+	// carry forward. They aren't applied to later years here.
+	UnabsorbedSTCL float64
+	UnabsorbedLTCL float64
+	Lots           []RealizedLot
+}
+
+// FMVFunc returns a scheme's NAV on or before a grandfather date.
+type FMVFunc func(schemeCode string, date time.Time) (float64, bool)
+
+// NoTaxRuleError is a redemption older than every tax rule.
+type NoTaxRuleError struct {
+	Date time.Time
+}
+
+func (e *NoTaxRuleError) Error() string {
+	return "no capital gains tax rule for redemptions on " + e.Date.Format("2006-01-02")
+}
+
+// CapitalGains matches each redemption and switch-out to purchase lots first-in first-out, taxes
+// every sold lot under the rule in force on the sale date, and summarises the gains per financial
+// year, oldest first. rules must be sorted by EffectiveFrom.
+func CapitalGains(txns []Transaction, rules []TaxRule, fmv FMVFunc) ([]FYTax, error) {
+	byScheme := map[string][]Transaction{}
+	for _, t := range txns {
+		byScheme[t.SchemeCode] = append(byScheme[t.SchemeCode], t)
+	}
+
+	years := map[string]*FYTax{}
+	for code, scheme := range byScheme {
+		var lots []Lot
+		for _, t := range replayOrder(scheme) {
+			if t.inflow() {
+				lots = append(lots, Lot{Date: t.Date, Units: t.Units, Cost: t.Amount})
+				continue
+			}
+			rule, ok := ruleOn(rules, t.Date)
+			if !ok {
+				return nil, &NoTaxRuleError{Date: t.Date}
+			}
+			sold, err := consumeLots(&lots, code, t)
+			if err != nil {
+				return nil, err
+			}
+			fy := financialYear(t.Date)
+			y := years[fy]
+			if y == nil {
+				y = &FYTax{FY: fy}
+				years[fy] = y
+			}
+			for _, l := range sold {
+				y.Lots = append(y.Lots, realize(code, l, t, rule, fmv))
+			}
+		}
+	}
+
+	out := make([]FYTax, 0, len(years))
+	for _, y := range years {
+		sort.Slice(y.Lots, func(i, j int) bool {
+			a, b := y.Lots[i], y.Lots[j]
+			if !a.Sold.Equal(b.Sold) {
+				return a.Sold.Before(b.Sold)
+			}
+			if a.SchemeCode != b.SchemeCode {
+				return a.SchemeCode < b.SchemeCode
+			}
+			return a.Acquired.Before(b.Acquired)
+		})
+		if rule, ok := ruleOn(rules, financialYearEnd(y.FY)); ok {
+			y.ExemptionLimit = rule.LTCGExemption
+		}
+		settle(y)
+		out = append(out, *y)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FY < out[j].FY })
+	return out, nil
+}
+
+// realize prices the units of lot sold by t.
+func realize(code string, lot Lot, t Transaction, rule TaxRule, fmv FMVFunc) RealizedLot {
+	r := RealizedLot{
+		SchemeCode:      code,
+		Acquired:        lot.Date,
+		Sold:            t.Date,
+		Units:           lot.Units,
+		Cost:            lot.Cost,
+		AcquisitionCost: lot.Cost,
+		SaleValue:       t.Amount * lot.Units / t.Units,
+		LongTerm:        t.Date.After(lot.Date.AddDate(0, rule.LTCGMinHoldingMonths, 0)),
+		RuleVersion:     rule.Version,
+		RatePct:         rule.STCGRatePct,
+		CessPct:         rule.CessPct,
+	}
+	if r.LongTerm {
+		r.RatePct = rule.LTCGRatePct
+		if !rule.GrandfatherDate.IsZero() && !lot.Date.After(rule.GrandfatherDate) && fmv != nil {
+			if nav, ok := fmv(code, rule.GrandfatherDate); ok {
+				r.AcquisitionCost = math.Max(lot.Cost, math.Min(nav*lot.Units, r.SaleValue))
+				r.Grandfathered = true
+			}
+		}
+	}
+	r.Gain = r.SaleValue - r.AcquisitionCost
+	return r
+}
+
+// settle nets the year's gains per rate, sets short-term losses off against any gains and
+// long-term losses against long-term gains, then applies the exemption. Losses and the exemption
+// go to the highest rates first.
+func settle(y *FYTax) {
+	type key struct {
+		longTerm bool
+		rate     float64
+		cess     float64
+	}
+	buckets := map[key]*TaxBucket{}
+	for _, l := range y.Lots {
+		switch {
+		case l.LongTerm && l.Gain >= 0:
+			y.LTCG += l.Gain
+		case l.LongTerm:
+			y.LTCL -= l.Gain
+		case l.Gain >= 0:
+			y.STCG += l.Gain
+		default:
+			y.STCL -= l.Gain
+		}
+		k := key{l.LongTerm, l.RatePct, l.CessPct}
+		b := buckets[k]
+		if b == nil {
+			b = &TaxBucket{LongTerm: l.LongTerm, RatePct: l.RatePct, CessPct: l.CessPct}
+			buckets[k] = b
+		}
+		b.Net += l.Gain
+	}
+
+	y.Buckets = make([]TaxBucket, 0, len(buckets))
+	for _, b := range buckets {
+		y.Buckets = append(y.Buckets, *b)
+	}
+	sort.Slice(y.Buckets, func(i, j int) bool {
+		a, b := y.Buckets[i], y.Buckets[j]
+		if a.LongTerm != b.LongTerm {
+			return !a.LongTerm
+		}
+		return a.RatePct > b.RatePct
+	})
+
+	var stLoss, ltLoss float64
+	for i := range y.Buckets {
+		b := &y.Buckets[i]
+		switch {
+		case b.Net >= 0:
+			b.Taxable = b.Net
+		case b.LongTerm:
+			ltLoss -= b.Net
+		default:
+			stLoss -= b.Net
+		}
+	}
+	absorb := func(amount float64, longTerm bool) float64 {
+		for i := range y.Buckets {
+			b := &y.Buckets[i]
+			if b.LongTerm != longTerm || amount <= 0 {
+				continue
+			}
+			take := math.Min(amount, b.Taxable)
+			b.Taxable -= take
+			amount -= take
+		}
+		return amount
+	}
+	stLoss = absorb(stLoss, false)
+	y.UnabsorbedSTCL = absorb(stLoss, true)
+	y.UnabsorbedLTCL = absorb(ltLoss, true)
+	y.ExemptionUsed = y.ExemptionLimit - absorb(y.ExemptionLimit, true)
+
+	for i := range y.Buckets {
+		b := &y.Buckets[i]
+		b.Tax = b.Taxable * b.RatePct / 100
+		b.Cess = b.Tax * b.CessPct / 100
+		y.Tax += b.Tax
+		y.Cess += b.Cess
+	}
+}
+
+// ruleOn is the latest rule effective on or before date.
+func ruleOn(rules []TaxRule, date time.Time) (TaxRule, bool) {
+	i := sort.Search(len(rules), func(k int) bool { return rules[k].EffectiveFrom.After(date) })
+	if i == 0 {
+		return TaxRule{}, false
+	}
+	return rules[i-1], true
+}
+
+// financialYear labels the Indian financial year containing t, e.g. "2024-25".
+func financialYear(t time.Time) string {
+	y := t.Year()
+	if t.Month() < time.April {
+		y--
+	}
+	return fmt.Sprintf("%d-%02d", y, (y+1)%100)
+}
+
+// financialYearEnd is the last day of a financial year labelled by financialYear.
+func financialYearEnd(fy string) time.Time {
+	var y int
+	_, _ = fmt.Sscanf(fy, "%d-", &y)
+	return time.Date(y+1, time.March, 31, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,87 @@
+package investor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+var equityRules = []TaxRule{
+	{Version: "equity-2008-04", EffectiveFrom: date(2008, 4, 1), LTCGMinHoldingMonths: 12, STCGRatePct: 15, CessPct: 3},
+	{
+		Version: "equity-2018-04", EffectiveFrom: date(2018, 4, 1), LTCGMinHoldingMonths: 12,
+		STCGRatePct: 15, LTCGRatePct: 10, LTCGExemption: 100000, CessPct: 4, GrandfatherDate: date(2018, 1, 31),
+	},
+	{
+		Version: "equity-2024-07", EffectiveFrom: date(2024, 7, 23), LTCGMinHoldingMonths: 12,
+		STCGRatePct: 20, LTCGRatePct: 12.5, LTCGExemption: 125000, CessPct: 4, GrandfatherDate: date(2018, 1, 31),
+	},
+}
+
+func TestCapitalGainsGrandfathering(t *testing.T) {
+	txns := []Transaction{
+		{SchemeCode: "A", Type: TypePurchase, Date: date(2017, 1, 1), Amount: 1000, Units: 100},
+		{SchemeCode: "A", Type: TypeRedemption, Date: date(2019, 6, 1), Amount: 2000, Units: 100},
+	}
+	fmv := func(code string, d time.Time) (float64, bool) { return 15, code == "A" && d.Equal(date(2018, 1, 31)) }
+
+	years, err := CapitalGains(txns, equityRules, fmv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(years) != 1 || years[0].FY != "2019-20" || len(years[0].Lots) != 1 {
+		t.Fatalf("unexpected years %+v", years)
+	}
+	l := years[0].Lots[0]
+	// Cost is the higher of 1000 and min(100 × 15, 2000).
+	if !l.LongTerm || !l.Grandfathered || l.AcquisitionCost != 1500 || l.Gain != 500 || l.RatePct != 10 {
+		t.Fatalf("unexpected lot %+v", l)
+	}
+	if years[0].ExemptionUsed != 500 || years[0].Tax != 0 {
+		t.Fatalf("gain should be covered by the exemption, got %+v", years[0])
+	}
+}
+
+func TestCapitalGainsRateChangeWithinYear(t *testing.T) {
+	txns := []Transaction{
+		{SchemeCode: "A", Type: TypePurchase, Date: date(2023, 6, 1), Amount: 100000, Units: 1000},
+		// Exactly 12 months is still short term, taxed at the old 15%.
+		{SchemeCode: "A", Type: TypeRedemption, Date: date(2024, 6, 1), Amount: 60000, Units: 500},
+		{SchemeCode: "A", Type: TypeRedemption, Date: date(2024, 8, 1), Amount: 70000, Units: 500},
+		{SchemeCode: "B", Type: TypePurchase, Date: date(2024, 1, 1), Amount: 50000, Units: 500},
+		{SchemeCode: "B", Type: TypeSwitchOut, Date: date(2024, 9, 1), Amount: 40000, Units: 500},
+		{SchemeCode: "C", Type: TypePurchase, Date: date(2020, 1, 1), Amount: 100000, Units: 1000},
+		{SchemeCode: "C", Type: TypeRedemption, Date: date(2024, 10, 1), Amount: 300000, Units: 1000},
+	}
+	years, err := CapitalGains(txns, equityRules, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(years) != 1 {
+		t.Fatalf("expected one financial year, got %d", len(years))
+	}
+	y := years[0]
+	if y.FY != "2024-25" || y.STCG != 10000 || y.STCL != 10000 || y.LTCG != 220000 {
+		t.Fatalf("unexpected gross gains %+v", y)
+	}
+	// The 20% short-term loss wipes out the 15% short-term gain; 1.25 lakh of the long-term gain
+	// is exempt and the remaining 95000 is taxed at 12.5%.
+	if y.ExemptionLimit != 125000 || y.ExemptionUsed != 125000 {
+		t.Fatalf("exemption limit %v used %v, want 125000", y.ExemptionLimit, y.ExemptionUsed)
+	}
+	if math.Abs(y.Tax-11875) > 1e-6 || math.Abs(y.Cess-475) > 1e-6 {
+		t.Fatalf("tax %v cess %v, want 11875 475", y.Tax, y.Cess)
+	}
+}
+
+func TestCapitalGainsNoRule(t *testing.T) {
+	_, err := CapitalGains([]Transaction{
+		{SchemeCode: "A", Type: TypePurchase, Date: date(2005, 1, 1), Amount: 100, Units: 10},
+		{SchemeCode: "A", Type: TypeRedemption, Date: date(2006, 1, 1), Amount: 100, Units: 10},
+	}, equityRules, nil)
+	if _, ok := err.(*NoTaxRuleError); !ok {
+		t.Fatalf("expected NoTaxRuleError, got %v", err)
+	}
+}
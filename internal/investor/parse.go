@@ -0,0 +1,186 @@
+package investor
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the transaction date formats accepted in CSV files.
+var dateLayouts = []string{"2006-01-02", "02-01-2006", "02-Jan-2006", "02/01/2006"}
+
+// csvColumns maps accepted CSV header names to the field they fill.
+var csvColumns = map[string]string{
+	"date":        "date",
+	"txn_date":    "date",
+	"fund_code":   "fund_code",
+	"scheme_code": "fund_code",
+	"type":        "type",
+	"txn_type":    "type",
+	"amount":      "amount",
+	"units":       "units",
+}
+
+// ParseCSV reads transactions from a CSV file with a header row naming the date, fund_code, type,
+// amount and units columns, in any order. Errors name the offending line.
+func ParseCSV(r io.Reader) ([]Transaction, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			index[field] = i
+		}
+	}
+	for _, field := range []string{"date", "fund_code", "type", "amount", "units"} {
+		if _, ok := index[field]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %s column", field)
+		}
+	}
+
+	var out []Transaction
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		get := func(field string) string {
+			if i := index[field]; i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+
+		t := Transaction{
+			SchemeCode: get("fund_code"),
+			Type:       strings.ToLower(get("type")),
+		}
+		if t.Date, err = parseDate(get("date")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if t.Amount, err = parseAmount(get("amount")); err != nil {
+			return nil, fmt.Errorf("line %d: amount: %w", line, err)
+		}
+		if t.Units, err = parseAmount(get("units")); err != nil {
+			return nil, fmt.Errorf("line %d: units: %w", line, err)
+		}
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+var (
+	// casSchemeCode finds the AMFI code on a CAS scheme heading, either "AMFI: 119598" anywhere in
+	// the line or a leading "119598 - Scheme Name".
+	casSchemeCode = regexp.MustCompile(`(?i)\bAMFI(?:\s+code)?\s*:\s*(\d+)|^\s*(\d{5,6})\s+-\s+\S`)
+	// casTxn is a transaction row: date, description, amount, units, price and unit balance.
+	// Outflows are in parentheses.
+	casTxn = regexp.MustCompile(
+		`^\s*(\d{2}-[A-Za-z]{3}-\d{4})\s+(.+?)\s+(\(?-?[\d,]+\.\d+\)?)\s+(\(?-?[\d,]+\.\d+\)?)\s+([\d,]+\.\d+)\s+\(?[\d,]+\.\d+\)?\s*$`,
+	)
+)
+
+// ParseCAS reads transactions from the text export of a consolidated account statement. Each
+// scheme's rows follow a heading carrying its AMFI code; rows that aren't unit transactions (stamp
+// duty, STT, balances) are skipped. Types come from the sign of the units and whether the
+// description mentions a switch.
+func ParseCAS(r io.Reader) ([]Transaction, error) {
+	var out []Transaction
+	var scheme string
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if m := casSchemeCode.FindStringSubmatch(text); m != nil {
+			scheme = m[1] + m[2]
+			continue
+		}
+		m := casTxn.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		if scheme == "" {
+			return nil, fmt.Errorf("line %d: transaction before any scheme heading with an AMFI code", line)
+		}
+
+		date, err := time.Parse("02-Jan-2006", m[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		amount, err := parseAmount(m[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: amount: %w", line, err)
+		}
+		units, err := parseAmount(m[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: units: %w", line, err)
+		}
+
+		t := Transaction{SchemeCode: scheme, Date: date, Amount: math.Abs(amount), Units: math.Abs(units)}
+		switch {
+		case units < 0 && strings.Contains(strings.ToLower(m[2]), "switch"):
+			t.Type = TypeSwitchOut
+		case units < 0:
+			t.Type = TypeRedemption
+		case strings.Contains(strings.ToLower(m[2]), "switch"):
+			t.Type = TypeSwitchIn
+		default:
+			t.Type = TypePurchase
+		}
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, t)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", s)
+}
+
+// parseAmount parses a number that may carry thousands separators; parentheses make it negative.
+func parseAmount(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	neg := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	if neg {
+		s = s[1 : len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
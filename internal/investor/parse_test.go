@@ -0,0 +1,64 @@
+package investor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	in := "Type,Date,Fund_Code,Units,Amount\n" +
+		"purchase,2022-01-10,119598,100.000,\"5,000.00\"\n" +
+		"redemption,15-03-2023,119598,40.5,2500\n"
+	txns, err := ParseCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Transaction{
+		{SchemeCode: "119598", Type: TypePurchase, Date: time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC), Amount: 5000, Units: 100},
+		{SchemeCode: "119598", Type: TypeRedemption, Date: time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC), Amount: 2500, Units: 40.5},
+	}
+	if len(txns) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(txns), len(want))
+	}
+	for i := range want {
+		if txns[i] != want[i] {
+			t.Fatalf("transaction %d = %+v, want %+v", i, txns[i], want[i])
+		}
+	}
+
+	_, err = ParseCSV(strings.NewReader("date,fund_code,type,amount,units\n2022-01-10,119598,gift,10,1\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Fatalf("expected a line 2 error for an unknown type, got %v", err)
+	}
+}
+
+func TestParseCAS(t *testing.T) {
+	in := `Folio No: 1234567 / 89
+AMFI: 119598 Axis Midcap Fund - Direct Growth (Advisor: DIRECT)
+Opening Unit Balance: 0.000
+10-Jan-2022 Systematic Investment Purchase 5,000.00 100.000 50.0000 100.000
+10-Jan-2022 *** Stamp Duty *** 0.25
+15-Mar-2023 Redemption (2,500.00) (40.500) 61.7284 59.500
+Closing Unit Balance: 59.500
+120503 - Axis Bluechip Fund - Direct Growth
+01-Apr-2023 Switch-In from Axis Midcap Fund 1,000.00 20.000 50.0000 20.000
+`
+	txns, err := ParseCAS(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Transaction{
+		{SchemeCode: "119598", Type: TypePurchase, Date: time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC), Amount: 5000, Units: 100},
+		{SchemeCode: "119598", Type: TypeRedemption, Date: time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC), Amount: 2500, Units: 40.5},
+		{SchemeCode: "120503", Type: TypeSwitchIn, Date: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), Amount: 1000, Units: 20},
+	}
+	if len(txns) != len(want) {
+		t.Fatalf("got %d transactions %+v, want %d", len(txns), txns, len(want))
+	}
+	for i := range want {
+		if txns[i] != want[i] {
+			t.Fatalf("transaction %d = %+v, want %+v", i, txns[i], want[i])
+		}
+	}
+}
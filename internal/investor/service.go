@@ -0,0 +1,217 @@
+package investor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mf-analytics-service/internal/db"
+)
+
+// UnknownFundsError lists fund codes in an import that the service doesn't track.
+type UnknownFundsError struct {
+	Codes []string
+}
+
+func (e *UnknownFundsError) Error() string {
+	return "unknown fund codes: " + strings.Join(e.Codes, ", ")
+}
+
+// Import stores txns for userID, skipping ones already stored, and returns how many were added.
+// Every fund must be tracked; nothing is stored otherwise.
+func Import(ctx context.Context, pool *pgxpool.Pool, userID, source string, txns []Transaction) (int64, error) {
+	if !ValidUserID(userID) {
+		return 0, ErrInvalidUserID
+	}
+	q := db.New(pool)
+
+	params := db.InsertUserTransactionsParams{UserID: userID, Source: source}
+	checked := map[string]bool{}
+	var unknown []string
+	for _, t := range txns {
+		if err := t.validate(); err != nil {
+			return 0, err
+		}
+		if _, ok := checked[t.SchemeCode]; !ok {
+			_, err := q.GetFund(ctx, t.SchemeCode)
+			switch {
+			case errors.Is(err, pgx.ErrNoRows):
+				unknown = append(unknown, t.SchemeCode)
+			case err != nil:
+				return 0, err
+			}
+			checked[t.SchemeCode] = true
+		}
+		params.SchemeCodes = append(params.SchemeCodes, t.SchemeCode)
+		params.TxnTypes = append(params.TxnTypes, t.Type)
+		params.TxnDates = append(params.TxnDates, pgtype.Date{Time: t.Date, Valid: true})
+		params.Amounts = append(params.Amounts, t.Amount)
+		params.Units = append(params.Units, t.Units)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return 0, &UnknownFundsError{Codes: unknown}
+	}
+	if len(txns) == 0 {
+		return 0, nil
+	}
+	return q.InsertUserTransactions(ctx, params)
+}
+
+// Transactions loads userID's stored transactions in date order.
+func Transactions(ctx context.Context, pool *pgxpool.Pool, userID string) ([]Transaction, error) {
+	rows, err := db.New(pool).ListUserTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Transaction, len(rows))
+	for i, row := range rows {
+		out[i] = Transaction{
+			SchemeCode: row.SchemeCode,
+			Type:       row.TxnType,
+			Date:       row.TxnDate.Time,
+			Amount:     row.Amount.InexactFloat64(),
+			Units:      row.Units.InexactFloat64(),
+		}
+	}
+	return out, nil
+}
+
+// Portfolio values userID's transactions at each fund's latest NAV.
+func Portfolio(ctx context.Context, pool *pgxpool.Pool, userID string) (Summary, error) {
+	txns, err := Transactions(ctx, pool, userID)
+	if err != nil {
+		return Summary{}, err
+	}
+	q := db.New(pool)
+	quotes := map[string]Quote{}
+	for _, t := range txns {
+		if _, ok := quotes[t.SchemeCode]; ok {
+			continue
+		}
+		nav, err := q.GetLatestNav(ctx, t.SchemeCode)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Summary{}, err
+		}
+		quotes[t.SchemeCode] = Quote{Date: nav.NavDate.Time, NAV: nav.NavValue.InexactFloat64()}
+	}
+	return Value(txns, quotes)
+}
+
+// AssetClassEquity is the only asset class with capital gains rules so far.
+const AssetClassEquity = "equity"
+
+// CapitalGainsReport is a user's realized gains per financial year on equity funds. Funds of other
+// categories are listed in Excluded and left out.
+type CapitalGainsReport struct {
+	Years    []FYTax
+	Excluded []string
+}
+
+// UserCapitalGains computes the capital gains on userID's redemptions and switch-outs from equity
+// funds under the rules stored in `capital_gains_tax_rules`.
+func UserCapitalGains(ctx context.Context, pool *pgxpool.Pool, userID string) (CapitalGainsReport, error) {
+	txns, err := Transactions(ctx, pool, userID)
+	if err != nil {
+		return CapitalGainsReport{}, err
+	}
+	q := db.New(pool)
+	rules, err := TaxRules(ctx, pool, AssetClassEquity)
+	if err != nil {
+		return CapitalGainsReport{}, err
+	}
+
+	var out CapitalGainsReport
+	equity := map[string]bool{}
+	var kept []Transaction
+	for _, t := range txns {
+		isEquity, ok := equity[t.SchemeCode]
+		if !ok {
+			f, err := q.GetFund(ctx, t.SchemeCode)
+			if err != nil {
+				return CapitalGainsReport{}, err
+			}
+			isEquity = strings.HasPrefix(strings.ToLower(f.Category), "equity")
+			equity[t.SchemeCode] = isEquity
+			if !isEquity {
+				out.Excluded = append(out.Excluded, t.SchemeCode)
+			}
+		}
+		if isEquity {
+			kept = append(kept, t)
+		}
+	}
+	sort.Strings(out.Excluded)
+
+	// Grandfathered costs need each fund's NAV on the grandfather dates in use.
+	type fmvKey struct {
+		code string
+		date time.Time
+	}
+	fmvs := map[fmvKey]float64{}
+	for _, rule := range rules {
+		if rule.GrandfatherDate.IsZero() {
+			continue
+		}
+		for code, isEquity := range equity {
+			k := fmvKey{code, rule.GrandfatherDate}
+			if _, ok := fmvs[k]; ok || !isEquity {
+				continue
+			}
+			nav, err := q.GetNavOnOrBefore(ctx, db.GetNavOnOrBeforeParams{
+				SchemeCode: code,
+				NavDate:    pgtype.Date{Time: rule.GrandfatherDate, Valid: true},
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return CapitalGainsReport{}, err
+			}
+			fmvs[k] = nav.NavValue.InexactFloat64()
+		}
+	}
+	fmv := func(code string, date time.Time) (float64, bool) {
+		v, ok := fmvs[fmvKey{code, date}]
+		return v, ok
+	}
+
+	out.Years, err = CapitalGains(kept, rules, fmv)
+	if err != nil {
+		return CapitalGainsReport{}, err
+	}
+	return out, nil
+}
+
+// TaxRules loads an asset class's capital gains rules, oldest first.
+func TaxRules(ctx context.Context, pool *pgxpool.Pool, assetClass string) ([]TaxRule, error) {
+	rows, err := db.New(pool).ListCapitalGainsTaxRules(ctx, assetClass)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TaxRule, len(rows))
+	for i, row := range rows {
+		out[i] = TaxRule{
+			Version:              row.Version,
+			EffectiveFrom:        row.EffectiveFrom.Time,
+			LTCGMinHoldingMonths: int(row.LtcgMinHoldingMonths),
+			STCGRatePct:          row.StcgRate.InexactFloat64(),
+			LTCGRatePct:          row.LtcgRate.InexactFloat64(),
+			LTCGExemption:        row.LtcgExemption.InexactFloat64(),
+			CessPct:              row.CessRate.InexactFloat64(),
+		}
+		if row.GrandfatherDate.Valid {
+			out[i].GrandfatherDate = row.GrandfatherDate.Time
+		}
+	}
+	return out, nil
+}
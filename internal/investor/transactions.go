@@ -0,0 +1,68 @@
+// Package investor tracks investors' own transactions in the funds the service follows and values
+// their holdings against `nav_history`.
+package investor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Transaction types. A switch moves money between two funds and is recorded as a switch_out of
+// one and a switch_in of the other.
+const (
+	TypePurchase   = "purchase"
+	TypeRedemption = "redemption"
+	TypeSwitchIn   = "switch_in"
+	TypeSwitchOut  = "switch_out"
+)
+
+// Import sources, stored with each transaction.
+const (
+	SourceCSV = "csv"
+	SourceCAS = "cas"
+)
+
+// Transaction is one purchase, redemption or switch leg. Amount and Units are always positive;
+// Type says which way the money moved.
+type Transaction struct {
+	SchemeCode string
+	Type       string
+	Date       time.Time
+	Amount     float64
+	Units      float64
+}
+
+var ErrInvalidUserID = errors.New("user id must be 1-64 characters of letters, digits, '.', '_', '-' or '@'")
+
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9._@-]{1,64}$`)
+
+// ValidUserID reports whether id is usable as a `user_transactions.user_id`.
+func ValidUserID(id string) bool {
+	return userIDPattern.MatchString(id)
+}
+
+// inflow reports whether the transaction puts money into its fund.
+func (t Transaction) inflow() bool {
+	return t.Type == TypePurchase || t.Type == TypeSwitchIn
+}
+
+func (t Transaction) validate() error {
+	switch t.Type {
+	case TypePurchase, TypeRedemption, TypeSwitchIn, TypeSwitchOut:
+	default:
+		return fmt.Errorf("type must be purchase|redemption|switch_in|switch_out, got %q", t.Type)
+	}
+	switch {
+	case t.SchemeCode == "":
+		return errors.New("fund code is required")
+	case t.Date.IsZero():
+		return errors.New("date is required")
+	case !(t.Amount > 0):
+		return errors.New("amount must be positive")
+	case !(t.Units > 0):
+		return errors.New("units must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package investor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"mf-analytics-service/internal/analytics"
+)
+
+// unitTolerance absorbs the rounding of units in statements (usually 3 decimals) when a
+// redemption empties a fund.
+const unitTolerance = 0.001
+
+// Lot is an open purchase or switch-in, reduced first-in first-out by later outflows.
+type Lot struct {
+	Date  time.Time
+	Units float64
+	Cost  float64
+}
+
+// Quote is the NAV a fund is valued at.
+type Quote struct {
+	Date time.Time
+	NAV  float64
+}
+
+// Position is an investor's holding in one fund, valued at its latest NAV.
+type Position struct {
+	SchemeCode string
+	Units      float64
+	// Invested and Withdrawn are gross inflows (purchases, switch-ins) and outflows (redemption and
+	// switch-out proceeds); CostBasis is the FIFO cost of the units still held.
+	Invested       float64
+	Withdrawn      float64
+	CostBasis      float64
+	ValuationDate  time.Time // zero when nothing is held
+	NAV            float64
+	CurrentValue   float64
+	RealizedGain   float64
+	UnrealizedGain float64
+	XIRRPct        float64 // NaN when no rate solves the cash flows
+	FirstInvested  time.Time
+	// HoldingDays is the unit-weighted age of the open lots at the valuation date.
+	HoldingDays float64
+	Lots        []Lot
+}
+
+// Summary is an investor's whole portfolio. Totals are sums over the positions; XIRR runs over
+// every fund's cash flows together, so the two legs of a switch cancel out.
+type Summary struct {
+	Positions      []Position
+	Invested       float64
+	Withdrawn      float64
+	CostBasis      float64
+	CurrentValue   float64
+	RealizedGain   float64
+	UnrealizedGain float64
+	XIRRPct        float64
+}
+
+// OversoldError is an outflow of more units than the investor held, usually a statement with
+// missing purchases.
+type OversoldError struct {
+	SchemeCode string
+	Date       time.Time
+	Units      float64
+	Held       float64
+}
+
+func (e *OversoldError) Error() string {
+	return fmt.Sprintf(
+		"fund %s: outflow of %.4f units on %s exceeds the %.4f held",
+		e.SchemeCode, e.Units, e.Date.Format("2006-01-02"), e.Held,
+	)
+}
+
+// MissingQuoteError is a fund with units held but no NAV to value them at.
+type MissingQuoteError struct {
+	SchemeCode string
+}
+
+func (e *MissingQuoteError) Error() string {
+	return "fund " + e.SchemeCode + " has no nav to value holdings at"
+}
+
+// Value replays txns per fund in date order, matching outflows to lots first-in first-out, and
+// values what is left at quotes. Funds are returned in scheme code order.
+func Value(txns []Transaction, quotes map[string]Quote) (Summary, error) {
+	byScheme := map[string][]Transaction{}
+	for _, t := range txns {
+		byScheme[t.SchemeCode] = append(byScheme[t.SchemeCode], t)
+	}
+	codes := make([]string, 0, len(byScheme))
+	for code := range byScheme {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	out := Summary{Positions: make([]Position, 0, len(codes)), XIRRPct: math.NaN()}
+	var all []analytics.CashFlow
+	for _, code := range codes {
+		p, flows, err := valuePosition(code, byScheme[code], quotes)
+		if err != nil {
+			return Summary{}, err
+		}
+		out.Positions = append(out.Positions, p)
+		out.Invested += p.Invested
+		out.Withdrawn += p.Withdrawn
+		out.CostBasis += p.CostBasis
+		out.CurrentValue += p.CurrentValue
+		out.RealizedGain += p.RealizedGain
+		out.UnrealizedGain += p.UnrealizedGain
+		all = append(all, flows...)
+	}
+	if r, err := analytics.XIRR(all); err == nil {
+		out.XIRRPct = r * 100.0
+	}
+	return out, nil
+}
+
+// valuePosition values one fund's transactions, returning its cash flows including the terminal
+// value for the portfolio XIRR.
+func valuePosition(code string, txns []Transaction, quotes map[string]Quote) (Position, []analytics.CashFlow, error) {
+	txns = replayOrder(txns)
+	p := Position{SchemeCode: code, XIRRPct: math.NaN()}
+	flows := make([]analytics.CashFlow, 0, len(txns)+1)
+	for _, t := range txns {
+		if t.inflow() {
+			if p.FirstInvested.IsZero() {
+				p.FirstInvested = t.Date
+			}
+			p.Lots = append(p.Lots, Lot{Date: t.Date, Units: t.Units, Cost: t.Amount})
+			p.Invested += t.Amount
+			flows = append(flows, analytics.CashFlow{Date: t.Date, Amount: -t.Amount})
+			continue
+		}
+
+		sold, err := consumeLots(&p.Lots, code, t)
+		if err != nil {
+			return Position{}, nil, err
+		}
+		var cost float64
+		for _, l := range sold {
+			cost += l.Cost
+		}
+		p.Withdrawn += t.Amount
+		p.RealizedGain += t.Amount - cost
+		flows = append(flows, analytics.CashFlow{Date: t.Date, Amount: t.Amount})
+	}
+
+	for _, l := range p.Lots {
+		p.Units += l.Units
+		p.CostBasis += l.Cost
+	}
+	if len(p.Lots) > 0 {
+		q, ok := quotes[code]
+		if !ok || q.NAV <= 0 {
+			return Position{}, nil, &MissingQuoteError{SchemeCode: code}
+		}
+		p.ValuationDate, p.NAV = q.Date, q.NAV
+		p.CurrentValue = p.Units * q.NAV
+		p.UnrealizedGain = p.CurrentValue - p.CostBasis
+		var unitDays float64
+		for _, l := range p.Lots {
+			unitDays += l.Units * q.Date.Sub(l.Date).Hours() / 24
+		}
+		p.HoldingDays = unitDays / p.Units
+		flows = append(flows, analytics.CashFlow{Date: q.Date, Amount: p.CurrentValue})
+	}
+
+	if r, err := analytics.XIRR(flows); err == nil {
+		p.XIRRPct = r * 100.0
+	}
+	return p, flows, nil
+}
+
+// replayOrder returns a copy of one fund's txns in date order. Same-day inflows go first so a buy
+// and sell on one day can match.
+func replayOrder(txns []Transaction) []Transaction {
+	txns = append([]Transaction(nil), txns...)
+	sort.SliceStable(txns, func(i, j int) bool {
+		if !txns[i].Date.Equal(txns[j].Date) {
+			return txns[i].Date.Before(txns[j].Date)
+		}
+		return txns[i].inflow() && !txns[j].inflow()
+	})
+	return txns
+}
+
+// consumeLots removes t.Units from the front of the open lots and returns the parts it sold.
+func consumeLots(lots *[]Lot, code string, t Transaction) ([]Lot, error) {
+	var held float64
+	for _, l := range *lots {
+		held += l.Units
+	}
+	if t.Units > held+unitTolerance {
+		return nil, &OversoldError{SchemeCode: code, Date: t.Date, Units: t.Units, Held: held}
+	}
+
+	remaining := t.Units
+	var sold []Lot
+	for len(*lots) > 0 && remaining > 0 {
+		l := &(*lots)[0]
+		if l.Units <= remaining+unitTolerance {
+			sold = append(sold, *l)
+			remaining -= l.Units
+			*lots = (*lots)[1:]
+			continue
+		}
+		part := Lot{Date: l.Date, Units: remaining, Cost: l.Cost * remaining / l.Units}
+		sold = append(sold, part)
+		l.Cost -= part.Cost
+		l.Units -= remaining
+		remaining = 0
+	}
+	return sold, nil
+}
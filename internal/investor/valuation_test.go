@@ -0,0 +1,64 @@
+package investor
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValueFIFO(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+	txns := []Transaction{
+		{SchemeCode: "A", Type: TypePurchase, Date: d(2022, 1, 1), Amount: 1000, Units: 100},
+		{SchemeCode: "A", Type: TypePurchase, Date: d(2022, 7, 1), Amount: 1500, Units: 100},
+		// Sells the whole first lot and half the second: cost 1000 + 750.
+		{SchemeCode: "A", Type: TypeRedemption, Date: d(2023, 1, 1), Amount: 3000, Units: 150},
+		{SchemeCode: "A", Type: TypeSwitchOut, Date: d(2023, 1, 1), Amount: 1000, Units: 50},
+		{SchemeCode: "B", Type: TypeSwitchIn, Date: d(2023, 1, 1), Amount: 1000, Units: 10},
+	}
+	quotes := map[string]Quote{"B": {Date: d(2024, 1, 1), NAV: 110}}
+
+	s, err := Value(txns, quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Positions) != 2 {
+		t.Fatalf("expected 2 positions, got %d", len(s.Positions))
+	}
+	a, b := s.Positions[0], s.Positions[1]
+	if a.Units != 0 || len(a.Lots) != 0 || a.CurrentValue != 0 {
+		t.Fatalf("fund A should be fully redeemed, got %+v", a)
+	}
+	// 3000 - 1750 on the redemption, 1000 - 750 on the switch-out.
+	if math.Abs(a.RealizedGain-1500) > 1e-9 {
+		t.Fatalf("realized gain %v, want 1500", a.RealizedGain)
+	}
+	if b.CurrentValue != 1100 || b.UnrealizedGain != 100 || b.HoldingDays != 365 {
+		t.Fatalf("fund B value %v gain %v days %v, want 1100 100 365", b.CurrentValue, b.UnrealizedGain, b.HoldingDays)
+	}
+	if math.Abs(b.XIRRPct-10) > 1e-6 {
+		t.Fatalf("fund B XIRR %v, want 10", b.XIRRPct)
+	}
+	if math.IsNaN(s.XIRRPct) || s.RealizedGain != a.RealizedGain || s.CurrentValue != 1100 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+}
+
+func TestValueErrors(t *testing.T) {
+	d := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := Value([]Transaction{
+		{SchemeCode: "A", Type: TypePurchase, Date: d, Amount: 100, Units: 10},
+		{SchemeCode: "A", Type: TypeRedemption, Date: d.AddDate(0, 1, 0), Amount: 200, Units: 11},
+	}, nil)
+	var oversold *OversoldError
+	if !errors.As(err, &oversold) {
+		t.Fatalf("expected an OversoldError, got %v", err)
+	}
+
+	_, err = Value([]Transaction{{SchemeCode: "A", Type: TypePurchase, Date: d, Amount: 100, Units: 10}}, nil)
+	var missing *MissingQuoteError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a MissingQuoteError, got %v", err)
+	}
+}
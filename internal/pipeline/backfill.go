@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -22,6 +23,36 @@ type BackfillRunner struct {
 	mf         *mfapi.Client
 	staleAfter time.Duration
 	log        *slog.Logger
+	windows    []analytics.WindowSpec
+	// riskFreePct is the rate Sharpe ratios and consistency are measured against.
+	riskFreePct float64
+	// incrementalAnalytics makes daily syncs extend stored analytics instead of recomputing them.
+	incrementalAnalytics bool
+	// historyRetention prunes analytics history snapshots older than this after each run; 0 keeps all.
+	historyRetention time.Duration
+}
+
+type RunnerOption func(*BackfillRunner)
+
+// WithWindows sets the analytics windows precomputed after each scheme sync.
+func WithWindows(windows []analytics.WindowSpec) RunnerOption {
+	return func(r *BackfillRunner) { r.windows = windows }
+}
+
+// WithRiskFreeRate sets the annual risk-free rate (%) of Sharpe ratios and consistency.
+func WithRiskFreeRate(pct float64) RunnerOption {
+	return func(r *BackfillRunner) { r.riskFreePct = pct }
+}
+
+// WithHistoryRetention prunes `fund_analytics_history` snapshots older than d after each run.
+func WithHistoryRetention(d time.Duration) RunnerOption {
+	return func(r *BackfillRunner) { r.historyRetention = d }
+}
+
+// WithIncrementalAnalytics makes incremental syncs update analytics from the new NAVs only.
+// Full backfills always recompute.
+func WithIncrementalAnalytics(enabled bool) RunnerOption {
+	return func(r *BackfillRunner) { r.incrementalAnalytics = enabled }
 }
 
 func NewBackfillRunner(
@@ -29,11 +60,23 @@ func NewBackfillRunner(
 	mf *mfapi.Client,
 	staleAfter time.Duration,
 	logger *slog.Logger,
+	opts ...RunnerOption,
 ) *BackfillRunner {
 	if staleAfter <= 0 {
 		staleAfter = 15 * time.Minute
 	}
-	return &BackfillRunner{pool: pool, mf: mf, staleAfter: staleAfter, log: logger}
+	r := &BackfillRunner{
+		pool:        pool,
+		mf:          mf,
+		staleAfter:  staleAfter,
+		log:         logger,
+		windows:     analytics.DefaultWindows,
+		riskFreePct: analytics.DefaultRiskFreeRatePct,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // RunLatest processes the latest RUNNING run until drained and marks it completed/failed.
@@ -63,7 +106,15 @@ func (r *BackfillRunner) RunLatest(ctx context.Context) (processed bool, err err
 		st, err := q.ClaimNextSyncState(ctx)
 		if err != nil {
 			if err == pgx.ErrNoRows {
-				// drained; mark overall run status based on per-scheme outcomes.
+				// drained; refresh peer-group stats from the updated per-fund analytics.
+				if err := analytics.RefreshCategoryAnalytics(ctx, r.pool); err != nil && r.log != nil {
+					r.log.Warn("category analytics refresh failed", "error", err)
+				}
+				if err := r.pruneHistory(ctx); err != nil && r.log != nil {
+					r.log.Warn("analytics history prune failed", "error", err)
+				}
+
+				// mark overall run status based on per-scheme outcomes.
 				counts, err := q.CountSyncStateByStatus(ctx)
 				if err != nil {
 					return processed, err
@@ -74,6 +125,8 @@ func (r *BackfillRunner) RunLatest(ctx context.Context) (processed bool, err err
 						failed = c.Count
 					}
 				}
+				// even a failed run changed the schemes that did sync, so both outcomes notify.
+				defer r.notifySyncCompleted(ctx, q, run.RunID)
 				if failed > 0 {
 					if r.log != nil {
 						r.log.Warn("run finished with failures", "failed", failed)
@@ -181,7 +234,7 @@ func (r *BackfillRunner) backfillOne(ctx context.Context, st db.SyncState) error
 		return r.failSyncState(ctx, st, fmt.Errorf("no nav data returned"))
 	}
 
-	if err := analytics.ComputeAndUpsert(ctx, r.pool, st.SchemeCode); err != nil {
+	if err := analytics.ComputeAndUpsert(ctx, r.pool, st.SchemeCode, r.windows, r.riskFreePct); err != nil {
 		return r.failSyncState(ctx, st, fmt.Errorf("compute analytics: %w", err))
 	}
 
@@ -202,3 +255,24 @@ func (r *BackfillRunner) failSyncState(ctx context.Context, st db.SyncState, cau
 	})
 	return cause
 }
+
+// pruneHistory applies the analytics history retention, if any.
+func (r *BackfillRunner) pruneHistory(ctx context.Context) error {
+	if r.historyRetention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().UTC().Add(-r.historyRetention)
+	n, err := db.New(r.pool).DeleteFundAnalyticsHistoryBefore(ctx, pgtype.Date{Time: cutoff, Valid: true})
+	if err == nil && n > 0 && r.log != nil {
+		r.log.Info("pruned analytics history", "snapshots", n, "before", cutoff.Format("2006-01-02"))
+	}
+	return err
+}
+
+// notifySyncCompleted announces a finished run on events.SyncCompleted. A lost notification only
+// leaves API caches stale until their TTL, so a failure is logged rather than failing the run.
+func (r *BackfillRunner) notifySyncCompleted(ctx context.Context, q *db.Queries, runID pgtype.UUID) {
+	if err := q.NotifySyncCompleted(ctx, uuid.UUID(runID.Bytes).String()); err != nil && r.log != nil {
+		r.log.Warn("sync completion notify failed", "error", err)
+	}
+}
@@ -65,7 +65,11 @@ func (r *BackfillRunner) incrementalOne(ctx context.Context, st db.SyncState) er
 		}
 	}
 
-	if err := analytics.ComputeAndUpsert(ctx, r.pool, st.SchemeCode); err != nil {
+	compute := analytics.ComputeAndUpsert
+	if r.incrementalAnalytics {
+		compute = analytics.ComputeIncrementalAndUpsert
+	}
+	if err := compute(ctx, r.pool, st.SchemeCode, r.windows, r.riskFreePct); err != nil {
 		return r.failSyncState(ctx, st, fmt.Errorf("compute analytics: %w", err))
 	}
 
@@ -44,6 +44,18 @@ func DefaultConfig() Config {
 	}
 }
 
+// DefaultKeyConfig paces inbound requests of one API key.
+func DefaultKeyConfig() Config {
+	return Config{
+		Now: time.Now,
+		Windows: []WindowConfig{
+			{Type: WindowSecond, Duration: time.Second, Limit: 20},
+			{Type: WindowMinute, Duration: time.Minute, Limit: 600},
+			{Type: WindowHour, Duration: time.Hour, Limit: 10000},
+		},
+	}
+}
+
 // Logger is intentionally minimal so callers can use stdlib log.Logger, zap, etc.
 type Logger interface {
 	Printf(format string, args ...any)
@@ -99,9 +111,20 @@ func (l *Limiter) Acquire(ctx context.Context) error {
 	}
 }
 
+// TryAcquire takes one request from the shared windows (outbound mfapi calls). When any window is
+// exhausted it returns ok=false and how long until that window resets.
 func (l *Limiter) TryAcquire(ctx context.Context) (wait time.Duration, ok bool, err error) {
+	return l.tryAcquire(ctx, sharedState{})
+}
+
+// TryAcquireKey is TryAcquire with windows of their own per key, e.g. an inbound API key.
+func (l *Limiter) TryAcquireKey(ctx context.Context, key string) (wait time.Duration, ok bool, err error) {
+	return l.tryAcquire(ctx, keyState{key: key})
+}
+
+func (l *Limiter) tryAcquire(ctx context.Context, state windowState) (wait time.Duration, ok bool, err error) {
 	now := l.cfg.Now().UTC()
-	l.logf("ratelimiter attempt now=%s", now.Format(time.RFC3339Nano))
+	l.logf("ratelimiter attempt %snow=%s", state.label(), now.Format(time.RFC3339Nano))
 
 	tx, err := l.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
@@ -115,11 +138,7 @@ func (l *Limiter) TryAcquire(ctx context.Context) (wait time.Duration, ok bool,
 	// Ensure rows exist so FOR UPDATE works reliably.
 	for _, w := range l.cfg.Windows {
 		ws := truncateTo(now, w.Duration)
-		if err := q.UpsertRateLimiterState(ctx, db.UpsertRateLimiterStateParams{
-			WindowType:   string(w.Type),
-			WindowStart:  toPgTimestamp(ws),
-			RequestCount: 0,
-		}); err != nil {
+		if err := state.upsert(ctx, q, w.Type, ws, 0); err != nil {
 			l.logf("ratelimiter init_state window=%s error=%v", w.Type, err)
 			return 0, false, err
 		}
@@ -136,7 +155,7 @@ func (l *Limiter) TryAcquire(ctx context.Context) (wait time.Duration, ok bool,
 	var blockReason string
 
 	for _, w := range l.cfg.Windows {
-		st, err := q.GetRateLimiterStateForUpdate(ctx, string(w.Type))
+		st, err := state.getForUpdate(ctx, q, w.Type)
 		if err != nil {
 			l.logf("ratelimiter read_for_update window=%s error=%v", w.Type, err)
 			return 0, false, err
@@ -187,11 +206,7 @@ func (l *Limiter) TryAcquire(ctx context.Context) (wait time.Duration, ok bool,
 	}
 
 	for _, e := range evals {
-		if err := q.UpsertRateLimiterState(ctx, db.UpsertRateLimiterStateParams{
-			WindowType:   string(e.cfg.Type),
-			WindowStart:  toPgTimestamp(e.windowStart),
-			RequestCount: e.nextCount,
-		}); err != nil {
+		if err := state.upsert(ctx, q, e.cfg.Type, e.windowStart, e.nextCount); err != nil {
 			l.logf("ratelimiter write_state window=%s error=%v", e.cfg.Type, err)
 			return 0, false, err
 		}
@@ -205,6 +220,57 @@ func (l *Limiter) TryAcquire(ctx context.Context) (wait time.Duration, ok bool,
 	return 0, true, nil
 }
 
+// windowState reads and writes the fixed-window counters, within the limiter's transaction.
+type windowState interface {
+	getForUpdate(ctx context.Context, q *db.Queries, w WindowType) (db.RateLimiterState, error)
+	upsert(ctx context.Context, q *db.Queries, w WindowType, start time.Time, count int32) error
+	label() string
+}
+
+// sharedState is the one set of windows in rate_limiter_state.
+type sharedState struct{}
+
+func (sharedState) getForUpdate(ctx context.Context, q *db.Queries, w WindowType) (db.RateLimiterState, error) {
+	return q.GetRateLimiterStateForUpdate(ctx, string(w))
+}
+
+func (sharedState) upsert(ctx context.Context, q *db.Queries, w WindowType, start time.Time, count int32) error {
+	return q.UpsertRateLimiterState(ctx, db.UpsertRateLimiterStateParams{
+		WindowType:   string(w),
+		WindowStart:  toPgTimestamp(start),
+		RequestCount: count,
+	})
+}
+
+func (sharedState) label() string { return "" }
+
+// keyState is one key's windows in api_key_rate_limit_state.
+type keyState struct{ key string }
+
+func (s keyState) getForUpdate(ctx context.Context, q *db.Queries, w WindowType) (db.RateLimiterState, error) {
+	st, err := q.GetAPIKeyRateLimitStateForUpdate(ctx, db.GetAPIKeyRateLimitStateForUpdateParams{
+		KeyID:      s.key,
+		WindowType: string(w),
+	})
+	return db.RateLimiterState{
+		WindowType:   st.WindowType,
+		WindowStart:  st.WindowStart,
+		RequestCount: st.RequestCount,
+		UpdatedAt:    st.UpdatedAt,
+	}, err
+}
+
+func (s keyState) upsert(ctx context.Context, q *db.Queries, w WindowType, start time.Time, count int32) error {
+	return q.UpsertAPIKeyRateLimitState(ctx, db.UpsertAPIKeyRateLimitStateParams{
+		KeyID:        s.key,
+		WindowType:   string(w),
+		WindowStart:  toPgTimestamp(start),
+		RequestCount: count,
+	})
+}
+
+func (s keyState) label() string { return "key=" + s.key + " " }
+
 func truncateTo(t time.Time, d time.Duration) time.Time {
 	return t.Truncate(d)
 }
@@ -145,6 +145,63 @@ func TestLimiter_PersistsAcrossInstances(t *testing.T) {
 	}
 }
 
+func TestLimiter_KeysHaveSeparateWindows(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS api_key_rate_limit_state")
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS api_keys")
+	ddl, err := os.ReadFile("../../migrations/000017_api_keys.up.sql")
+	if err != nil {
+		t.Fatalf("read migration: %v", err)
+	}
+	if _, err := pool.Exec(ctx, string(ddl)); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, id := range []string{"000000000000000a", "000000000000000b"} {
+		if _, err := pool.Exec(ctx,
+			"INSERT INTO api_keys (key_id, name, secret_hash, scopes) VALUES ($1, 'test', '\\x00', '{read}')", id,
+		); err != nil {
+			t.Fatalf("insert key: %v", err)
+		}
+	}
+
+	l, err := New(pool, Config{
+		Now: time.Now,
+		Windows: []WindowConfig{
+			{Type: WindowMinute, Duration: time.Minute, Limit: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, ok, err := l.TryAcquireKey(ctx, "000000000000000a"); err != nil || !ok {
+			t.Fatalf("TryAcquireKey a #%d: ok=%v err=%v", i+1, ok, err)
+		}
+	}
+	wait, ok, err := l.TryAcquireKey(ctx, "000000000000000a")
+	if err != nil || ok || wait <= 0 {
+		t.Fatalf("expected a to be denied with a wait; ok=%v wait=%s err=%v", ok, wait, err)
+	}
+	// Another key starts with a full window.
+	if _, ok, err := l.TryAcquireKey(ctx, "000000000000000b"); err != nil || !ok {
+		t.Fatalf("TryAcquireKey b: ok=%v err=%v", ok, err)
+	}
+}
+
 func resetSchema(ctx context.Context, pool *pgxpool.Pool) error {
 	// Best-effort cleanup; ignore errors for non-existent tables.
 	drop := []string{
@@ -0,0 +1,12 @@
+CREATE TABLE fund_rolling_returns (
+    scheme_code   VARCHAR(20) NOT NULL,
+    "window"      VARCHAR(5) NOT NULL, -- 1Y | 3Y | 5Y | 10Y
+    end_date      DATE NOT NULL,
+    start_date    DATE NOT NULL,
+
+    return_pct    NUMERIC(8,2) NOT NULL,
+    cagr_pct      NUMERIC(8,2),
+
+    PRIMARY KEY (scheme_code, "window", end_date),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
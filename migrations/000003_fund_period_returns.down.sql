@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS fund_calendar_returns;
+DROP TABLE IF EXISTS fund_trailing_returns;
@@ -0,0 +1,30 @@
+CREATE TABLE fund_trailing_returns (
+    scheme_code       VARCHAR(20) NOT NULL,
+    period            VARCHAR(5) NOT NULL, -- 1M | 3M | 6M | YTD | 1Y | 3Y | 5Y
+
+    start_date        DATE,
+    end_date          DATE,
+    absolute_return   NUMERIC(8,2),
+    annualized_return NUMERIC(8,2),
+
+    computed_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (scheme_code, period),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
+
+CREATE TABLE fund_calendar_returns (
+    scheme_code   VARCHAR(20) NOT NULL,
+    year          INT NOT NULL,
+
+    start_date    DATE NOT NULL,
+    end_date      DATE NOT NULL,
+    return_pct    NUMERIC(8,2) NOT NULL,
+    complete      BOOLEAN NOT NULL,
+    -- false for the inception year and the current (year-to-date) year
+
+    computed_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (scheme_code, year),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
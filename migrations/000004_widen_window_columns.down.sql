@@ -0,0 +1,2 @@
+ALTER TABLE fund_rolling_returns ALTER COLUMN "window" TYPE VARCHAR(5);
+ALTER TABLE fund_analytics ALTER COLUMN "window" TYPE VARCHAR(5);
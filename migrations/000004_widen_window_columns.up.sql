@@ -0,0 +1,3 @@
+-- Windows are configurable (e.g. 6M, 18M, 7Y, 120M); widen to analytics.MaxWindowLabelLen.
+ALTER TABLE fund_analytics ALTER COLUMN "window" TYPE VARCHAR(8);
+ALTER TABLE fund_rolling_returns ALTER COLUMN "window" TYPE VARCHAR(8);
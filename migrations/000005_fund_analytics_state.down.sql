@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS fund_analytics_state;
+
+ALTER TABLE fund_rolling_returns
+    ALTER COLUMN return_pct TYPE NUMERIC(8,2),
+    ALTER COLUMN cagr_pct TYPE NUMERIC(8,2);
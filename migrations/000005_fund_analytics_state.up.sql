@@ -0,0 +1,18 @@
+-- Rolling series values are kept at full precision so that extending analytics from newly
+-- appended NAVs reproduces a full recompute exactly.
+ALTER TABLE fund_rolling_returns
+    ALTER COLUMN return_pct TYPE DOUBLE PRECISION,
+    ALTER COLUMN cagr_pct TYPE DOUBLE PRECISION;
+
+CREATE TABLE fund_analytics_state (
+    scheme_code     VARCHAR(20) NOT NULL,
+    "window"        VARCHAR(8) NOT NULL,
+
+    last_end_date   DATE,             -- end date of the latest rolling period
+    worst_drawdown  DOUBLE PRECISION, -- unrounded; NULL until the first period
+
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (scheme_code, "window"),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
@@ -0,0 +1 @@
+ALTER TABLE fund_analytics DROP COLUMN IF EXISTS distribution;
@@ -0,0 +1,4 @@
+-- Full rolling return distribution (extra percentiles, moments, quantiles, histogram) for
+-- `detail=full`. Read only for single-fund responses, so it is stored as one JSONB document
+-- rather than as sortable columns.
+ALTER TABLE fund_analytics ADD COLUMN distribution JSONB;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS fund_category_ranks;
+DROP TABLE IF EXISTS category_analytics;
@@ -0,0 +1,35 @@
+-- Peer-group statistics, rebuilt from `fund_analytics` after each sync run drains.
+-- Metrics are fund_analytics column names (rolling_median, max_drawdown, cagr_median, ...).
+CREATE TABLE category_analytics (
+    category      TEXT NOT NULL,
+    "window"      VARCHAR(8) NOT NULL,
+    metric        VARCHAR(32) NOT NULL,
+
+    fund_count    INT NOT NULL,
+    mean          DOUBLE PRECISION NOT NULL,
+    p25           DOUBLE PRECISION NOT NULL,
+    median        DOUBLE PRECISION NOT NULL,
+    p75           DOUBLE PRECISION NOT NULL,
+
+    computed_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (category, "window", metric)
+);
+
+-- Where each fund sits within its category, per window and metric. Percentiles are oriented so
+-- 100 / quartile 1 is the best: higher values rank better except for volatility (drawdowns are
+-- negative, so higher is better for them too).
+CREATE TABLE fund_category_ranks (
+    scheme_code   VARCHAR(20) NOT NULL,
+    "window"      VARCHAR(8) NOT NULL,
+    metric        VARCHAR(32) NOT NULL,
+
+    category      TEXT NOT NULL,
+    percentile    DOUBLE PRECISION NOT NULL, -- 0..100
+    quartile      SMALLINT NOT NULL,         -- 1 (top) .. 4
+
+    computed_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (scheme_code, "window", metric),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
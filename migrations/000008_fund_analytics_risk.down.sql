@@ -0,0 +1,4 @@
+ALTER TABLE fund_analytics
+    DROP COLUMN IF EXISTS consistency,
+    DROP COLUMN IF EXISTS sharpe,
+    DROP COLUMN IF EXISTS volatility;
@@ -0,0 +1,7 @@
+-- Risk-adjusted metrics used by composite ranking. volatility and sharpe are measured over the
+-- trailing window; consistency is the share (%) of rolling periods whose CAGR beat the
+-- risk-free rate.
+ALTER TABLE fund_analytics
+    ADD COLUMN volatility  NUMERIC(6,2),
+    ADD COLUMN sharpe      NUMERIC(6,2),
+    ADD COLUMN consistency NUMERIC(5,2);
@@ -0,0 +1,28 @@
+-- The metric columns of each fund_analytics row as they were before its NAV data last advanced,
+-- so rankings can report movement against the previous computation.
+CREATE TABLE fund_analytics_previous (
+    scheme_code    VARCHAR(20) NOT NULL,
+    "window"       VARCHAR(8) NOT NULL,
+
+    rolling_min    NUMERIC(6,2),
+    rolling_max    NUMERIC(6,2),
+    rolling_median NUMERIC(6,2),
+    rolling_p25    NUMERIC(6,2),
+    rolling_p75    NUMERIC(6,2),
+
+    max_drawdown   NUMERIC(6,2),
+
+    cagr_min       NUMERIC(6,2),
+    cagr_max       NUMERIC(6,2),
+    cagr_median    NUMERIC(6,2),
+
+    volatility     NUMERIC(6,2),
+    sharpe         NUMERIC(6,2),
+    consistency    NUMERIC(5,2),
+
+    data_end_date  DATE,
+    computed_at    TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (scheme_code, "window"),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
@@ -0,0 +1,32 @@
+-- One snapshot of the fund_analytics metrics per fund, window and data date (as_of is the
+-- row's data_end_date), so rankings can be reconstructed as of any past date. Pruned by
+-- analytics.history_retention_days.
+CREATE TABLE fund_analytics_history (
+    scheme_code    VARCHAR(20) NOT NULL,
+    "window"       VARCHAR(8) NOT NULL,
+    as_of          DATE NOT NULL,
+
+    rolling_min    NUMERIC(6,2),
+    rolling_max    NUMERIC(6,2),
+    rolling_median NUMERIC(6,2),
+    rolling_p25    NUMERIC(6,2),
+    rolling_p75    NUMERIC(6,2),
+
+    max_drawdown   NUMERIC(6,2),
+
+    cagr_min       NUMERIC(6,2),
+    cagr_max       NUMERIC(6,2),
+    cagr_median    NUMERIC(6,2),
+
+    volatility     NUMERIC(6,2),
+    sharpe         NUMERIC(6,2),
+    consistency    NUMERIC(5,2),
+
+    computed_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (scheme_code, "window", as_of),
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
+
+-- as_of rankings pick each fund's latest snapshot on or before a date across a whole window.
+CREATE INDEX idx_fund_analytics_history_window_as_of ON fund_analytics_history ("window", as_of);
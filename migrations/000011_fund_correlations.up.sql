@@ -0,0 +1,24 @@
+-- Pairwise correlation of fund returns per window and return frequency (daily|weekly), rebuilt
+-- by the scheduled correlation job. Each pair is stored once, with scheme_code_a < scheme_code_b.
+CREATE TABLE fund_correlations (
+    "window"      VARCHAR(8) NOT NULL,
+    frequency     VARCHAR(8) NOT NULL,
+    scheme_code_a VARCHAR(20) NOT NULL,
+    scheme_code_b VARCHAR(20) NOT NULL,
+
+    -- NULL when the pair is insufficient or either series is flat.
+    correlation   NUMERIC(5,4),
+    -- Returns measured on dates both funds have a NAV for.
+    observations  INT NOT NULL,
+    -- FALSE when observations fell short of the job's minimum.
+    sufficient    BOOLEAN NOT NULL,
+    period_start  DATE,
+    period_end    DATE,
+
+    computed_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY ("window", frequency, scheme_code_a, scheme_code_b),
+    CHECK (scheme_code_a < scheme_code_b),
+    FOREIGN KEY (scheme_code_a) REFERENCES funds(scheme_code),
+    FOREIGN KEY (scheme_code_b) REFERENCES funds(scheme_code)
+);
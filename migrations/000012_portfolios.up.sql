@@ -0,0 +1,21 @@
+-- Advisor model portfolios: fixed target weights over tracked funds, rebalanced back to those
+-- weights at the start of each period (or never, for buy-and-hold).
+CREATE TABLE portfolios (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    rebalance  VARCHAR(10) NOT NULL DEFAULT 'none'
+               CHECK (rebalance IN ('none', 'monthly', 'quarterly', 'yearly')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Target weights in percent; a portfolio's weights sum to 100 (enforced by the API).
+CREATE TABLE portfolio_holdings (
+    portfolio_id BIGINT NOT NULL,
+    scheme_code  VARCHAR(20) NOT NULL,
+    weight       NUMERIC(7,4) NOT NULL CHECK (weight > 0 AND weight <= 100),
+
+    PRIMARY KEY (portfolio_id, scheme_code),
+    FOREIGN KEY (portfolio_id) REFERENCES portfolios(id) ON DELETE CASCADE,
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
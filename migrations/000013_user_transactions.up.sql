@@ -0,0 +1,19 @@
+-- Investors' own transactions in tracked funds, imported from CSV or CAS text exports. Switches are recorded as a switch_out of one fund and a switch_in of another.
+CREATE TABLE user_transactions (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     VARCHAR(64) NOT NULL,
+    scheme_code VARCHAR(20) NOT NULL,
+    txn_type    VARCHAR(16) NOT NULL
+                CHECK (txn_type IN ('purchase', 'redemption', 'switch_in', 'switch_out')),
+    txn_date    DATE NOT NULL,
+    amount      NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    units       NUMERIC(18,4) NOT NULL CHECK (units > 0),
+    source      VARCHAR(8) NOT NULL CHECK (source IN ('csv', 'cas')),
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code),
+    -- Re-importing the same statement is a no-op.
+    UNIQUE (user_id, scheme_code, txn_type, txn_date, amount, units)
+);
+
+CREATE INDEX idx_user_transactions_user_date ON user_transactions (user_id, txn_date);
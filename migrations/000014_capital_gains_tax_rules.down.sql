@@ -0,0 +1 @@
+DROP TABLE IF EXISTS capital_gains_tax_rules;
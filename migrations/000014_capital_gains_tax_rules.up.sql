@@ -0,0 +1,32 @@
+-- Versioned capital gains tax rules. A rule applies to redemptions on or after effective_from until
+-- the next rule for the same asset class; rate changes are new rows, never edits.
+CREATE TABLE capital_gains_tax_rules (
+    version                 VARCHAR(32) PRIMARY KEY,
+    asset_class             VARCHAR(16) NOT NULL CHECK (asset_class IN ('equity')),
+    effective_from          DATE NOT NULL,
+    -- Units held longer than this are long term.
+    ltcg_min_holding_months INT NOT NULL CHECK (ltcg_min_holding_months > 0),
+    stcg_rate               NUMERIC(5,2) NOT NULL,
+    ltcg_rate               NUMERIC(5,2) NOT NULL,
+    -- Long-term gains exempt per financial year; the rule in force at the year end sets it.
+    ltcg_exemption          NUMERIC(12,2) NOT NULL DEFAULT 0,
+    cess_rate               NUMERIC(5,2) NOT NULL DEFAULT 0,
+    -- Units bought on or before this date may use its NAV as their cost (section 112A).
+    grandfather_date        DATE NULL,
+    description             TEXT NOT NULL DEFAULT '',
+
+    UNIQUE (asset_class, effective_from)
+);
+
+INSERT INTO capital_gains_tax_rules
+    (version, asset_class, effective_from, ltcg_min_holding_months, stcg_rate, ltcg_rate,
+     ltcg_exemption, cess_rate, grandfather_date, description)
+VALUES
+    ('equity-2004-10', 'equity', '2004-10-01', 12, 10.00, 0.00, 0, 2.00, NULL,
+     'STT regime: long-term gains exempt'),
+    ('equity-2008-04', 'equity', '2008-04-01', 12, 15.00, 0.00, 0, 3.00, NULL,
+     'Finance Act 2008: STCG 15%'),
+    ('equity-2018-04', 'equity', '2018-04-01', 12, 15.00, 10.00, 100000, 4.00, '2018-01-31',
+     'Finance Act 2018: LTCG 10% above 1 lakh, grandfathered to 31-Jan-2018'),
+    ('equity-2024-07', 'equity', '2024-07-23', 12, 20.00, 12.50, 125000, 4.00, '2018-01-31',
+     'Finance (No. 2) Act 2024: STCG 20%, LTCG 12.5% above 1.25 lakh');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_funds_category_trgm;
+DROP INDEX IF EXISTS idx_funds_amc_trgm;
+DROP INDEX IF EXISTS idx_funds_scheme_name_trgm;
@@ -0,0 +1,7 @@
+-- Trigram indexes for case-insensitive partial matching (ILIKE '%..%') and fuzzy search on
+-- GET /funds.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_funds_scheme_name_trgm ON funds USING gin (scheme_name gin_trgm_ops);
+CREATE INDEX idx_funds_amc_trgm ON funds USING gin (amc gin_trgm_ops);
+CREATE INDEX idx_funds_category_trgm ON funds USING gin (category gin_trgm_ops);
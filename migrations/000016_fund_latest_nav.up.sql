@@ -0,0 +1,16 @@
+-- The latest NAV of every fund, kept in step with nav_history by UpsertNavHistory (one statement
+-- writes both), so rankings, listings and fund details read one row per fund instead of scanning
+-- nav_history per fund.
+CREATE TABLE fund_latest_nav (
+    scheme_code VARCHAR(20) PRIMARY KEY,
+    nav_date    DATE NOT NULL,
+    nav_value   NUMERIC(10,4) NOT NULL,
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (scheme_code) REFERENCES funds(scheme_code)
+);
+
+INSERT INTO fund_latest_nav (scheme_code, nav_date, nav_value)
+SELECT DISTINCT ON (scheme_code) scheme_code, nav_date, nav_value
+FROM nav_history
+ORDER BY scheme_code, nav_date DESC;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS api_key_rate_limit_state;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,25 @@
+-- API keys. A key is "mfa_<key_id>_<secret>"; only the SHA-256 of the secret is stored, so the
+-- plaintext is shown once, when the key is created.
+CREATE TABLE api_keys (
+    key_id      VARCHAR(16) PRIMARY KEY,
+    name        TEXT NOT NULL,
+    secret_hash BYTEA NOT NULL,
+    scopes      TEXT[] NOT NULL,
+    -- read | sync:trigger | admin
+
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at  TIMESTAMP
+);
+
+-- Inbound fixed windows per key, the counterpart of rate_limiter_state (which paces outbound
+-- mfapi calls).
+CREATE TABLE api_key_rate_limit_state (
+    key_id        VARCHAR(16) NOT NULL,
+    window_type   VARCHAR(10) NOT NULL,
+    window_start  TIMESTAMP NOT NULL,
+    request_count INT NOT NULL,
+    updated_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (key_id, window_type),
+    FOREIGN KEY (key_id) REFERENCES api_keys(key_id)
+);
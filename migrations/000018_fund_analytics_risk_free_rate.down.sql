@@ -0,0 +1,2 @@
+ALTER TABLE fund_analytics
+    DROP COLUMN IF EXISTS risk_free_rate;
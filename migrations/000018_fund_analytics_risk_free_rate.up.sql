@@ -0,0 +1,4 @@
+-- The annual risk-free rate (%) sharpe and consistency were measured against, so a change of
+-- analytics.risk_free_rate_pct is visible per row. NULL for rows computed before it was stored.
+ALTER TABLE fund_analytics
+    ADD COLUMN risk_free_rate NUMERIC(5,2);